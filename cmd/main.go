@@ -1,34 +1,129 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"log"
+	"log/slog"
+	"momentum/internal/auth"
+	"momentum/internal/config"
 	"momentum/internal/database"
+	"momentum/internal/handlers"
+	"momentum/internal/logging"
+	"momentum/internal/metrics"
+	"momentum/internal/models"
 	"momentum/internal/routes"
+	"momentum/internal/store"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 )
 
 func main() {
-	// Check if the DATABASE_URL environment variable is set
-	connStr := os.Getenv("DATABASE_URL")
-	if connStr == "" {
-		log.Fatalln("DATABASE_URL environment variable is not set")
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "repair-dates" {
+		runRepairDates(os.Args[2:])
+		return
+	}
+
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Fatalln("Invalid configuration:", err)
+	}
+	level, _ := cfg.Level() // checked by config.Load
+	logging.Setup(os.Stderr, cfg.LogFormat, level)
+	slog.Info("Configuration", "config", cfg.String())
+
+	st, err := store.Open(cfg.Store, cfg.Database) // Initialize the storage backend
+	if err != nil {
+		fatal("Error opening the store", "err", err)
+	}
+	models.SetStore(st)
+	if cfg.Store != store.Memory {
+		metrics.RegisterDB(database.DB.DB, cfg.Store)
+	}
+
+	if err := models.SetWODScope(cfg.Features.WODScope); err != nil {
+		fatal("Invalid workout of the day scope", "err", err)
+	}
+	target := models.WeeklyTarget{
+		CardioMinutes:   cfg.Features.WeeklyCardioMinutes,
+		WeightsSessions: cfg.Features.WeeklyWeightsSessions,
+	}
+	if err := models.SetWeeklyTarget(target); err != nil {
+		fatal("Invalid weekly target", "err", err)
+	}
+	handlers.SetRegistrationOpen(cfg.Features.Registration)
+	handlers.SetCookieSecure(cfg.TLS.CookieSecure)
+
+	// Create or promote the bootstrap admin, if one is configured
+	if cfg.Admin.Username != "" {
+		if err := auth.EnsureAdmin(context.Background(), cfg.Admin.Username, cfg.Admin.Password); err != nil {
+			fatal("Error bootstrapping admin user", "err", err)
+		}
 	}
 
-	database.InitDB() // Initialize the database connection
+	router := routes.InitializeRoutes(cfg.StaticDir) // Initialize routes using gorilla/mux
+
+	serve(cfg, logging.AssignRequestID(logging.AccessLog(router)))
+}
 
-	router := routes.InitializeRoutes() // Initialize routes using gorilla/mux
+// serve runs the server until SIGINT or SIGTERM, then stops accepting
+// connections, drains in-flight requests and closes the database.
+func serve(cfg *config.Config, handler http.Handler) {
+	server := &http.Server{
+		Addr:              cfg.Listen,
+		Handler:           handler,
+		ReadTimeout:       cfg.Timeouts.Read,
+		ReadHeaderTimeout: cfg.Timeouts.ReadHeader,
+		WriteTimeout:      cfg.Timeouts.Write,
+		IdleTimeout:       cfg.Timeouts.Idle,
+		ErrorLog:          slog.NewLogLogger(slog.Default().Handler(), slog.LevelWarn),
+	}
 
-	// Serve static files from the "web" directory
-	router.PathPrefix("/").Handler(http.FileServer(http.Dir("./web")))
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	errc := make(chan error, 1)
+	go func() {
+		slog.Info("Starting server", "addr", cfg.Listen, "tls", cfg.TLS.CertFile != "")
+		if cfg.TLS.CertFile != "" {
+			errc <- server.ListenAndServeTLS(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+		} else {
+			errc <- server.ListenAndServe()
+		}
+	}()
 
-	router.HandleFunc("/style.css", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "text/css")
-		http.ServeFile(w, r, "web/style.css")
-	})
+	select {
+	case err := <-errc:
+		fatal("Error serving", "err", err)
+	case <-ctx.Done():
+	}
+	stop() // A second signal stops the server without waiting
 
-	log.Println("Starting server on :8080")
-	if err := http.ListenAndServe(":8080", router); err != nil {
-		log.Fatal(err)
+	slog.Info("Shutting down, draining requests", "timeout", cfg.Timeouts.Shutdown.String())
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Timeouts.Shutdown)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.Error("Error draining requests", "err", err)
+	}
+	if cfg.Store != store.Memory {
+		if err := database.CloseDB(); err != nil {
+			slog.Error("Error closing the database", "err", err)
+		}
 	}
+	slog.Info("Server stopped")
+}
+
+// fatal logs an error and exits.
+func fatal(msg string, args ...interface{}) {
+	slog.Error(msg, args...)
+	os.Exit(1)
 }
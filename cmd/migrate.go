@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"momentum/internal/config"
+	"momentum/internal/database"
+	"momentum/internal/migrations"
+	"momentum/internal/store"
+	"os"
+	"strconv"
+)
+
+// runMigrate implements `momentum migrate up|down [steps]|status`.
+func runMigrate(args []string) {
+	if len(args) == 0 {
+		log.Fatalln("usage: momentum migrate up|down [steps]|status")
+	}
+
+	cfg, err := config.LoadStore()
+	if err != nil {
+		log.Fatalln("Invalid configuration:", err)
+	}
+	if cfg.Store == store.Memory {
+		log.Fatalln("The memory store has no schema to migrate")
+	}
+	if err := database.Connect(cfg.Store, cfg.Database); err != nil {
+		log.Fatalln(err)
+	}
+	defer database.CloseDB()
+
+	switch args[0] {
+	case "up":
+		if err := migrations.Up(database.DB); err != nil {
+			log.Fatalln("Error applying migrations:", err)
+		}
+		log.Println("Database is up to date")
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				log.Fatalf("Invalid number of steps: %s", args[1])
+			}
+			steps = n
+		}
+		if err := migrations.Down(database.DB, steps); err != nil {
+			log.Fatalln("Error rolling back migrations:", err)
+		}
+	case "status":
+		statuses, err := migrations.GetStatus(database.DB)
+		if err != nil {
+			log.Fatalln("Error reading migration status:", err)
+		}
+		for _, s := range statuses {
+			state := "pending"
+			if s.Applied {
+				state = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(os.Stdout, "%04d_%-30s %s\n", s.Version, s.Name, state)
+		}
+	default:
+		log.Fatalf("Unknown migrate command %q (expected up, down or status)", args[0])
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"momentum/internal/config"
+	"momentum/internal/database"
+	"momentum/internal/models"
+	"momentum/internal/store"
+	"os"
+)
+
+// runRepairDates implements `momentum repair-dates [-dry-run]`, which dates
+// the cardio workouts logged without a date.
+func runRepairDates(args []string) {
+	flags := flag.NewFlagSet("repair-dates", flag.ExitOnError)
+	dryRun := flags.Bool("dry-run", false, "list the repairs without changing the database")
+	flags.Parse(args)
+
+	cfg, err := config.LoadStore()
+	if err != nil {
+		log.Fatalln("Invalid configuration:", err)
+	}
+	if cfg.Store == store.Memory {
+		log.Fatalln("The memory store has no stored workouts to repair")
+	}
+	st, err := store.Open(cfg.Store, cfg.Database)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	models.SetStore(st)
+	defer database.CloseDB()
+
+	repairs, err := models.RepairWorkoutDates(context.Background(), *dryRun)
+	if err != nil {
+		log.Fatalln("Error repairing workout dates:", err)
+	}
+	for _, repair := range repairs {
+		fmt.Fprintf(os.Stdout, "workout %d: %s\n", repair.WorkoutID, repair.Date.Format("2006-01-02 15:04:05 -07:00"))
+	}
+	switch {
+	case len(repairs) == 0:
+		log.Println("No workouts without a date")
+	case *dryRun:
+		log.Printf("%d workouts without a date would be repaired", len(repairs))
+	default:
+		log.Printf("Repaired %d workouts without a date", len(repairs))
+	}
+}
@@ -0,0 +1,231 @@
+package analytics
+
+import (
+	"context"
+	"math"
+	"momentum/internal/models"
+	"strings"
+	"time"
+)
+
+// splitDistances are the distances, in kilometers, of the best splits: 1 km,
+// 5 km, 10 km, a half and a full marathon.
+var splitDistances = []float64{1, 5, 10, 21.0975, 42.195}
+
+// Rolling averages cover this many periods, ending with the current one.
+const (
+	rollingWeeks  = 4
+	rollingMonths = 3
+)
+
+// CardioReport summarises the cardio training of a user over a range of days.
+// Distances are in the distance unit of the user's unit system, durations in
+// seconds, paces in minutes per distance unit and speeds in distance units per
+// hour. Paces and speeds only count workouts with a distance and are omitted
+// when there are none.
+type CardioReport struct {
+	CardioType   string              `json:"cardio_type,omitempty"` // Only this cardio type, when given
+	DistanceUnit string              `json:"distance_unit"`
+	From         time.Time           `json:"from"` // First day of the range
+	To           time.Time           `json:"to"`   // Last day of the range
+	Types        []CardioTypeSummary `json:"types"`
+	Weekly       []CardioPeriod      `json:"weekly"`
+	Monthly      []CardioPeriod      `json:"monthly"`
+}
+
+// CardioTypeSummary is the training of one cardio type over the range.
+type CardioTypeSummary struct {
+	CardioType string   `json:"cardio_type"`
+	Workouts   int      `json:"workouts"`
+	Distance   float64  `json:"distance"`
+	Duration   float64  `json:"duration"`
+	Pace       *float64 `json:"pace,omitempty"`
+	Speed      *float64 `json:"speed,omitempty"`
+	BestSplits []Split  `json:"best_splits"`
+}
+
+// Split is the fastest time over a distance, from the average pace of the
+// workouts at least that long.
+type Split struct {
+	Distance float64 `json:"distance"`
+	Seconds  float64 `json:"seconds"`
+	Pace     float64 `json:"pace"`
+}
+
+// CardioPeriod is the training of a week, starting on Monday, or a month,
+// with the rolling averages of the periods ending with it.
+type CardioPeriod struct {
+	Start           time.Time `json:"start"`
+	Workouts        int       `json:"workouts"`
+	Distance        float64   `json:"distance"`
+	Duration        float64   `json:"duration"`
+	Pace            *float64  `json:"pace,omitempty"`
+	Speed           *float64  `json:"speed,omitempty"`
+	RollingDistance float64   `json:"rolling_distance"` // Average distance per period
+	RollingDuration float64   `json:"rolling_duration"` // Average duration per period
+	RollingPace     *float64  `json:"rolling_pace,omitempty"`
+
+	pacedDuration float64
+}
+
+// Cardio builds the cardio report of a user from from up to but excluding to,
+// for every cardio type or only the one named, in a unit system. Workouts are
+// summed by the database into the weeks and months of the user's time zone;
+// the report only combines the totals.
+func Cardio(ctx context.Context, userID int, cardioType, units string, loc *time.Location, from, to time.Time) (*CardioReport, error) {
+	unit := models.DistanceUnit(units)
+	cardioType = strings.ToLower(cardioType)
+	weekly, err := models.FetchCardioTotals(ctx, userID, models.PeriodWeek, loc, from, to)
+	if err != nil {
+		return nil, err
+	}
+	monthly, err := models.FetchCardioTotals(ctx, userID, models.PeriodMonth, loc, from, to)
+	if err != nil {
+		return nil, err
+	}
+	splits, err := models.FetchCardioBestSplits(ctx, userID, splitDistances, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if cardioType != "" {
+		weekly = filterTotals(weekly, cardioType)
+		monthly = filterTotals(monthly, cardioType)
+	}
+	if unit == models.UnitMi {
+		for _, totals := range [][]models.CardioTotal{weekly, monthly} {
+			for i := range totals {
+				totals[i].Distance /= models.KmPerMile
+			}
+		}
+		for i := range splits {
+			splits[i].Distance /= models.KmPerMile
+		}
+	}
+
+	first, last := models.DayOf(from, loc), models.DayOf(to.AddDate(0, 0, -1), loc)
+	var weeks, months []time.Time
+	for week := weekOf(first); !week.After(last); week = week.AddDate(0, 0, 7) {
+		weeks = append(weeks, week)
+	}
+	for month := first.AddDate(0, 0, 1-first.Day()); !month.After(last); month = month.AddDate(0, 1, 0) {
+		months = append(months, month)
+	}
+
+	return &CardioReport{
+		CardioType:   cardioType,
+		DistanceUnit: unit,
+		From:         first,
+		To:           last,
+		Types:        typeSummaries(weekly, splits),
+		Weekly:       cardioPeriods(weekly, weeks, rollingWeeks),
+		Monthly:      cardioPeriods(monthly, months, rollingMonths),
+	}, nil
+}
+
+// filterTotals returns the totals of one cardio type.
+func filterTotals(totals []models.CardioTotal, cardioType string) []models.CardioTotal {
+	filtered := []models.CardioTotal{}
+	for _, total := range totals {
+		if total.CardioType == cardioType {
+			filtered = append(filtered, total)
+		}
+	}
+	return filtered
+}
+
+// typeSummaries adds up the totals of each cardio type, in the order the
+// types are first trained, with their best splits.
+func typeSummaries(totals []models.CardioTotal, splits []models.CardioSplit) []CardioTypeSummary {
+	summaries := []CardioTypeSummary{}
+	index := map[string]int{}
+	pacedDurations := map[string]float64{}
+	for _, total := range totals {
+		i, ok := index[total.CardioType]
+		if !ok {
+			i = len(summaries)
+			index[total.CardioType] = i
+			summaries = append(summaries, CardioTypeSummary{CardioType: total.CardioType, BestSplits: []Split{}})
+		}
+		summaries[i].Workouts += total.Workouts
+		summaries[i].Distance += total.Distance
+		summaries[i].Duration += total.Duration
+		pacedDurations[total.CardioType] += total.PacedDuration
+	}
+	for i := range summaries {
+		summary := &summaries[i]
+		summary.Pace = pace(pacedDurations[summary.CardioType], summary.Distance)
+		summary.Speed = speed(pacedDurations[summary.CardioType], summary.Distance)
+		summary.Distance = round(summary.Distance)
+	}
+	for _, split := range splits {
+		if i, ok := index[split.CardioType]; ok {
+			summaries[i].BestSplits = append(summaries[i].BestSplits, Split{
+				Distance: math.Round(split.Distance*100) / 100,
+				Seconds:  math.Round(split.Seconds),
+				Pace:     *pace(split.Seconds, split.Distance),
+			})
+		}
+	}
+	return summaries
+}
+
+// cardioPeriods adds up the totals of each period, including periods without
+// training, and their rolling averages over window periods.
+func cardioPeriods(totals []models.CardioTotal, starts []time.Time, window int) []CardioPeriod {
+	periods := make([]CardioPeriod, len(starts))
+	index := make(map[string]int, len(starts))
+	for i, start := range starts {
+		periods[i].Start = start
+		index[start.Format(time.DateOnly)] = i
+	}
+	for _, total := range totals {
+		i, ok := index[total.Period]
+		if !ok {
+			continue
+		}
+		periods[i].Workouts += total.Workouts
+		periods[i].Distance += total.Distance
+		periods[i].Duration += total.Duration
+		periods[i].pacedDuration += total.PacedDuration
+	}
+	for i := range periods {
+		period := &periods[i]
+		period.Pace = pace(period.pacedDuration, period.Distance)
+		period.Speed = speed(period.pacedDuration, period.Distance)
+		var distance, duration, pacedDuration float64
+		first := max(0, i-window+1)
+		for _, p := range periods[first : i+1] {
+			distance += p.Distance
+			duration += p.Duration
+			pacedDuration += p.pacedDuration
+		}
+		n := float64(i + 1 - first)
+		period.RollingDistance = round(distance / n)
+		period.RollingDuration = math.Round(duration / n)
+		period.RollingPace = pace(pacedDuration, distance)
+	}
+	for i := range periods {
+		periods[i].Distance = round(periods[i].Distance)
+	}
+	return periods
+}
+
+// pace returns the pace in minutes per kilometer, rounded to 0.01, or nil
+// without a distance or duration.
+func pace(seconds, distance float64) *float64 {
+	if seconds <= 0 || distance <= 0 {
+		return nil
+	}
+	v := math.Round(seconds/60/distance*100) / 100
+	return &v
+}
+
+// speed returns the speed in kilometers per hour, rounded to 0.1, or nil
+// without a distance or duration.
+func speed(seconds, distance float64) *float64 {
+	if seconds <= 0 || distance <= 0 {
+		return nil
+	}
+	v := round(distance / (seconds / 3600))
+	return &v
+}
@@ -0,0 +1,80 @@
+package analytics
+
+import (
+	"momentum/internal/models"
+	"testing"
+	"time"
+)
+
+// ptrEqual reports whether two optional values are both missing or equal.
+func ptrEqual(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func ptr(v float64) *float64 {
+	return &v
+}
+
+func TestCardioPeriods(t *testing.T) {
+	monday := time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)
+	var weeks []time.Time
+	for i := range 4 {
+		weeks = append(weeks, monday.AddDate(0, 0, 7*i))
+	}
+	totals := []models.CardioTotal{
+		{Period: "2025-03-03", CardioType: "run", Workouts: 2, Distance: 10, Duration: 3000, PacedDuration: 3000},
+		// 300 seconds without a distance count towards the duration only
+		{Period: "2025-03-17", CardioType: "run", Workouts: 2, Distance: 5, Duration: 2100, PacedDuration: 1800},
+		{Period: "2025-02-24", CardioType: "run", Workouts: 1, Distance: 8, Duration: 2400, PacedDuration: 2400},
+	}
+	want := []CardioPeriod{
+		{Start: weeks[0], Workouts: 2, Distance: 10, Duration: 3000, Pace: ptr(5), Speed: ptr(12), RollingDistance: 10, RollingDuration: 3000, RollingPace: ptr(5)},
+		{Start: weeks[1], RollingDistance: 5, RollingDuration: 1500, RollingPace: ptr(5)},
+		{Start: weeks[2], Workouts: 2, Distance: 5, Duration: 2100, Pace: ptr(6), Speed: ptr(10), RollingDistance: 2.5, RollingDuration: 1050, RollingPace: ptr(6)},
+		{Start: weeks[3], RollingDistance: 2.5, RollingDuration: 1050, RollingPace: ptr(6)},
+	}
+	got := cardioPeriods(totals, weeks, 2)
+	if len(got) != len(want) {
+		t.Fatalf("got %d periods, want %d", len(got), len(want))
+	}
+	for i := range want {
+		g, w := got[i], want[i]
+		if !g.Start.Equal(w.Start) || g.Workouts != w.Workouts || g.Distance != w.Distance || g.Duration != w.Duration ||
+			!ptrEqual(g.Pace, w.Pace) || !ptrEqual(g.Speed, w.Speed) ||
+			g.RollingDistance != w.RollingDistance || g.RollingDuration != w.RollingDuration || !ptrEqual(g.RollingPace, w.RollingPace) {
+			t.Errorf("period %d:\n got %+v\nwant %+v", i, g, w)
+		}
+	}
+}
+
+func TestTypeSummaries(t *testing.T) {
+	totals := []models.CardioTotal{
+		{Period: "2025-03-03", CardioType: "run", Workouts: 2, Distance: 10, Duration: 3000, PacedDuration: 3000},
+		{Period: "2025-03-03", CardioType: "bike", Workouts: 1, Duration: 3600},
+		{Period: "2025-03-10", CardioType: "run", Workouts: 1, Distance: 5.04, Duration: 1800, PacedDuration: 1800},
+	}
+	splits := []models.CardioSplit{
+		{CardioType: "run", Distance: 5, Seconds: 1499.6},
+		{CardioType: "swim", Distance: 1, Seconds: 1200}, // No totals in the range
+	}
+	got := typeSummaries(totals, splits)
+	if len(got) != 2 || got[0].CardioType != "run" || got[1].CardioType != "bike" {
+		t.Fatalf("got %+v, want run then bike", got)
+	}
+	run, bike := got[0], got[1]
+	if run.Workouts != 3 || run.Distance != 15 || run.Duration != 4800 || !ptrEqual(run.Pace, ptr(5.32)) || !ptrEqual(run.Speed, ptr(11.3)) {
+		t.Errorf("run: got %+v", run)
+	}
+	if len(run.BestSplits) != 1 || run.BestSplits[0] != (Split{Distance: 5, Seconds: 1500, Pace: 5}) {
+		t.Errorf("run splits: got %+v", run.BestSplits)
+	}
+	if bike.Pace != nil || bike.Speed != nil || len(bike.BestSplits) != 0 {
+		t.Errorf("bike without a distance: got %+v", bike)
+	}
+	if filtered := filterTotals(totals, "bike"); len(filtered) != 1 || filtered[0].CardioType != "bike" {
+		t.Errorf("filtered totals: got %+v", filtered)
+	}
+}
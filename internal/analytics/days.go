@@ -0,0 +1,91 @@
+package analytics
+
+import (
+	"context"
+	"momentum/internal/models"
+	"time"
+)
+
+// streakWindowDays is how many days of activity are fetched at a time while
+// counting back the current streak.
+const streakWindowDays = 90
+
+// DaysReport is the activity of a user on each day of a range, with days
+// starting at midnight in the user's time zone. Distances are in the distance
+// unit of the user's unit system and durations in seconds.
+type DaysReport struct {
+	TimeZone      string               `json:"time_zone"` // IANA name, or Local for the server's time zone
+	DistanceUnit  string               `json:"distance_unit"`
+	From          time.Time            `json:"from"`           // First day of the range
+	To            time.Time            `json:"to"`             // Last day of the range
+	Days          []models.DayActivity `json:"days"`           // Every day of the range, including rest days
+	CurrentStreak int                  `json:"current_streak"` // Days in a row with a log up to today, or yesterday while today has none yet
+	LongestStreak int                  `json:"longest_streak"` // Most days in a row with a log within the range
+}
+
+// Days builds the daily activity report of a user from from up to but
+// excluding to in a unit system. Logs are counted by the database into the
+// days of the user's time zone.
+func Days(ctx context.Context, userID int, units string, loc *time.Location, from, to time.Time) (*DaysReport, error) {
+	unit := models.DistanceUnit(units)
+	activity, err := models.FetchDailyActivity(ctx, userID, loc, from, to)
+	if err != nil {
+		return nil, err
+	}
+	byDay := make(map[string]models.DayActivity, len(activity))
+	for _, day := range activity {
+		byDay[day.Day] = day
+	}
+	current, err := currentStreak(ctx, userID, loc, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	first, last := models.DayOf(from, loc), models.DayOf(to.AddDate(0, 0, -1), loc)
+	report := &DaysReport{TimeZone: loc.String(), DistanceUnit: unit, From: first, To: last, Days: []models.DayActivity{}, CurrentStreak: current}
+	streak := 0
+	for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+		key := day.Format(time.DateOnly)
+		dayActivity, ok := byDay[key]
+		if !ok {
+			dayActivity = models.DayActivity{Day: key}
+		}
+		dayActivity.Distance = models.FromKm(dayActivity.Distance, unit)
+		report.Days = append(report.Days, dayActivity)
+		if !ok {
+			streak = 0
+			continue
+		}
+		streak++
+		report.LongestStreak = max(report.LongestStreak, streak)
+	}
+	return report, nil
+}
+
+// currentStreak counts the days in a row, in a time zone, on which the user
+// logged a workout, ending today, or yesterday when nothing has been logged
+// today yet.
+func currentStreak(ctx context.Context, userID int, loc *time.Location, now time.Time) (int, error) {
+	today := models.DayOf(now, loc)
+	active := map[string]bool{}
+	_, fetchedFrom := models.DayBounds(today, loc)
+	streak := 0
+	for day := today; ; day = day.AddDate(0, 0, -1) {
+		if start, _ := models.DayBounds(day, loc); start.Before(fetchedFrom) {
+			from, _ := models.DayBounds(day.AddDate(0, 0, 1-streakWindowDays), loc)
+			activity, err := models.FetchDailyActivity(ctx, userID, loc, from, fetchedFrom)
+			if err != nil {
+				return 0, err
+			}
+			for _, a := range activity {
+				active[a.Day] = true
+			}
+			fetchedFrom = from
+		}
+		if active[day.Format(time.DateOnly)] {
+			streak++
+		} else if !day.Equal(today) {
+			return streak, nil
+		}
+	}
+}
@@ -0,0 +1,285 @@
+package analytics
+
+import (
+	"context"
+	"math"
+	"momentum/internal/models"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxEstimateReps is the highest rep count used to estimate a one-rep max;
+// both formulas lose accuracy beyond it.
+const maxEstimateReps = 12
+
+// otherMuscleGroup is reported for exercises without a muscle group in the
+// weight workouts catalogue.
+const otherMuscleGroup = "other"
+
+// intensityZones are the zones of the intensity distribution, with their
+// lower bound in percent of the estimated one-rep max.
+var intensityZones = []struct {
+	name string
+	min  float64
+}{
+	{"<60%", 0},
+	{"60-70%", 60},
+	{"70-80%", 70},
+	{"80-90%", 80},
+	{"90%+", 90},
+}
+
+// StrengthReport summarises the weights training of a user over a range of
+// days. Weights are in the weight unit of the user's unit system, and warm-up
+// sets are left out.
+type StrengthReport struct {
+	Exercise              string            `json:"exercise,omitempty"` // Only this exercise, when given
+	WeightUnit            string            `json:"weight_unit"`
+	From                  time.Time         `json:"from"` // First day of the range
+	To                    time.Time         `json:"to"`   // Last day of the range
+	OneRepMax             []OneRepMaxPoint  `json:"one_rep_max"`
+	WeeklyTonnage         []WeekTonnage     `json:"weekly_tonnage"`
+	SetsPerMuscleGroup    []MuscleGroupSets `json:"sets_per_muscle_group"`
+	IntensityDistribution []IntensityZone   `json:"intensity_distribution"`
+}
+
+// OneRepMaxPoint is the best estimated one-rep max of an exercise on a day,
+// from the set with the best Epley estimate.
+type OneRepMaxPoint struct {
+	Date     time.Time `json:"date"`
+	Exercise string    `json:"exercise"`
+	Weight   float64   `json:"weight"` // Weight of the set
+	Reps     int       `json:"reps"`   // Reps of the set
+	Epley    float64   `json:"epley"`
+	Brzycki  float64   `json:"brzycki"`
+}
+
+// WeekTonnage is the volume lifted in a week starting on Monday.
+type WeekTonnage struct {
+	Week    time.Time `json:"week"`
+	Tonnage float64   `json:"tonnage"` // Sum of weight × reps
+	Sets    int       `json:"sets"`
+	Reps    int       `json:"reps"`
+}
+
+// MuscleGroupSets is the number of sets that trained a muscle group.
+type MuscleGroupSets struct {
+	MuscleGroup string  `json:"muscle_group"`
+	Sets        int     `json:"sets"`
+	Tonnage     float64 `json:"tonnage"`
+}
+
+// IntensityZone counts the sets lifted at a share of the exercise's best
+// estimated one-rep max in the range. Max is omitted for the top zone.
+type IntensityZone struct {
+	Zone    string   `json:"zone"`
+	Min     float64  `json:"min"`
+	Max     *float64 `json:"max,omitempty"`
+	Sets    int      `json:"sets"`
+	Percent float64  `json:"percent"` // Share of all the sets with an estimate
+}
+
+// Brzycki estimates a one-rep max as weight × 36 / (37 - reps).
+func Brzycki(weight float64, reps int) float64 {
+	if reps >= 37 {
+		return 0
+	}
+	return weight * 36 / (37 - float64(reps))
+}
+
+// liftedSet is a set counted by the report, with its weight in the unit of
+// the report.
+type liftedSet struct {
+	day      time.Time
+	exercise string
+	weight   float64
+	reps     int
+}
+
+// Strength builds the strength report of a user from from up to but excluding
+// to, for every exercise or only the one named, ignoring case, in a unit
+// system. Sets are grouped by the days of the user's time zone.
+func Strength(ctx context.Context, userID int, exercise, units string, loc *time.Location, from, to time.Time) (*StrengthReport, error) {
+	unit := models.WeightUnit(units)
+	weightsLogs, err := models.FetchWeightsLogsBetween(ctx, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	catalogue, err := models.ViewWeightWorkouts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	muscleGroups := map[string]string{}
+	for _, weightWorkout := range catalogue {
+		if weightWorkout.MuscleGroup != "" {
+			muscleGroups[strings.ToLower(weightWorkout.Exercise)] = weightWorkout.MuscleGroup
+		}
+	}
+
+	var sets []liftedSet
+	for _, weightsLog := range weightsLogs {
+		for _, e := range weightsLog.Exercises {
+			if exercise != "" && !strings.EqualFold(e.Name, exercise) {
+				continue
+			}
+			for _, set := range e.Sets {
+				if set.SetType == models.SetTypeWarmup || set.Reps < 1 || set.Weight <= 0 {
+					continue
+				}
+				sets = append(sets, liftedSet{day: models.DayOf(weightsLog.Date, loc), exercise: e.Name, weight: models.FromKg(set.WeightKg(), unit), reps: set.Reps})
+			}
+		}
+	}
+
+	return &StrengthReport{
+		Exercise:              exercise,
+		WeightUnit:            unit,
+		From:                  models.DayOf(from, loc),
+		To:                    models.DayOf(to.AddDate(0, 0, -1), loc),
+		OneRepMax:             oneRepMaxes(sets),
+		WeeklyTonnage:         weeklyTonnage(sets, loc, from, to),
+		SetsPerMuscleGroup:    setsPerMuscleGroup(sets, muscleGroups),
+		IntensityDistribution: intensityDistribution(sets),
+	}, nil
+}
+
+// oneRepMaxes returns the best estimate of each exercise on each day, in the
+// order they were lifted.
+func oneRepMaxes(sets []liftedSet) []OneRepMaxPoint {
+	points := []OneRepMaxPoint{}
+	index := map[string]int{}
+	for _, set := range sets {
+		if set.reps > maxEstimateReps {
+			continue
+		}
+		point := OneRepMaxPoint{
+			Date:     set.day,
+			Exercise: set.exercise,
+			Weight:   set.weight,
+			Reps:     set.reps,
+			Epley:    round(models.Epley(set.weight, set.reps)),
+			Brzycki:  round(Brzycki(set.weight, set.reps)),
+		}
+		key := set.day.Format(time.DateOnly) + "/" + strings.ToLower(set.exercise)
+		i, ok := index[key]
+		switch {
+		case !ok:
+			index[key] = len(points)
+			points = append(points, point)
+		case point.Epley > points[i].Epley:
+			points[i] = point
+		}
+	}
+	return points
+}
+
+// weeklyTonnage returns the volume of every week of the range, including
+// weeks without training.
+func weeklyTonnage(sets []liftedSet, loc *time.Location, from, to time.Time) []WeekTonnage {
+	weeks := []WeekTonnage{}
+	index := map[time.Time]int{}
+	last := models.DayOf(to.AddDate(0, 0, -1), loc)
+	for week := weekOf(models.DayOf(from, loc)); !week.After(last); week = week.AddDate(0, 0, 7) {
+		index[week] = len(weeks)
+		weeks = append(weeks, WeekTonnage{Week: week})
+	}
+	for _, set := range sets {
+		i, ok := index[weekOf(set.day)]
+		if !ok {
+			continue
+		}
+		weeks[i].Tonnage += set.weight * float64(set.reps)
+		weeks[i].Sets++
+		weeks[i].Reps += set.reps
+	}
+	for i := range weeks {
+		weeks[i].Tonnage = round(weeks[i].Tonnage)
+	}
+	return weeks
+}
+
+// setsPerMuscleGroup counts the sets of each muscle group, most trained first.
+func setsPerMuscleGroup(sets []liftedSet, muscleGroups map[string]string) []MuscleGroupSets {
+	groups := []MuscleGroupSets{}
+	index := map[string]int{}
+	for _, set := range sets {
+		group, ok := muscleGroups[strings.ToLower(set.exercise)]
+		if !ok {
+			group = otherMuscleGroup
+		}
+		i, ok := index[group]
+		if !ok {
+			i = len(groups)
+			index[group] = i
+			groups = append(groups, MuscleGroupSets{MuscleGroup: group})
+		}
+		groups[i].Sets++
+		groups[i].Tonnage += set.weight * float64(set.reps)
+	}
+	for i := range groups {
+		groups[i].Tonnage = round(groups[i].Tonnage)
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].Sets != groups[j].Sets {
+			return groups[i].Sets > groups[j].Sets
+		}
+		return groups[i].MuscleGroup < groups[j].MuscleGroup
+	})
+	return groups
+}
+
+// intensityDistribution counts the sets in each intensity zone, relative to
+// the best Epley estimate of their exercise over all the sets. Exercises
+// without an estimate are left out.
+func intensityDistribution(sets []liftedSet) []IntensityZone {
+	best := map[string]float64{}
+	for _, set := range sets {
+		if set.reps > maxEstimateReps {
+			continue
+		}
+		name := strings.ToLower(set.exercise)
+		best[name] = math.Max(best[name], models.Epley(set.weight, set.reps))
+	}
+
+	zones := make([]IntensityZone, len(intensityZones))
+	for i, zone := range intensityZones {
+		zones[i].Zone, zones[i].Min = zone.name, zone.min
+		if i+1 < len(intensityZones) {
+			upper := intensityZones[i+1].min
+			zones[i].Max = &upper
+		}
+	}
+	total := 0
+	for _, set := range sets {
+		oneRepMax, ok := best[strings.ToLower(set.exercise)]
+		if !ok {
+			continue
+		}
+		percent := set.weight / oneRepMax * 100
+		zone := 0
+		for i, z := range intensityZones {
+			if percent >= z.min {
+				zone = i
+			}
+		}
+		zones[zone].Sets++
+		total++
+	}
+	if total > 0 {
+		for i := range zones {
+			zones[i].Percent = round(float64(zones[i].Sets) / float64(total) * 100)
+		}
+	}
+	return zones
+}
+
+// weekOf returns the Monday of the week of a day returned by models.DayOf.
+func weekOf(day time.Time) time.Time {
+	return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+}
+
+// round rounds a value to 0.1.
+func round(v float64) float64 {
+	return math.Round(v*10) / 10
+}
@@ -0,0 +1,72 @@
+package analytics
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestStrengthSummaries(t *testing.T) {
+	monday := time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)
+	tuesday, nextWednesday := monday.AddDate(0, 0, 1), monday.AddDate(0, 0, 9)
+	sets := []liftedSet{
+		{day: tuesday, exercise: "Bench", weight: 100, reps: 5},
+		{day: tuesday, exercise: "bench", weight: 90, reps: 8},
+		{day: tuesday, exercise: "Squat", weight: 60, reps: 20}, // Too many reps for an estimate
+		{day: nextWednesday, exercise: "Bench", weight: 104, reps: 3},
+	}
+
+	wantMaxes := []OneRepMaxPoint{
+		{Date: tuesday, Exercise: "Bench", Weight: 100, Reps: 5, Epley: 116.7, Brzycki: 112.5},
+		{Date: nextWednesday, Exercise: "Bench", Weight: 104, Reps: 3, Epley: 114.4, Brzycki: 110.1},
+	}
+	if got := oneRepMaxes(sets); !slices.Equal(got, wantMaxes) {
+		t.Errorf("one-rep maxes:\n got %+v\nwant %+v", got, wantMaxes)
+	}
+
+	// The range ends on a Wednesday, so the third week is partly covered
+	wantWeeks := []WeekTonnage{
+		{Week: monday, Tonnage: 2420, Sets: 3, Reps: 33},
+		{Week: monday.AddDate(0, 0, 7), Tonnage: 312, Sets: 1, Reps: 3},
+		{Week: monday.AddDate(0, 0, 14)},
+	}
+	if got := weeklyTonnage(sets, time.UTC, monday, monday.AddDate(0, 0, 17)); !slices.Equal(got, wantWeeks) {
+		t.Errorf("weekly tonnage:\n got %+v\nwant %+v", got, wantWeeks)
+	}
+
+	wantGroups := []MuscleGroupSets{{MuscleGroup: "chest", Sets: 3, Tonnage: 1532}, {MuscleGroup: otherMuscleGroup, Sets: 1, Tonnage: 1200}}
+	if got := setsPerMuscleGroup(sets, map[string]string{"bench": "chest"}); !slices.Equal(got, wantGroups) {
+		t.Errorf("sets per muscle group:\n got %+v\nwant %+v", got, wantGroups)
+	}
+
+	// Relative to the best Bench estimate of 116.7, leaving out the Squat
+	zones := intensityDistribution(sets)
+	wantSets := []int{0, 0, 1, 2, 0}
+	wantPercents := []float64{0, 0, 33.3, 66.7, 0}
+	for i, zone := range zones {
+		if zone.Sets != wantSets[i] || zone.Percent != wantPercents[i] {
+			t.Errorf("zone %s: %d sets, %v%%; want %d, %v%%", zone.Zone, zone.Sets, zone.Percent, wantSets[i], wantPercents[i])
+		}
+		if (zone.Max == nil) != (i == len(zones)-1) {
+			t.Errorf("zone %s: max %v", zone.Zone, zone.Max)
+		}
+	}
+}
+
+func TestBrzycki(t *testing.T) {
+	if got := Brzycki(100, 1); got != 100 {
+		t.Errorf("Brzycki(100, 1) = %v, want 100", got)
+	}
+	if got := Brzycki(100, 37); got != 0 {
+		t.Errorf("Brzycki(100, 37) = %v, want 0", got)
+	}
+}
+
+func TestWeekOf(t *testing.T) {
+	monday := time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)
+	for days := range 7 {
+		if got := weekOf(monday.AddDate(0, 0, days)); !got.Equal(monday) {
+			t.Errorf("%s is in the week of %s, want %s", monday.AddDate(0, 0, days).Weekday(), got, monday)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+// Package apierror writes the JSON error responses of the API and maps the
+// errors of the models package to HTTP status codes.
+package apierror
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"momentum/internal/models"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Error codes of the responses.
+const (
+	CodeInvalidJSON    = "invalid_json"      // The body is not the JSON expected
+	CodeValidation     = "validation_failed" // Some fields are missing or out of range
+	CodeInvalidRequest = "invalid_request"   // Other bad requests
+	CodeUnauthorized   = "unauthorized"
+	CodeForbidden      = "forbidden"
+	CodeNotFound       = "not_found"
+	CodeConflict       = "conflict"
+	CodeUnavailable    = "unavailable" // The request was cancelled or timed out
+	CodeInternal       = "internal_error"
+)
+
+// Response is the body of every error response. Details name the invalid
+// fields of validation and JSON errors.
+type Response struct {
+	Code    string              `json:"code"`
+	Message string              `json:"message"`
+	Details []models.FieldError `json:"details,omitempty"`
+}
+
+// ErrEmptyBody is returned by Decode for a request without a body.
+var ErrEmptyBody = errors.New("request body is required")
+
+// BodyError is returned by Decode when the body is not valid JSON or does not
+// match the type decoded into.
+type BodyError struct {
+	Field   string // Path of the offending field, if known
+	Message string
+	Err     error
+}
+
+// Error implements error.
+func (e *BodyError) Error() string {
+	if e.Field != "" {
+		return e.Field + ": " + e.Message
+	}
+	return e.Message
+}
+
+// Unwrap returns the decoding error.
+func (e *BodyError) Unwrap() error {
+	return e.Err
+}
+
+// Decode decodes the JSON body of a request into v, rejecting unknown fields
+// and trailing data.
+func Decode(r *http.Request, v interface{}) error {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return bodyError(err)
+	}
+	if decoder.More() {
+		return &BodyError{Message: "request body must be a single JSON value"}
+	}
+	return nil
+}
+
+// bodyError describes a decoding error in terms of the request's fields.
+func bodyError(err error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var timeErr *time.ParseError
+	switch {
+	case errors.Is(err, io.EOF):
+		return &BodyError{Message: ErrEmptyBody.Error(), Err: ErrEmptyBody}
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return &BodyError{Message: "request body is truncated", Err: err}
+	case errors.As(err, &syntaxErr):
+		return &BodyError{Message: fmt.Sprintf("malformed JSON at offset %d", syntaxErr.Offset), Err: err}
+	case errors.As(err, &typeErr):
+		return &BodyError{Field: typeErr.Field, Message: "must be " + jsonKind(typeErr.Type.Kind().String()), Err: err}
+	case errors.As(err, &timeErr):
+		return &BodyError{Message: fmt.Sprintf("invalid date %s, expected an RFC 3339 timestamp", timeErr.Value), Err: err}
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return &BodyError{Field: field, Message: "is not a known field", Err: err}
+	}
+	return &BodyError{Message: err.Error(), Err: err}
+}
+
+// jsonKind names the JSON type of a Go kind.
+func jsonKind(kind string) string {
+	switch {
+	case strings.HasPrefix(kind, "int"), strings.HasPrefix(kind, "uint"), strings.HasPrefix(kind, "float"):
+		return "a number"
+	case kind == "bool":
+		return "a boolean"
+	case kind == "slice", kind == "array":
+		return "an array"
+	case kind == "struct", kind == "map":
+		return "an object"
+	}
+	return "a " + kind
+}
+
+// Write writes an error response.
+func Write(w http.ResponseWriter, status int, code, message string, details ...models.FieldError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(Response{Code: code, Message: message, Details: details})
+}
+
+// WriteErr writes the response of an error: 400 for invalid requests, 404
+// for missing records, 409 for conflicts, 503 for requests cancelled or past
+// their query deadline and 500 for anything else. The message of a 500 does
+// not reveal the error, which is logged instead.
+func WriteErr(w http.ResponseWriter, err error) {
+	var bodyErr *BodyError
+	var validationErr *models.ValidationError
+	switch {
+	case errors.As(err, &bodyErr):
+		var details []models.FieldError
+		if bodyErr.Field != "" {
+			details = append(details, models.FieldError{Field: bodyErr.Field, Message: bodyErr.Message})
+		}
+		Write(w, http.StatusBadRequest, CodeInvalidJSON, bodyErr.Error(), details...)
+	case errors.As(err, &validationErr):
+		Write(w, http.StatusBadRequest, CodeValidation, validationErr.Err.Error(), validationErr.Fields...)
+	case errors.Is(err, models.ErrInvalidCursor):
+		Write(w, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+	case errors.Is(err, models.ErrNotFound), errors.Is(err, models.ErrProgramNotFound), errors.Is(err, models.ErrNoWODs):
+		Write(w, http.StatusNotFound, CodeNotFound, err.Error())
+	case errors.Is(err, models.ErrUserExists), errors.Is(err, models.ErrConflict):
+		Write(w, http.StatusConflict, CodeConflict, err.Error())
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		Write(w, http.StatusServiceUnavailable, CodeUnavailable, "request timed out or was cancelled")
+	default:
+		slog.Error("Internal error", "err", err)
+		Write(w, http.StatusInternalServerError, CodeInternal, "internal server error")
+	}
+}
+
+// Status returns the status code WriteErr responds with for an error.
+func Status(err error) int {
+	var bodyErr *BodyError
+	var validationErr *models.ValidationError
+	switch {
+	case errors.As(err, &bodyErr), errors.As(err, &validationErr), errors.Is(err, models.ErrInvalidCursor):
+		return http.StatusBadRequest
+	case errors.Is(err, models.ErrNotFound), errors.Is(err, models.ErrProgramNotFound), errors.Is(err, models.ErrNoWODs):
+		return http.StatusNotFound
+	case errors.Is(err, models.ErrUserExists), errors.Is(err, models.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+// Log logs an error before WriteErr responds with it: at the error level when
+// the server is at fault and at the debug level for the client's mistakes.
+func Log(ctx context.Context, msg string, err error, args ...any) {
+	level := slog.LevelDebug
+	if Status(err) >= http.StatusInternalServerError {
+		level = slog.LevelError
+	}
+	slog.Log(ctx, level, msg, append(args, "err", err)...)
+}
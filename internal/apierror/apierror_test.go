@@ -0,0 +1,82 @@
+package apierror
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"momentum/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWriteErr(t *testing.T) {
+	validation := &models.ValidationError{
+		Err:    errors.New("invalid workout"),
+		Fields: []models.FieldError{{Field: "duration", Message: "must be positive"}},
+	}
+	tests := []struct {
+		err     error
+		status  int
+		code    string
+		details int
+	}{
+		{err: &BodyError{Field: "reps", Message: "must be a number"}, status: http.StatusBadRequest, code: CodeInvalidJSON, details: 1},
+		{err: fmt.Errorf("saving: %w", validation), status: http.StatusBadRequest, code: CodeValidation, details: 1},
+		{err: models.ErrInvalidCursor, status: http.StatusBadRequest, code: CodeInvalidRequest},
+		{err: fmt.Errorf("fetching: %w", models.ErrNotFound), status: http.StatusNotFound, code: CodeNotFound},
+		{err: models.ErrProgramNotFound, status: http.StatusNotFound, code: CodeNotFound},
+		{err: models.ErrNoWODs, status: http.StatusNotFound, code: CodeNotFound},
+		{err: models.ErrUserExists, status: http.StatusConflict, code: CodeConflict},
+		{err: models.ErrConflict, status: http.StatusConflict, code: CodeConflict},
+		{err: context.DeadlineExceeded, status: http.StatusServiceUnavailable, code: CodeUnavailable},
+		{err: context.Canceled, status: http.StatusServiceUnavailable, code: CodeUnavailable},
+		{err: errors.New("connection refused"), status: http.StatusInternalServerError, code: CodeInternal},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		WriteErr(w, tt.err)
+		var resp Response
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		if w.Code != tt.status || resp.Code != tt.code || len(resp.Details) != tt.details {
+			t.Errorf("%v: got %d %+v, want %d %s with %d details", tt.err, w.Code, resp, tt.status, tt.code, tt.details)
+		}
+		if status := Status(tt.err); status != w.Code {
+			t.Errorf("%v: Status returned %d, WriteErr wrote %d", tt.err, status, w.Code)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	WriteErr(w, errors.New("password for db.internal is hunter2"))
+	if strings.Contains(w.Body.String(), "hunter2") {
+		t.Errorf("internal error revealed in %s", w.Body)
+	}
+}
+
+func TestLog(t *testing.T) {
+	var buf bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+
+	tests := []struct {
+		err   error
+		level string
+	}{
+		{err: models.ErrNotFound, level: "level=DEBUG"},
+		{err: context.Canceled, level: "level=ERROR"},
+		{err: errors.New("disk full"), level: "level=ERROR"},
+	}
+	for _, tt := range tests {
+		buf.Reset()
+		Log(context.Background(), "Error saving", tt.err, "user_id", 1)
+		if !strings.Contains(buf.String(), tt.level) || !strings.Contains(buf.String(), "user_id=1") {
+			t.Errorf("%v: logged %q, want %s", tt.err, buf.String(), tt.level)
+		}
+	}
+}
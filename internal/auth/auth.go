@@ -0,0 +1,181 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"momentum/internal/apierror"
+	"momentum/internal/models"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// SessionCookie is the name of the cookie carrying the session token for
+// browser clients. API clients can send the token as a Bearer token instead.
+const SessionCookie = "momentum_session"
+
+// SessionTTL is how long a session stays valid after login.
+const SessionTTL = 30 * 24 * time.Hour
+
+type contextKey struct{}
+
+// HashPassword returns the bcrypt hash of a password.
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// CheckPassword reports whether the password matches the stored hash.
+func CheckPassword(hash, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// dummyHash is a bcrypt hash at the default cost that no password given at
+// login matches.
+const dummyHash = "$2a$10$DYZtRB2/lWec0pFHWzs3kuhkSAsd6m2QV.rfxlPjmQkhG3M0mvE.."
+
+// CheckLogin reports whether the password is the user's. A missing user is
+// checked against a dummy hash, so that logging in as an unknown username
+// takes as long as with a wrong password and does not reveal which accounts
+// exist.
+func CheckLogin(user *models.User, password string) bool {
+	if user == nil {
+		CheckPassword(dummyHash, password)
+		return false
+	}
+	return CheckPassword(user.PasswordHash, password)
+}
+
+// HashToken returns the hex SHA-256 hash under which a session token is stored.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewSession creates a session for the user and returns its token. Expired
+// sessions of every user are deleted first.
+func NewSession(ctx context.Context, userID int) (string, time.Time, error) {
+	if err := models.DeleteExpiredSessions(ctx); err != nil {
+		slog.WarnContext(ctx, "Error deleting expired sessions", "err", err)
+	}
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", time.Time{}, err
+	}
+	token := hex.EncodeToString(buf)
+	expiresAt := time.Now().Add(SessionTTL)
+	session := models.Session{TokenHash: HashToken(token), UserID: userID, ExpiresAt: expiresAt}
+	if err := models.CreateSession(ctx, session); err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expiresAt, nil
+}
+
+// TokenFromRequest extracts the session token from the Authorization header
+// or the session cookie.
+func TokenFromRequest(r *http.Request) string {
+	if header := r.Header.Get("Authorization"); strings.HasPrefix(header, "Bearer ") {
+		return strings.TrimPrefix(header, "Bearer ")
+	}
+	if cookie, err := r.Cookie(SessionCookie); err == nil {
+		return cookie.Value
+	}
+	return ""
+}
+
+// RequireUser rejects requests without a valid session with 401 and makes the
+// logged-in user available to the wrapped handler through UserFromContext.
+func RequireUser(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := TokenFromRequest(r)
+		if token == "" {
+			apierror.Write(w, http.StatusUnauthorized, apierror.CodeUnauthorized, "authentication required")
+			return
+		}
+		session, err := models.FetchSession(r.Context(), HashToken(token))
+		if err != nil {
+			apierror.WriteErr(w, err)
+			return
+		}
+		if session == nil {
+			apierror.Write(w, http.StatusUnauthorized, apierror.CodeUnauthorized, "session is invalid or has expired")
+			return
+		}
+		user, err := models.FetchUserByID(r.Context(), session.UserID)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error fetching user for session", "user_id", session.UserID, "err", err)
+			apierror.WriteErr(w, err)
+			return
+		}
+		if user == nil {
+			apierror.Write(w, http.StatusUnauthorized, apierror.CodeUnauthorized, "session is invalid or has expired")
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, user)))
+	})
+}
+
+// RequireAdmin rejects requests from users without the admin role with 403.
+// It must be installed after RequireUser.
+func RequireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := UserFromContext(r.Context())
+		if user == nil {
+			apierror.Write(w, http.StatusUnauthorized, apierror.CodeUnauthorized, "authentication required")
+			return
+		}
+		if user.Role != models.RoleAdmin {
+			slog.InfoContext(r.Context(), "Denied admin access", "user_id", user.ID, "path", r.URL.Path)
+			apierror.Write(w, http.StatusForbidden, apierror.CodeForbidden, "admin access required")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// EnsureAdmin makes sure an admin account with the given username exists,
+// creating it with the given password. An existing user with that name who is
+// not an admin may have registered it first, so they are only promoted when a
+// password is given, which replaces theirs and ends their sessions. The
+// password of an existing admin is left unchanged.
+func EnsureAdmin(ctx context.Context, username, password string) error {
+	user, err := models.FetchUserByUsername(ctx, username)
+	if err != nil {
+		return err
+	}
+	if user != nil && user.Role == models.RoleAdmin {
+		return nil
+	}
+	if password == "" {
+		return fmt.Errorf("no password configured for bootstrap admin %s", username)
+	}
+	hash, err := HashPassword(password)
+	if err != nil {
+		return err
+	}
+	if user != nil {
+		slog.WarnContext(ctx, "Promoting existing user to admin and resetting their password", "user_id", user.ID)
+		if err := models.SetUserPassword(ctx, user.ID, hash); err != nil {
+			return err
+		}
+		return models.SetUserRole(ctx, user.ID, models.RoleAdmin)
+	}
+	slog.InfoContext(ctx, "Creating bootstrap admin", "username", username)
+	_, err = models.CreateUser(ctx, username, hash, models.RoleAdmin)
+	return err
+}
+
+// UserFromContext returns the user set by RequireUser.
+func UserFromContext(ctx context.Context) *models.User {
+	user, _ := ctx.Value(contextKey{}).(*models.User)
+	return user
+}
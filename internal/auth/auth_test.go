@@ -0,0 +1,145 @@
+package auth
+
+import (
+	"context"
+	"momentum/internal/models"
+	"momentum/internal/store"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// useMemoryStore sets a new memory store as the store of the models package
+// for the rest of the test and returns it.
+func useMemoryStore(t *testing.T) models.Store {
+	s := store.NewMemory()
+	models.SetStore(s)
+	t.Cleanup(func() { models.SetStore(nil) })
+	return s
+}
+
+func TestCheckLogin(t *testing.T) {
+	hash, err := HashPassword("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &models.User{ID: 1, Username: "ada", PasswordHash: hash}
+	if !CheckLogin(user, "correct horse") {
+		t.Error("the right password was rejected")
+	}
+	if CheckLogin(user, "battery staple") {
+		t.Error("a wrong password was accepted")
+	}
+	if CheckLogin(nil, "correct horse") {
+		t.Error("an unknown user was accepted")
+	}
+	// The dummy hash costs as much to check as the hash of a real password
+	if cost, err := bcrypt.Cost([]byte(dummyHash)); err != nil || cost != bcrypt.DefaultCost {
+		t.Errorf("dummy hash has cost %d (%v), want %d", cost, err, bcrypt.DefaultCost)
+	}
+}
+
+func TestNewSessionDeletesExpiredSessions(t *testing.T) {
+	s := useMemoryStore(t)
+	ctx := context.Background()
+	user, err := models.CreateUser(ctx, "ada", "hash", models.RoleUser)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired := models.Session{TokenHash: HashToken("old"), UserID: user.ID, ExpiresAt: time.Now().Add(-time.Minute)}
+	if err := models.CreateSession(ctx, expired); err != nil {
+		t.Fatal(err)
+	}
+
+	token, expiresAt, err := NewSession(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session, err := s.FetchSession(ctx, HashToken("old")); err != nil || session != nil {
+		t.Errorf("expired session still stored: %+v, %v", session, err)
+	}
+	session, err := models.FetchSession(ctx, HashToken(token))
+	if err != nil || session == nil || session.UserID != user.ID {
+		t.Fatalf("got session %+v, %v for the new token", session, err)
+	}
+	if !session.ExpiresAt.Equal(expiresAt) {
+		t.Errorf("session expires at %s, want %s", session.ExpiresAt, expiresAt)
+	}
+}
+
+func TestRequireUserAndAdmin(t *testing.T) {
+	useMemoryStore(t)
+	ctx := context.Background()
+	login := func(username, role string) string {
+		user, err := models.CreateUser(ctx, username, "hash", role)
+		if err != nil {
+			t.Fatal(err)
+		}
+		token, _, err := NewSession(ctx, user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	userToken, adminToken := login("ada", models.RoleUser), login("grace", models.RoleAdmin)
+	expired, err := models.CreateUser(ctx, "alan", "hash", models.RoleAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := models.CreateSession(ctx, models.Session{TokenHash: HashToken("expired"), UserID: expired.ID, ExpiresAt: time.Now().Add(-time.Minute)}); err != nil {
+		t.Fatal(err)
+	}
+
+	var seen *models.User
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { seen = UserFromContext(r.Context()) })
+	tests := []struct {
+		name     string
+		bearer   string
+		cookie   string
+		admin    bool
+		status   int
+		username string
+	}{
+		{name: "no token", status: http.StatusUnauthorized},
+		{name: "unknown token", bearer: "nope", status: http.StatusUnauthorized},
+		{name: "expired session", bearer: "expired", status: http.StatusUnauthorized},
+		{name: "bearer token", bearer: userToken, status: http.StatusOK, username: "ada"},
+		{name: "cookie", cookie: userToken, status: http.StatusOK, username: "ada"},
+		{name: "user on an admin route", bearer: userToken, admin: true, status: http.StatusForbidden},
+		{name: "admin on an admin route", cookie: adminToken, admin: true, status: http.StatusOK, username: "grace"},
+		{name: "expired admin session", bearer: "expired", admin: true, status: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seen = nil
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.bearer != "" {
+				r.Header.Set("Authorization", "Bearer "+tt.bearer)
+			}
+			if tt.cookie != "" {
+				r.AddCookie(&http.Cookie{Name: SessionCookie, Value: tt.cookie})
+			}
+			var handler http.Handler = ok
+			if tt.admin {
+				handler = RequireAdmin(handler)
+			}
+			w := httptest.NewRecorder()
+			RequireUser(handler).ServeHTTP(w, r)
+			if w.Code != tt.status {
+				t.Fatalf("status %d, want %d", w.Code, tt.status)
+			}
+			if tt.username != "" && (seen == nil || seen.Username != tt.username) {
+				t.Errorf("handler saw user %+v, want %s", seen, tt.username)
+			}
+		})
+	}
+
+	w := httptest.NewRecorder()
+	RequireAdmin(ok).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("RequireAdmin without RequireUser: status %d, want 401", w.Code)
+	}
+}
@@ -0,0 +1,373 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Storage backends selected by Store.
+const (
+	StorePostgres = "postgres"
+	StoreSQLite   = "sqlite"
+	StoreMemory   = "memory"
+)
+
+// Config is the configuration of the server. Each setting is read from, in
+// increasing order of precedence, its default, the config file, the
+// environment and the command line.
+type Config struct {
+	File      string   `yaml:"-" toml:"-"`                   // Config file the settings were read from, if any
+	Listen    string   `yaml:"listen" toml:"listen"`         // Address the server listens on, as host:port
+	TLS       TLS      `yaml:"tls" toml:"tls"`               // Serve HTTPS when a certificate is set
+	StaticDir string   `yaml:"static_dir" toml:"static_dir"` // Directory of the web app
+	Store     string   `yaml:"store" toml:"store"`           // Storage backend: postgres, sqlite or memory
+	Database  Database `yaml:"database" toml:"database"`
+	Timeouts  Timeouts `yaml:"timeouts" toml:"timeouts"`
+	LogLevel  string   `yaml:"log_level" toml:"log_level"`   // debug, info, warn or error
+	LogFormat string   `yaml:"log_format" toml:"log_format"` // text or json
+	Features  Features `yaml:"features" toml:"features"`
+	Admin     Admin    `yaml:"admin" toml:"admin"`
+}
+
+// TLS holds the paths of the PEM certificate and private key files.
+// CookieSecure marks the session cookie Secure when a proxy in front of the
+// server terminates TLS instead.
+type TLS struct {
+	CertFile     string `yaml:"cert_file" toml:"cert_file"`
+	KeyFile      string `yaml:"key_file" toml:"key_file"`
+	CookieSecure bool   `yaml:"cookie_secure" toml:"cookie_secure"`
+}
+
+// Database is the connection to the SQL backends and its pool. Zero pool
+// sizes, lifetimes and timeouts mean no limit.
+type Database struct {
+	URL             string        `yaml:"url" toml:"url"`
+	MaxOpenConns    int           `yaml:"max_open_conns" toml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" toml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" toml:"conn_max_lifetime"`
+	QueryTimeout    time.Duration `yaml:"query_timeout" toml:"query_timeout"` // Deadline of each call to the store
+}
+
+// Timeouts bound how long the server spends on a connection. Zero means no
+// timeout, except for Shutdown.
+type Timeouts struct {
+	Read       time.Duration `yaml:"read" toml:"read"`               // Reading a whole request, body included
+	ReadHeader time.Duration `yaml:"read_header" toml:"read_header"` // Reading the request headers
+	Write      time.Duration `yaml:"write" toml:"write"`             // From the end of the headers to the end of the response
+	Idle       time.Duration `yaml:"idle" toml:"idle"`               // Waiting for the next request on a keep-alive connection
+	Shutdown   time.Duration `yaml:"shutdown" toml:"shutdown"`       // Draining in-flight requests on SIGINT or SIGTERM
+}
+
+// Features turns parts of the app on or off and tunes the workout of the day.
+type Features struct {
+	Registration          bool   `yaml:"registration" toml:"registration"` // Anyone may create an account
+	WODScope              string `yaml:"wod_scope" toml:"wod_scope"`       // global or user
+	WeeklyCardioMinutes   int    `yaml:"weekly_cardio_minutes" toml:"weekly_cardio_minutes"`
+	WeeklyWeightsSessions int    `yaml:"weekly_weights_sessions" toml:"weekly_weights_sessions"`
+}
+
+// Admin names the bootstrap admin created or promoted at startup.
+type Admin struct {
+	Username string `yaml:"username" toml:"username"`
+	Password string `yaml:"password" toml:"password"`
+}
+
+// Default returns the settings used when nothing else is configured.
+func Default() Config {
+	return Config{
+		Listen:    ":8080",
+		StaticDir: "./web",
+		Store:     StorePostgres,
+		Database:  Database{MaxIdleConns: 2, QueryTimeout: 10 * time.Second},
+		Timeouts: Timeouts{
+			Read:       15 * time.Second,
+			ReadHeader: 5 * time.Second,
+			Write:      30 * time.Second,
+			Idle:       60 * time.Second,
+			Shutdown:   15 * time.Second,
+		},
+		LogLevel:  "info",
+		LogFormat: "text",
+		Features: Features{
+			Registration:          true,
+			WODScope:              "global",
+			WeeklyCardioMinutes:   150,
+			WeeklyWeightsSessions: 3,
+		},
+	}
+}
+
+// Load reads the server configuration from the command line arguments, the
+// environment and the config file named by -config or MOMENTUM_CONFIG, and
+// validates it.
+func Load(args []string) (*Config, error) {
+	// The config file is read before the flags are applied so that flags
+	// win, which takes a first pass to find it.
+	scratch := Default()
+	if err := flags(&scratch).Parse(args); err != nil {
+		return nil, err
+	}
+	cfg := Default()
+	if err := cfg.loadFile(scratch.File); err != nil {
+		return nil, err
+	}
+	envErr := cfg.loadEnv()
+	if err := flags(&cfg).Parse(args); err != nil {
+		return nil, err
+	}
+	if err := errors.Join(envErr, cfg.Validate()); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// LoadStore reads the configuration from the environment and the config file
+// named by MOMENTUM_CONFIG for commands that only use the store, and
+// validates the store settings.
+func LoadStore() (*Config, error) {
+	cfg := Default()
+	if err := cfg.loadFile(""); err != nil {
+		return nil, err
+	}
+	if err := errors.Join(cfg.loadEnv(), cfg.validateStore()); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// flags returns the command line flags of the server, which set the fields of
+// c.
+func flags(c *Config) *flag.FlagSet {
+	fs := flag.NewFlagSet("momentum", flag.ContinueOnError)
+	fs.StringVar(&c.File, "config", c.File, "YAML or TOML config `file`")
+	fs.StringVar(&c.Listen, "listen", c.Listen, "`address` to listen on")
+	fs.StringVar(&c.TLS.CertFile, "tls-cert", c.TLS.CertFile, "TLS certificate `file`")
+	fs.StringVar(&c.TLS.KeyFile, "tls-key", c.TLS.KeyFile, "TLS private key `file`")
+	fs.BoolVar(&c.TLS.CookieSecure, "cookie-secure", c.TLS.CookieSecure, "mark the session cookie Secure behind a TLS-terminating proxy")
+	fs.StringVar(&c.StaticDir, "static-dir", c.StaticDir, "`directory` of the web app")
+	fs.StringVar(&c.Store, "store", c.Store, "storage backend: postgres, sqlite or memory")
+	fs.IntVar(&c.Database.MaxOpenConns, "db-max-open-conns", c.Database.MaxOpenConns, "maximum open database connections, 0 for no limit")
+	fs.IntVar(&c.Database.MaxIdleConns, "db-max-idle-conns", c.Database.MaxIdleConns, "maximum idle database connections")
+	fs.DurationVar(&c.Database.ConnMaxLifetime, "db-conn-max-lifetime", c.Database.ConnMaxLifetime, "maximum `duration` a database connection is reused, 0 for no limit")
+	fs.DurationVar(&c.Database.QueryTimeout, "db-query-timeout", c.Database.QueryTimeout, "maximum `duration` of a database query, 0 for no limit")
+	fs.DurationVar(&c.Timeouts.Read, "read-timeout", c.Timeouts.Read, "maximum `duration` to read a request")
+	fs.DurationVar(&c.Timeouts.ReadHeader, "read-header-timeout", c.Timeouts.ReadHeader, "maximum `duration` to read the request headers")
+	fs.DurationVar(&c.Timeouts.Write, "write-timeout", c.Timeouts.Write, "maximum `duration` to write a response")
+	fs.DurationVar(&c.Timeouts.Idle, "idle-timeout", c.Timeouts.Idle, "maximum `duration` to wait for the next request on a connection")
+	fs.DurationVar(&c.Timeouts.Shutdown, "shutdown-timeout", c.Timeouts.Shutdown, "maximum `duration` to drain requests when stopping")
+	fs.StringVar(&c.LogLevel, "log-level", c.LogLevel, "log `level`: debug, info, warn or error")
+	fs.StringVar(&c.LogFormat, "log-format", c.LogFormat, "log `format`: text or json")
+	fs.BoolVar(&c.Features.Registration, "registration", c.Features.Registration, "let anyone create an account")
+	fs.StringVar(&c.Features.WODScope, "wod-scope", c.Features.WODScope, "workout of the day `scope`: global or user")
+	fs.IntVar(&c.Features.WeeklyCardioMinutes, "weekly-cardio-minutes", c.Features.WeeklyCardioMinutes, "weekly cardio `minutes` aimed for")
+	fs.IntVar(&c.Features.WeeklyWeightsSessions, "weekly-weights-sessions", c.Features.WeeklyWeightsSessions, "weekly weights `sessions` aimed for")
+	return fs
+}
+
+// loadFile applies a YAML or TOML config file, told apart by its extension,
+// or the one named by MOMENTUM_CONFIG when file is empty. Settings missing
+// from the file are left as they are and unknown ones are rejected.
+func (c *Config) loadFile(file string) error {
+	if file == "" {
+		file = os.Getenv("MOMENTUM_CONFIG")
+	}
+	if file == "" {
+		return nil
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("reading config file: %w", err)
+	}
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err = dec.Decode(c); err == io.EOF {
+			err = nil
+		}
+	case ".toml":
+		var meta toml.MetaData
+		meta, err = toml.Decode(string(data), c)
+		if undecoded := meta.Undecoded(); err == nil && len(undecoded) > 0 {
+			err = fmt.Errorf("unknown setting %s", undecoded[0])
+		}
+	default:
+		return fmt.Errorf("config file %s must end in .yaml, .yml or .toml", file)
+	}
+	if err != nil {
+		return fmt.Errorf("parsing config file %s: %w", file, err)
+	}
+	c.File = file
+	return nil
+}
+
+// loadEnv applies the environment variables that are set.
+func (c *Config) loadEnv() error {
+	var errs []error
+	str := func(name string, dst *string) {
+		if v := os.Getenv(name); v != "" {
+			*dst = v
+		}
+	}
+	parse := func(name string, set func(string) error) {
+		if v := os.Getenv(name); v != "" {
+			if err := set(v); err != nil {
+				errs = append(errs, fmt.Errorf("%s: invalid value %q", name, v))
+			}
+		}
+	}
+	num := func(name string, dst *int) {
+		parse(name, func(v string) (err error) {
+			*dst, err = strconv.Atoi(v)
+			return err
+		})
+	}
+	dur := func(name string, dst *time.Duration) {
+		parse(name, func(v string) (err error) {
+			*dst, err = time.ParseDuration(v)
+			return err
+		})
+	}
+
+	str("MOMENTUM_LISTEN", &c.Listen)
+	str("MOMENTUM_TLS_CERT", &c.TLS.CertFile)
+	str("MOMENTUM_TLS_KEY", &c.TLS.KeyFile)
+	parse("MOMENTUM_COOKIE_SECURE", func(v string) (err error) {
+		c.TLS.CookieSecure, err = strconv.ParseBool(v)
+		return err
+	})
+	str("MOMENTUM_STATIC_DIR", &c.StaticDir)
+	str("MOMENTUM_STORE", &c.Store)
+	str("DATABASE_URL", &c.Database.URL)
+	num("MOMENTUM_DB_MAX_OPEN_CONNS", &c.Database.MaxOpenConns)
+	num("MOMENTUM_DB_MAX_IDLE_CONNS", &c.Database.MaxIdleConns)
+	dur("MOMENTUM_DB_CONN_MAX_LIFETIME", &c.Database.ConnMaxLifetime)
+	dur("MOMENTUM_DB_QUERY_TIMEOUT", &c.Database.QueryTimeout)
+	dur("MOMENTUM_READ_TIMEOUT", &c.Timeouts.Read)
+	dur("MOMENTUM_READ_HEADER_TIMEOUT", &c.Timeouts.ReadHeader)
+	dur("MOMENTUM_WRITE_TIMEOUT", &c.Timeouts.Write)
+	dur("MOMENTUM_IDLE_TIMEOUT", &c.Timeouts.Idle)
+	dur("MOMENTUM_SHUTDOWN_TIMEOUT", &c.Timeouts.Shutdown)
+	str("MOMENTUM_LOG_LEVEL", &c.LogLevel)
+	str("MOMENTUM_LOG_FORMAT", &c.LogFormat)
+	parse("MOMENTUM_REGISTRATION", func(v string) (err error) {
+		c.Features.Registration, err = strconv.ParseBool(v)
+		return err
+	})
+	str("MOMENTUM_WOD_SCOPE", &c.Features.WODScope)
+	num("MOMENTUM_WEEKLY_CARDIO_MINUTES", &c.Features.WeeklyCardioMinutes)
+	num("MOMENTUM_WEEKLY_WEIGHTS_SESSIONS", &c.Features.WeeklyWeightsSessions)
+	str("MOMENTUM_ADMIN_USERNAME", &c.Admin.Username)
+	str("MOMENTUM_ADMIN_PASSWORD", &c.Admin.Password)
+	return errors.Join(errs...)
+}
+
+// Validate checks every setting, reporting all the problems found. The
+// workout of the day settings are checked by the models package when applied.
+func (c *Config) Validate() error {
+	var errs []error
+	if err := c.validateStore(); err != nil {
+		errs = append(errs, err)
+	}
+	if _, _, err := net.SplitHostPort(c.Listen); err != nil {
+		errs = append(errs, fmt.Errorf("listen: %q must be host:port", c.Listen))
+	}
+	if (c.TLS.CertFile == "") != (c.TLS.KeyFile == "") {
+		errs = append(errs, errors.New("tls: cert_file and key_file must be set together"))
+	}
+	for _, file := range []string{c.TLS.CertFile, c.TLS.KeyFile} {
+		if file == "" {
+			continue
+		}
+		if _, err := os.Stat(file); err != nil {
+			errs = append(errs, fmt.Errorf("tls: %w", err))
+		}
+	}
+	if info, err := os.Stat(c.StaticDir); err != nil || !info.IsDir() {
+		errs = append(errs, fmt.Errorf("static_dir: %q is not a directory", c.StaticDir))
+	}
+	t := c.Timeouts
+	if t.Read < 0 || t.ReadHeader < 0 || t.Write < 0 || t.Idle < 0 {
+		errs = append(errs, errors.New("timeouts: cannot be negative"))
+	}
+	if t.Shutdown <= 0 {
+		errs = append(errs, errors.New("timeouts.shutdown: must be greater than 0"))
+	}
+	if _, err := c.Level(); err != nil {
+		errs = append(errs, fmt.Errorf("log_level: %q must be debug, info, warn or error", c.LogLevel))
+	}
+	if c.LogFormat != "text" && c.LogFormat != "json" {
+		errs = append(errs, fmt.Errorf("log_format: %q must be text or json", c.LogFormat))
+	}
+	if c.Admin.Password != "" && c.Admin.Username == "" {
+		errs = append(errs, errors.New("admin: password set without a username"))
+	}
+	return errors.Join(errs...)
+}
+
+// validateStore checks the storage settings.
+func (c *Config) validateStore() error {
+	var errs []error
+	switch c.Store {
+	case StorePostgres, StoreSQLite:
+		if c.Database.URL == "" {
+			errs = append(errs, errors.New("database.url: DATABASE_URL is not set"))
+		}
+	case StoreMemory:
+	default:
+		errs = append(errs, fmt.Errorf("store: %q must be postgres, sqlite or memory", c.Store))
+	}
+	if c.Database.MaxOpenConns < 0 || c.Database.MaxIdleConns < 0 || c.Database.ConnMaxLifetime < 0 || c.Database.QueryTimeout < 0 {
+		errs = append(errs, errors.New("database: pool sizes, lifetime and query timeout cannot be negative"))
+	}
+	return errors.Join(errs...)
+}
+
+// Level returns the log level.
+func (c *Config) Level() (slog.Level, error) {
+	var level slog.Level
+	err := level.UnmarshalText([]byte(c.LogLevel))
+	return level, err
+}
+
+// String describes the configuration for the logs, with secrets redacted.
+func (c Config) String() string {
+	type redacted Config
+	r := redacted(c)
+	r.Database.URL = RedactURL(r.Database.URL)
+	if r.Admin.Password != "" {
+		r.Admin.Password = "xxxxx"
+	}
+	return fmt.Sprintf("%+v", r)
+}
+
+// keywordPassword matches the password of a key=value connection string.
+var keywordPassword = regexp.MustCompile(`(?i)(password\s*=\s*)('(?:[^'\\]|\\.)*'|\S+)`)
+
+// RedactURL hides the password of a database connection string, given as a
+// URL or as key=value pairs.
+func RedactURL(connStr string) string {
+	if u, err := url.Parse(connStr); err == nil && u.Scheme != "" {
+		if q := u.Query(); q.Has("password") {
+			q.Set("password", "xxxxx")
+			u.RawQuery = q.Encode()
+		}
+		return u.Redacted()
+	}
+	return keywordPassword.ReplaceAllString(connStr, "${1}xxxxx")
+}
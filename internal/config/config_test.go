@@ -0,0 +1,99 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadPrecedence(t *testing.T) {
+	staticDir := t.TempDir()
+	file := filepath.Join(t.TempDir(), "momentum.yaml")
+	yaml := "listen: \":9000\"\nstore: memory\nstatic_dir: " + staticDir + "\nlog_level: warn\ntimeouts:\n  read: 20s\n"
+	if err := os.WriteFile(file, []byte(yaml), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		env      map[string]string
+		args     []string
+		listen   string
+		logLevel string
+		read     time.Duration
+	}{
+		{
+			name:     "file overrides defaults",
+			args:     []string{"-config", file},
+			listen:   ":9000",
+			logLevel: "warn",
+			read:     20 * time.Second,
+		},
+		{
+			name:     "environment overrides the file",
+			env:      map[string]string{"MOMENTUM_LISTEN": ":9100", "MOMENTUM_READ_TIMEOUT": "25s"},
+			args:     []string{"-config", file},
+			listen:   ":9100",
+			logLevel: "warn",
+			read:     25 * time.Second,
+		},
+		{
+			name:     "flags override the environment",
+			env:      map[string]string{"MOMENTUM_LISTEN": ":9100", "MOMENTUM_LOG_LEVEL": "error"},
+			args:     []string{"-config", file, "-listen", ":9200", "-read-timeout", "30s"},
+			listen:   ":9200",
+			logLevel: "error",
+			read:     30 * time.Second,
+		},
+		{
+			name:     "config file named by the environment",
+			env:      map[string]string{"MOMENTUM_CONFIG": file},
+			listen:   ":9000",
+			logLevel: "warn",
+			read:     20 * time.Second,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("MOMENTUM_CONFIG", "")
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			cfg, err := Load(tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Listen != tt.listen || cfg.LogLevel != tt.logLevel || cfg.Timeouts.Read != tt.read {
+				t.Errorf("got listen %q, log level %q, read timeout %s; want %q, %q, %s", cfg.Listen, cfg.LogLevel, cfg.Timeouts.Read, tt.listen, tt.logLevel, tt.read)
+			}
+			if cfg.Timeouts.Write != Default().Timeouts.Write {
+				t.Errorf("write timeout %s, want the default %s", cfg.Timeouts.Write, Default().Timeouts.Write)
+			}
+		})
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	t.Setenv("MOMENTUM_CONFIG", "")
+	tests := []struct {
+		name string
+		env  map[string]string
+		args []string
+	}{
+		{name: "missing database url", args: []string{"-store", "sqlite", "-static-dir", t.TempDir()}},
+		{name: "unknown store", args: []string{"-store", "mongo", "-static-dir", t.TempDir()}},
+		{name: "bad environment duration", env: map[string]string{"MOMENTUM_READ_TIMEOUT": "soon"}, args: []string{"-store", "memory", "-static-dir", t.TempDir()}},
+		{name: "missing static dir", args: []string{"-store", "memory", "-static-dir", filepath.Join(t.TempDir(), "missing")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			if _, err := Load(tt.args); err == nil {
+				t.Error("Load succeeded, want an error")
+			}
+		})
+	}
+}
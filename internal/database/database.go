@@ -1,147 +1,66 @@
 package database
 
 import (
-	"log"
-	"os"
+	"errors"
+	"fmt"
+	"log/slog"
+	"momentum/internal/config"
+	"momentum/internal/migrations"
 
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// Supported database kinds, as selected by the store setting.
+const (
+	Postgres = config.StorePostgres
+	SQLite   = config.StoreSQLite
 )
 
 var DB *sqlx.DB
 
-func InitDB() {
-	var err error
-	connStr := os.Getenv("DATABASE_URL")
-	if connStr == "" {
-		log.Fatalln("DATABASE_URL environment variable is not set")
+// InitDB connects to the database and applies any pending migrations.
+func InitDB(kind string, cfg config.Database) error {
+	if err := Connect(kind, cfg); err != nil {
+		return err
 	}
-	log.Println("Connecting to database with connection string:", connStr)
-	DB, err = sqlx.Connect("postgres", connStr)
-	if err != nil {
-		log.Fatalln("Error connecting to the database:", err)
+	if err := migrations.Up(DB); err != nil {
+		return fmt.Errorf("applying database migrations: %w", err)
 	}
-
-	createSchema()
+	return nil
 }
 
-// createSchema creates the necessary database schema if it doesn't exist
-func createSchema() {
-	schema := `
-    CREATE TABLE IF NOT EXISTS workouts (
-        id SERIAL PRIMARY KEY,
-        type VARCHAR(50),
-        duration FLOAT, -- Change to FLOAT to store seconds
-        distance FLOAT,
-        date TIMESTAMP
-    );
-
-    CREATE TABLE IF NOT EXISTS workout_logs (
-        id SERIAL PRIMARY KEY,
-        exercise VARCHAR(50),
-        time FLOAT, -- Change to FLOAT to store seconds
-        distance FLOAT,
-        weight FLOAT,
-        reps INT,
-        sets INT,
-        date TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-    );
-
-    CREATE TABLE IF NOT EXISTS weights_logs (
-        id SERIAL PRIMARY KEY,
-        workout_type VARCHAR(50),
-        date TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-    );
-
-    CREATE TABLE IF NOT EXISTS exercises (
-        id SERIAL PRIMARY KEY,
-        weights_log_id INT REFERENCES weights_logs(id),
-        name VARCHAR(50),
-        set1 INT,
-        set2 INT,
-        set3 INT
-    );
-
-    CREATE TABLE IF NOT EXISTS wods (
-        id SERIAL PRIMARY KEY,
-        type VARCHAR(50),
-        duration FLOAT, -- Change to FLOAT to store seconds
-        distance FLOAT,
-        date TIMESTAMP
-    );
-
-    CREATE TABLE IF NOT EXISTS weight_workouts (
-        id SERIAL PRIMARY KEY,
-        workout_type VARCHAR(50),
-        exercise VARCHAR(50)
-    );
-    `
-	DB.MustExec(schema)
-
-	// Insert initial data if the wods table is empty
-	var count int
-	err := DB.Get(&count, "SELECT COUNT(*) FROM wods")
-	if err != nil {
-		log.Fatalln("Error checking wods table:", err)
+// Connect opens the database connection and sizes its pool without touching
+// the schema.
+func Connect(kind string, cfg config.Database) error {
+	var driver string
+	switch kind {
+	case Postgres:
+		driver = "postgres"
+	case SQLite:
+		driver = "sqlite3"
+	default:
+		return fmt.Errorf("unsupported database kind %q", kind)
 	}
-
-	if count == 0 {
-		initialData := `
-        INSERT INTO wods (type, duration, distance, date) VALUES
-        ('Walk', 60, 5.0, NOW()),
-        ('Run - Intervals', 30, 5.0, NOW()),
-        ('Run', 30, 5.0, NOW()),
-        ('Crosstrainer', 30, 5.0, NOW()),
-        ('Row - 2 mins on 1 min off', 30, 5.0, NOW()),
-        ('Row - 10 x 500m', 30, 5.0, NOW()),
-        ('Row', 30, 5.0, NOW()),
-        ('Bike', 60, 20.0, NOW());
-        `
-		DB.MustExec(initialData)
+	if cfg.URL == "" {
+		return errors.New("DATABASE_URL environment variable is not set")
 	}
-
-	// Insert initial data for weight workouts if the table is empty
-	err = DB.Get(&count, "SELECT COUNT(*) FROM weight_workouts")
+	slog.Info("Connecting to database", "kind", kind, "url", config.RedactURL(cfg.URL))
+	var err error
+	DB, err = sqlx.Connect(driver, cfg.URL)
 	if err != nil {
-		log.Fatalln("Error checking weight_workouts table:", err)
+		return fmt.Errorf("connecting to the database: %w", err)
 	}
-
-	if count == 0 {
-		initialData := `
-        INSERT INTO weight_workouts (workout_type, exercise) VALUES
-        ('push', 'Flat Dumbbells'),
-        ('push', 'Flat Flys'),
-        ('push', 'Seated Dumbbell front raises'),
-        ('push', 'Seated Dumbbell side raises'),
-        ('push', 'Seated Dumbbell shoulder press'),
-        ('push', 'Tricep Pushdowns'),
-        ('push', 'Incline Smith'),
-        ('push', 'Close Grip Incline Smith'),
-        ('push', 'Overhead Rope (Cables)'),
-        ('push', 'Assisted Dips/Dip machine'),
-        ('pull', 'Deadlifts'),
-        ('pull', 'Bent Over Rows (Underhand)'),
-        ('pull', 'Shrugs (Barbell or dumbbell)'),
-        ('pull', 'Lat Pulldown'),
-        ('pull', 'Upright Rows (Barbell or Rope)'),
-        ('pull', 'Rear Delt Raises (Dumbbell)'),
-        ('pull', 'Single Preacher Dumbbell Curls'),
-        ('pull', 'EZ Bar Standing Curls'),
-        ('pull', 'Double Dumbbell Hammer Curls'),
-        ('legs', 'Barbell Squat'),
-        ('legs', 'Straight leg deadlifts'),
-        ('legs', 'Front squat (added)'),
-        ('legs', 'Leg Press'),
-        ('legs', 'Calf Raises on Leg Press'),
-        ('legs', 'Leg Extensions'),
-        ('legs', 'Hamstring curls (Machine)'),
-        ('legs', 'Dumbbell lunges'),
-        ('legs', 'Ab/Crunch Machine'),
-        ('legs', 'Captains Chair Leg or Knee Raises');
-        `
-		DB.MustExec(initialData)
+	DB.SetMaxOpenConns(cfg.MaxOpenConns)
+	DB.SetMaxIdleConns(cfg.MaxIdleConns)
+	DB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	if kind == SQLite {
+		// SQLite allows a single writer; serialising access avoids
+		// "database is locked" errors under concurrent requests.
+		DB.SetMaxOpenConns(1)
 	}
-
+	return nil
 }
 
 // GetDB returns the database connection
@@ -150,8 +69,9 @@ func GetDB() *sqlx.DB {
 }
 
 // CloseDB closes the database connection
-func CloseDB() {
+func CloseDB() error {
 	if err := DB.Close(); err != nil {
-		log.Fatalf("Error closing database: %v", err)
+		return fmt.Errorf("closing database: %w", err)
 	}
+	return nil
 }
@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"momentum/internal/apierror"
+	"momentum/internal/models"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// Admin handlers serve every table registered in models, so adding a table
+// to the admin API only takes registering it there.
+
+// adminResource returns the admin table named in the URL, writing a 404 when
+// there is none.
+func adminResource(w http.ResponseWriter, r *http.Request) (models.AdminResource, bool) {
+	table := mux.Vars(r)["table"]
+	resource, ok := models.LookupAdminResource(table)
+	if !ok {
+		apierror.Write(w, http.StatusNotFound, apierror.CodeNotFound, fmt.Sprintf("unknown table %q", table))
+	}
+	return resource, ok
+}
+
+// recordID returns the record ID in the URL.
+func recordID(r *http.Request) (int, error) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || id < 1 {
+		return 0, fieldError("id", "must be a positive integer")
+	}
+	return id, nil
+}
+
+// GetAdminTables handles the request to list the tables of the admin API
+func GetAdminTables(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.AdminResourceNames())
+}
+
+// ViewRecords handles the request to view every record of a table
+func ViewRecords(w http.ResponseWriter, r *http.Request) {
+	resource, ok := adminResource(w, r)
+	if !ok {
+		return
+	}
+	records, err := resource.List(r.Context())
+	if err != nil {
+		apierror.Log(r.Context(), "Error viewing records", err, "table", resource.Name())
+		apierror.WriteErr(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(records)
+}
+
+// GetRecord handles the request to get a record of a table by ID
+func GetRecord(w http.ResponseWriter, r *http.Request) {
+	resource, ok := adminResource(w, r)
+	if !ok {
+		return
+	}
+	id, err := recordID(r)
+	if err != nil {
+		apierror.WriteErr(w, err)
+		return
+	}
+	writeRecord(w, r, resource, id)
+}
+
+// AddRecord handles the request to add a record to a table. The ID is
+// assigned by the database.
+func AddRecord(w http.ResponseWriter, r *http.Request) {
+	resource, ok := adminResource(w, r)
+	if !ok {
+		return
+	}
+	record := resource.New()
+	if err := apierror.Decode(r, record); err != nil {
+		slog.DebugContext(r.Context(), "Error decoding record", "table", resource.Name(), "err", err)
+		apierror.WriteErr(w, err)
+		return
+	}
+	resource.SetID(record, 0)
+	if err := resource.Add(r.Context(), record); err != nil {
+		apierror.Log(r.Context(), "Error adding record", err, "table", resource.Name())
+		apierror.WriteErr(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+// ReplaceRecord handles the request to replace a record of a table with the
+// one in the body
+func ReplaceRecord(w http.ResponseWriter, r *http.Request) {
+	resource, ok := adminResource(w, r)
+	if !ok {
+		return
+	}
+	id, err := recordID(r)
+	if err != nil {
+		apierror.WriteErr(w, err)
+		return
+	}
+	updateRecord(w, r, resource, id, resource.New())
+}
+
+// PatchRecord handles the request to change the fields of a record of a table
+// given in the body, keeping the others
+func PatchRecord(w http.ResponseWriter, r *http.Request) {
+	resource, ok := adminResource(w, r)
+	if !ok {
+		return
+	}
+	id, err := recordID(r)
+	if err != nil {
+		apierror.WriteErr(w, err)
+		return
+	}
+	record, err := resource.Get(r.Context(), id)
+	if err != nil {
+		apierror.WriteErr(w, err)
+		return
+	}
+	updateRecord(w, r, resource, id, record)
+}
+
+// updateRecord decodes the body onto a record and stores it under the ID in
+// the URL, responding with the stored record.
+func updateRecord(w http.ResponseWriter, r *http.Request, resource models.AdminResource, id int, record interface{}) {
+	resource.SetID(record, 0)
+	if err := apierror.Decode(r, record); err != nil {
+		slog.DebugContext(r.Context(), "Error decoding record", "table", resource.Name(), "err", err)
+		apierror.WriteErr(w, err)
+		return
+	}
+	if bodyID := resource.ID(record); bodyID != 0 && bodyID != id {
+		apierror.WriteErr(w, fieldError("id", "must match the ID in the URL"))
+		return
+	}
+	resource.SetID(record, id)
+	if err := resource.Update(r.Context(), record); err != nil {
+		apierror.Log(r.Context(), "Error updating record", err, "table", resource.Name(), "id", id)
+		apierror.WriteErr(w, err)
+		return
+	}
+	writeRecord(w, r, resource, id)
+}
+
+// writeRecord responds with a record of a table.
+func writeRecord(w http.ResponseWriter, r *http.Request, resource models.AdminResource, id int) {
+	record, err := resource.Get(r.Context(), id)
+	if err != nil {
+		apierror.WriteErr(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(record)
+}
+
+// DeleteRecord handles the request to delete a record of a table by ID
+func DeleteRecord(w http.ResponseWriter, r *http.Request) {
+	resource, ok := adminResource(w, r)
+	if !ok {
+		return
+	}
+	id, err := recordID(r)
+	if err != nil {
+		apierror.WriteErr(w, err)
+		return
+	}
+	if err := resource.Delete(r.Context(), id); err != nil {
+		apierror.Log(r.Context(), "Error deleting record", err, "table", resource.Name(), "id", id)
+		apierror.WriteErr(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// EmptyTable handles the request to delete every record of a table
+func EmptyTable(w http.ResponseWriter, r *http.Request) {
+	resource, ok := adminResource(w, r)
+	if !ok {
+		return
+	}
+	if err := resource.Empty(r.Context()); err != nil {
+		apierror.Log(r.Context(), "Error emptying table", err, "table", resource.Name())
+		apierror.WriteErr(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
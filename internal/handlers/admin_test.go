@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"momentum/internal/models"
+	"momentum/internal/store"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestAdminResources(t *testing.T) {
+	names := models.AdminResourceNames()
+	want := []string{"cardio_types", "exercises", "programs", "weight_workouts", "weights_logs", "wods", "workouts"}
+	if !slices.Equal(names, want) {
+		t.Errorf("got tables %v, want %v", names, want)
+	}
+	for _, name := range names {
+		resource, ok := models.LookupAdminResource(name)
+		if !ok || resource.Name() != name {
+			t.Fatalf("looking up %s: got %v, %t", name, resource, ok)
+		}
+		record := resource.New()
+		resource.SetID(record, 42)
+		if id := resource.ID(record); id != 42 {
+			t.Errorf("%s: ID %d after setting 42", name, id)
+		}
+	}
+	if _, ok := models.LookupAdminResource("users"); ok {
+		t.Error("users is an admin table")
+	}
+}
+
+func TestAdminRecords(t *testing.T) {
+	models.SetStore(store.NewMemory())
+	t.Cleanup(func() { models.SetStore(nil) })
+	router := mux.NewRouter()
+	router.HandleFunc("/admin/{table}", ViewRecords).Methods("GET")
+	router.HandleFunc("/admin/{table}", AddRecord).Methods("POST")
+	router.HandleFunc("/admin/{table}", EmptyTable).Methods("DELETE")
+	router.HandleFunc("/admin/{table}/{id}", GetRecord).Methods("GET")
+	router.HandleFunc("/admin/{table}/{id}", ReplaceRecord).Methods("PUT")
+	router.HandleFunc("/admin/{table}/{id}", PatchRecord).Methods("PATCH")
+	router.HandleFunc("/admin/{table}/{id}", DeleteRecord).Methods("DELETE")
+	do := func(method, path, body string, status int) *httptest.ResponseRecorder {
+		t.Helper()
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+		if w.Code != status {
+			t.Fatalf("%s %s: status %d, want %d: %s", method, path, w.Code, status, w.Body)
+		}
+		return w
+	}
+	wods := func() []models.WOD {
+		t.Helper()
+		var wods []models.WOD
+		if err := json.NewDecoder(do("GET", "/admin/wods", "", http.StatusOK).Body).Decode(&wods); err != nil {
+			t.Fatal(err)
+		}
+		return wods
+	}
+	wod := func(path string) models.WOD {
+		t.Helper()
+		var wod models.WOD
+		if err := json.NewDecoder(do("GET", path, "", http.StatusOK).Body).Decode(&wod); err != nil {
+			t.Fatal(err)
+		}
+		return wod
+	}
+
+	do("GET", "/admin/users", "", http.StatusNotFound)
+	seeded := len(wods())
+	// The ID of a new record is assigned by the store
+	do("POST", "/admin/wods", `{"id": 999, "type": "Row", "duration": 20}`, http.StatusCreated)
+	all := wods()
+	if len(all) != seeded+1 || all[seeded].Type != "Row" || all[seeded].ID == 999 {
+		t.Fatalf("got %+v after adding a Row WOD", all)
+	}
+	path := fmt.Sprintf("/admin/wods/%d", all[seeded].ID)
+
+	do("PATCH", path, `{"distance": 5}`, http.StatusOK)
+	if got := wod(path); got.Type != "Row" || got.Duration != 20 || got.Distance != 5 {
+		t.Errorf("got %+v after patching the distance", got)
+	}
+	do("PUT", path, `{"type": "Swim"}`, http.StatusOK)
+	if got := wod(path); got.Type != "Swim" || got.Duration != 0 || got.Distance != 0 {
+		t.Errorf("got %+v after replacing the record", got)
+	}
+	do("PUT", path, `{"id": 999, "type": "Swim"}`, http.StatusBadRequest)
+	do("PUT", path, `{"type": " "}`, http.StatusBadRequest)
+	do("PATCH", path, `{"type": "Swim", "pace": 5}`, http.StatusBadRequest)
+	do("GET", "/admin/wods/x", "", http.StatusBadRequest)
+	do("GET", "/admin/wods/999", "", http.StatusNotFound)
+	do("PUT", "/admin/wods/999", `{"type": "Swim"}`, http.StatusNotFound)
+
+	do("DELETE", path, "", http.StatusNoContent)
+	do("GET", path, "", http.StatusNotFound)
+	do("DELETE", path, "", http.StatusNotFound)
+	do("DELETE", "/admin/wods", "", http.StatusNoContent)
+	if body := do("GET", "/admin/wods", "", http.StatusOK).Body.String(); strings.TrimSpace(body) != "[]" {
+		t.Errorf("got %s for an empty table, want []", body)
+	}
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"encoding/json"
+	"momentum/internal/analytics"
+	"momentum/internal/apierror"
+	"momentum/internal/auth"
+	"net/http"
+	"strings"
+)
+
+// defaultAnalyticsDays is how many days the analytics cover when no range is given.
+const defaultAnalyticsDays = 84
+
+// GetStrengthAnalytics handles the request for the caller's strength report,
+// optionally of one exercise, over a range of days
+func GetStrengthAnalytics(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	loc := user.Location()
+	from, to, err := parseDayRange(r, loc, defaultAnalyticsDays)
+	if err != nil {
+		apierror.WriteErr(w, err)
+		return
+	}
+	exercise := strings.TrimSpace(r.URL.Query().Get("exercise"))
+	report, err := analytics.Strength(r.Context(), user.ID, exercise, user.Units, loc, from, to)
+	if err != nil {
+		apierror.Log(r.Context(), "Error building strength analytics", err)
+		apierror.WriteErr(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// GetCardioAnalytics handles the request for the caller's cardio report,
+// optionally of one cardio type, over a range of days
+func GetCardioAnalytics(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	loc := user.Location()
+	from, to, err := parseDayRange(r, loc, defaultAnalyticsDays)
+	if err != nil {
+		apierror.WriteErr(w, err)
+		return
+	}
+	cardioType := strings.TrimSpace(r.URL.Query().Get("type"))
+	report, err := analytics.Cardio(r.Context(), user.ID, cardioType, user.Units, loc, from, to)
+	if err != nil {
+		apierror.Log(r.Context(), "Error building cardio analytics", err)
+		apierror.WriteErr(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// GetDailyAnalytics handles the request for the caller's activity on each day
+// of a range, in their time zone, with their training streaks
+func GetDailyAnalytics(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	loc := user.Location()
+	from, to, err := parseDayRange(r, loc, defaultAnalyticsDays)
+	if err != nil {
+		apierror.WriteErr(w, err)
+		return
+	}
+	report, err := analytics.Days(r.Context(), user.ID, user.Units, loc, from, to)
+	if err != nil {
+		apierror.Log(r.Context(), "Error building daily analytics", err)
+		apierror.WriteErr(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
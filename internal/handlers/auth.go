@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"momentum/internal/apierror"
+	"momentum/internal/auth"
+	"momentum/internal/models"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// credentials is the request body for registration and login.
+type credentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// registrationOpen is whether anyone may create an account.
+var registrationOpen = true
+
+// SetRegistrationOpen opens or closes account registration.
+func SetRegistrationOpen(open bool) {
+	registrationOpen = open
+}
+
+// cookieSecure is whether the session cookie is always marked Secure, for
+// servers behind a proxy that terminates TLS.
+var cookieSecure = false
+
+// SetCookieSecure sets whether the session cookie is marked Secure even when
+// the request did not reach the server over TLS.
+func SetCookieSecure(secure bool) {
+	cookieSecure = secure
+}
+
+// secureCookie reports whether the session cookie set in response to a
+// request is marked Secure, keeping the token off plain HTTP.
+func secureCookie(r *http.Request) bool {
+	return cookieSecure || r.TLS != nil
+}
+
+// Register handles the request to create a new user account
+func Register(w http.ResponseWriter, r *http.Request) {
+	if !registrationOpen {
+		apierror.Write(w, http.StatusForbidden, apierror.CodeForbidden, "registration is closed")
+		return
+	}
+	var creds credentials
+	if err := apierror.Decode(r, &creds); err != nil {
+		slog.DebugContext(r.Context(), "Error decoding registration request", "err", err)
+		apierror.WriteErr(w, err)
+		return
+	}
+	creds.Username = strings.TrimSpace(creds.Username)
+	if err := models.ValidateCredentials(creds.Username, creds.Password); err != nil {
+		apierror.WriteErr(w, err)
+		return
+	}
+
+	hash, err := auth.HashPassword(creds.Password)
+	if err != nil {
+		apierror.Log(r.Context(), "Error hashing password", err)
+		apierror.WriteErr(w, err)
+		return
+	}
+	user, err := models.CreateUser(r.Context(), creds.Username, hash, models.RoleUser)
+	if err != nil {
+		apierror.WriteErr(w, err)
+		return
+	}
+	slog.InfoContext(r.Context(), "Registered user", "user_id", user.ID)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(user)
+}
+
+// Login handles the request to log in and start a session
+func Login(w http.ResponseWriter, r *http.Request) {
+	var creds credentials
+	if err := apierror.Decode(r, &creds); err != nil {
+		slog.DebugContext(r.Context(), "Error decoding login request", "err", err)
+		apierror.WriteErr(w, err)
+		return
+	}
+	user, err := models.FetchUserByUsername(r.Context(), strings.TrimSpace(creds.Username))
+	if err != nil {
+		apierror.Log(r.Context(), "Error fetching user for login", err)
+		apierror.WriteErr(w, err)
+		return
+	}
+	if !auth.CheckLogin(user, creds.Password) {
+		apierror.Write(w, http.StatusUnauthorized, apierror.CodeUnauthorized, "invalid username or password")
+		return
+	}
+
+	token, expiresAt, err := auth.NewSession(r.Context(), user.ID)
+	if err != nil {
+		apierror.Log(r.Context(), "Error creating session", err, "user_id", user.ID)
+		apierror.WriteErr(w, err)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     auth.SessionCookie,
+		Value:    token,
+		Path:     "/",
+		Expires:  expiresAt,
+		HttpOnly: true,
+		Secure:   secureCookie(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		Token     string       `json:"token"`
+		ExpiresAt time.Time    `json:"expires_at"`
+		User      *models.User `json:"user"`
+	}{token, expiresAt, user})
+}
+
+// Logout handles the request to end the current session
+func Logout(w http.ResponseWriter, r *http.Request) {
+	if token := auth.TokenFromRequest(r); token != "" {
+		if err := models.DeleteSession(r.Context(), auth.HashToken(token)); err != nil {
+			apierror.Log(r.Context(), "Error deleting session", err)
+			apierror.WriteErr(w, err)
+			return
+		}
+	}
+	http.SetCookie(w, &http.Cookie{Name: auth.SessionCookie, Value: "", Path: "/", MaxAge: -1, HttpOnly: true, Secure: secureCookie(r)})
+	w.WriteHeader(http.StatusOK)
+}
+
+// GetCurrentUser handles the request to get the logged-in user
+func GetCurrentUser(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(auth.UserFromContext(r.Context()))
+}
+
+// UpdateCurrentUser handles the request to change the logged-in user's
+// preferences. Only the fields given in the body are changed.
+func UpdateCurrentUser(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Units    *string `json:"units"`
+		TimeZone *string `json:"time_zone"`
+	}
+	if err := apierror.Decode(r, &req); err != nil {
+		slog.DebugContext(r.Context(), "Error decoding user update request", "err", err)
+		apierror.WriteErr(w, err)
+		return
+	}
+	user := *auth.UserFromContext(r.Context())
+	if req.Units != nil {
+		units := strings.ToLower(strings.TrimSpace(*req.Units))
+		if err := models.SetUserUnits(r.Context(), user.ID, units); err != nil {
+			apierror.WriteErr(w, err)
+			return
+		}
+		user.Units = units
+	}
+	if req.TimeZone != nil {
+		timeZone := strings.TrimSpace(*req.TimeZone)
+		if err := models.SetUserTimeZone(r.Context(), user.ID, timeZone); err != nil {
+			apierror.WriteErr(w, err)
+			return
+		}
+		user.TimeZone = timeZone
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user)
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"momentum/internal/models"
+	"net/http"
+	"time"
+)
+
+// readyTimeout bounds how long the readiness check waits for the store.
+const readyTimeout = 2 * time.Second
+
+// health is the body of the health and readiness responses.
+type health struct {
+	Status string `json:"status"` // ok or unavailable
+}
+
+// GetHealth handles the liveness check, which succeeds while the server is
+// able to answer
+func GetHealth(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, http.StatusOK, "ok")
+}
+
+// GetReadiness handles the readiness check, which succeeds when the store
+// answers a ping
+func GetReadiness(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readyTimeout)
+	defer cancel()
+	if err := models.Ping(ctx); err != nil {
+		slog.ErrorContext(r.Context(), "Readiness check failed", "err", err)
+		writeHealth(w, http.StatusServiceUnavailable, "unavailable")
+		return
+	}
+	writeHealth(w, http.StatusOK, "ok")
+}
+
+// writeHealth responds with the status of a health check.
+func writeHealth(w http.ResponseWriter, code int, status string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(health{Status: status})
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"momentum/internal/apierror"
+	"momentum/internal/auth"
+	"momentum/internal/models"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// GetPrograms handles the request to list the available programs
+func GetPrograms(w http.ResponseWriter, r *http.Request) {
+	programs, err := models.ViewPrograms(r.Context())
+	if err != nil {
+		apierror.WriteErr(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(programs)
+}
+
+// GetProgram handles the request to get a program with its weeks, days and exercises
+func GetProgram(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		apierror.WriteErr(w, fieldError("id", "must be an integer"))
+		return
+	}
+	program, err := models.FetchProgram(r.Context(), id)
+	if err != nil {
+		apierror.WriteErr(w, err)
+		return
+	}
+	if program == nil {
+		apierror.WriteErr(w, models.ErrProgramNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(program)
+}
+
+// EnrolInProgram handles the request to start following a program. The body
+// may give a start_date (YYYY-MM-DD) for week 1, day 1; it defaults to today
+// in the user's time zone.
+func EnrolInProgram(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		apierror.WriteErr(w, fieldError("id", "must be an integer"))
+		return
+	}
+	var req struct {
+		StartDate string `json:"start_date"`
+	}
+	if err := apierror.Decode(r, &req); err != nil && !errors.Is(err, apierror.ErrEmptyBody) {
+		slog.DebugContext(r.Context(), "Error decoding enrolment request", "err", err)
+		apierror.WriteErr(w, err)
+		return
+	}
+	user := auth.UserFromContext(r.Context())
+	start := time.Now().In(user.Location())
+	if req.StartDate != "" {
+		if start, err = time.ParseInLocation(dateLayout, req.StartDate, user.Location()); err != nil {
+			apierror.WriteErr(w, fieldError("start_date", "must be a date as YYYY-MM-DD"))
+			return
+		}
+	}
+	enrolment, err := models.EnrolInProgram(r.Context(), user.ID, id, start)
+	if err != nil {
+		apierror.WriteErr(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(enrolment)
+}
+
+// GetEnrolment handles the request to get the program the user is following
+func GetEnrolment(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	enrolment, err := models.FetchActiveEnrolment(r.Context(), user.ID)
+	if err != nil {
+		apierror.WriteErr(w, err)
+		return
+	}
+	if enrolment == nil {
+		apierror.Write(w, http.StatusNotFound, apierror.CodeNotFound, "not enrolled in a program")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(enrolment)
+}
+
+// EndEnrolment handles the request to stop following the current program
+func EndEnrolment(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	if err := models.EndEnrolment(r.Context(), user.ID); err != nil {
+		apierror.WriteErr(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
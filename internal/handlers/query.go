@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"momentum/internal/models"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// errInvalidRequest is the kind of the validation errors of query parameters
+// and request fields checked by the handlers.
+var errInvalidRequest = errors.New("invalid request")
+
+// fieldError reports an invalid query parameter or request field.
+func fieldError(name, format string, args ...interface{}) error {
+	v := &models.ValidationError{Err: errInvalidRequest}
+	v.Add(name, format, args...)
+	return v
+}
+
+// dateLayout is the plain calendar date accepted by the from and to filters.
+const dateLayout = "2006-01-02"
+
+// parseLogQuery reads the filter and pagination parameters of the log
+// endpoints: from, to, type, limit, offset and cursor. Plain dates are days of
+// the user's time zone.
+func parseLogQuery(r *http.Request, userID int, loc *time.Location) (models.LogQuery, error) {
+	params := r.URL.Query()
+	q := models.LogQuery{UserID: userID, Type: params.Get("type")}
+
+	if v := params.Get("from"); v != "" {
+		from, _, err := parseDateParam(v, loc)
+		if err != nil {
+			return q, fieldError("from", "%v", err)
+		}
+		q.From = &from
+	}
+	if v := params.Get("to"); v != "" {
+		to, dateOnly, err := parseDateParam(v, loc)
+		if err != nil {
+			return q, fieldError("to", "%v", err)
+		}
+		// A plain date includes the whole of that day
+		if dateOnly {
+			to = to.AddDate(0, 0, 1)
+		}
+		q.To = &to
+	}
+	if q.From != nil && q.To != nil && !q.From.Before(*q.To) {
+		return q, fieldError("from", "must be before to")
+	}
+
+	if v := params.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			return q, fieldError("limit", "must be a positive integer")
+		}
+		q.Limit = limit
+	}
+	if v := params.Get("offset"); v != "" {
+		offset, err := strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return q, fieldError("offset", "must be a non-negative integer")
+		}
+		q.Offset = offset
+	}
+	if v := params.Get("cursor"); v != "" {
+		cursor, err := models.DecodeCursor(v)
+		if err != nil {
+			return q, fieldError("cursor", "is not a cursor returned by this endpoint")
+		}
+		q.Cursor = cursor
+	}
+	return q, nil
+}
+
+// defaultHistoryDays is how many days of history are returned when no range is given.
+const defaultHistoryDays = 30
+
+// maxRangeDays is the longest range of days a request may ask for, so a single
+// request cannot make the server build a report for thousands of years.
+const maxRangeDays = 3 * 366
+
+// parseDayRange reads the from and to parameters of endpoints that list whole
+// days of the user's time zone. Both are inclusive dates; by default the range
+// ends today and spans the given number of days, and it may span at most
+// maxRangeDays. The returned times are midnights in the time zone, and to is
+// exclusive.
+func parseDayRange(r *http.Request, loc *time.Location, days int) (time.Time, time.Time, error) {
+	params := r.URL.Query()
+	now := time.Now().In(loc)
+	to := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, loc)
+	if v := params.Get("to"); v != "" {
+		t, _, err := parseDateParam(v, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, fieldError("to", "%v", err)
+		}
+		to = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+	}
+	from := time.Date(to.Year(), to.Month(), to.Day()-days, 0, 0, 0, 0, loc)
+	if v := params.Get("from"); v != "" {
+		t, _, err := parseDateParam(v, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, fieldError("from", "%v", err)
+		}
+		from = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	}
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, fieldError("from", "must not be after to")
+	}
+	if from.AddDate(0, 0, maxRangeDays).Before(to) {
+		return time.Time{}, time.Time{}, fieldError("from", "must be at most %d days before to", maxRangeDays)
+	}
+	return from, to, nil
+}
+
+// parseDateParam parses an RFC 3339 timestamp or a YYYY-MM-DD date, reporting
+// whether the value was a plain date. Plain dates start at midnight in loc,
+// and timestamps are returned in loc.
+func parseDateParam(v string, loc *time.Location) (time.Time, bool, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t.In(loc), false, nil
+	}
+	t, err := time.ParseInLocation(dateLayout, v, loc)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("expected YYYY-MM-DD or RFC 3339 timestamp, got %q", v)
+	}
+	return t, true, nil
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"errors"
+	"momentum/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseDayRange(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		query string
+		from  time.Time
+		to    time.Time
+	}{
+		{query: "from=2025-03-01&to=2025-03-31", from: time.Date(2025, 3, 1, 0, 0, 0, 0, berlin), to: time.Date(2025, 4, 1, 0, 0, 0, 0, berlin)},
+		// The range spans the change to summer time, so it is an hour short
+		{query: "from=2025-03-29&to=2025-03-30", from: time.Date(2025, 3, 28, 23, 0, 0, 0, time.UTC), to: time.Date(2025, 3, 30, 22, 0, 0, 0, time.UTC)},
+		// A timestamp counts for its day in the user's time zone
+		{query: "from=2025-03-29T23:30:00Z&to=2025-03-29T23:30:00Z", from: time.Date(2025, 3, 30, 0, 0, 0, 0, berlin), to: time.Date(2025, 3, 31, 0, 0, 0, 0, berlin)},
+		{query: "to=2025-03-31", from: time.Date(2025, 3, 25, 0, 0, 0, 0, berlin), to: time.Date(2025, 4, 1, 0, 0, 0, 0, berlin)},
+	}
+	for _, tt := range tests {
+		from, to, err := parseDayRange(httptest.NewRequest(http.MethodGet, "/?"+tt.query, nil), berlin, 7)
+		if err != nil {
+			t.Errorf("%s: %v", tt.query, err)
+			continue
+		}
+		if !from.Equal(tt.from) || !to.Equal(tt.to) {
+			t.Errorf("%s: got %s to %s, want %s to %s", tt.query, from, to, tt.from, tt.to)
+		}
+	}
+
+	// By default the range ends with today in the user's time zone
+	from, to, err := parseDayRange(httptest.NewRequest(http.MethodGet, "/", nil), berlin, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if today := models.DayOf(time.Now(), berlin); !models.DayOf(to.Add(-time.Nanosecond), berlin).Equal(today) {
+		t.Errorf("default range ends on %s, want %s", to, today.Format(time.DateOnly))
+	}
+	if days := models.DayOf(to, berlin).Sub(models.DayOf(from, berlin)); days != 7*24*time.Hour {
+		t.Errorf("default range spans %s, want 7 days", days)
+	}
+
+	for _, query := range []string{"from=2025-04-01&to=2025-03-31", "from=2020-01-01&to=2025-03-31", "from=yesterday", "to=31/03/2025"} {
+		_, _, err := parseDayRange(httptest.NewRequest(http.MethodGet, "/?"+query, nil), berlin, 7)
+		var validationErr *models.ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Errorf("%s: got %v, want a validation error", query, err)
+		}
+	}
+}
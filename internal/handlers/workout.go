@@ -2,83 +2,150 @@ package handlers
 
 import (
 	"encoding/json"
-	"log"
+	"log/slog"
+	"momentum/internal/apierror"
+	"momentum/internal/auth"
 	"momentum/internal/models"
 	"net/http"
-
-	"github.com/gorilla/mux"
+	"strconv"
+	"time"
 )
 
-// GetWorkoutOfTheDay handles the request to get the workout of the day
+// GetWorkoutOfTheDay handles the request to get the workout of the day for
+// today in the user's time zone. Users following a program get the session it
+// prescribes for today instead.
 func GetWorkoutOfTheDay(w http.ResponseWriter, r *http.Request) {
-	wod, err := models.FetchWorkoutOfTheDay()
+	user := auth.UserFromContext(r.Context())
+	loc := user.Location()
+	session, err := models.FetchProgramSession(r.Context(), user.ID, loc, time.Now())
+	if err != nil {
+		apierror.Log(r.Context(), "Error fetching program session", err)
+		apierror.WriteErr(w, err)
+		return
+	}
+	if session != nil {
+		session.InUnits(user.Units)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(session)
+		return
+	}
+	wod, err := models.FetchWorkoutOfTheDay(r.Context(), user.ID, loc)
 	if err != nil {
-		log.Printf("Error fetching workout of the day: %v", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Log(r.Context(), "Error fetching workout of the day", err)
+		apierror.WriteErr(w, err)
 		return
 	}
+	wod.InUnits(user.Units)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(wod)
 }
 
+// GetWODHistory handles the request to get past workouts of the day
+func GetWODHistory(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+	loc := user.Location()
+	from, to, err := parseDayRange(r, loc, defaultHistoryDays)
+	if err != nil {
+		apierror.WriteErr(w, err)
+		return
+	}
+	assignments, err := models.FetchWODHistory(r.Context(), user.ID, loc, from, to)
+	if err != nil {
+		apierror.Log(r.Context(), "Error fetching WOD history", err)
+		apierror.WriteErr(w, err)
+		return
+	}
+	for i := range assignments {
+		assignments[i].InUnits(user.Units)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(assignments)
+}
+
 // LogCardioWorkout handles the request to log a cardio workout
 func LogCardioWorkout(w http.ResponseWriter, r *http.Request) {
 	var workout models.Workout
-	if err := json.NewDecoder(r.Body).Decode(&workout); err != nil {
-		log.Printf("Error decoding cardio workout log request: %v", err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := apierror.Decode(r, &workout); err != nil {
+		slog.DebugContext(r.Context(), "Error decoding cardio workout log request", "err", err)
+		apierror.WriteErr(w, err)
 		return
 	}
-	log.Printf("Received cardio workout log request: %+v", workout)
-	if err := models.SaveWorkout(workout); err != nil {
-		log.Printf("Error saving cardio workout: %v", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	user := auth.UserFromContext(r.Context())
+	result, err := models.SaveWorkout(r.Context(), user.ID, user.Units, workout)
+	if err != nil {
+		apierror.Log(r.Context(), "Error saving cardio workout", err)
+		apierror.WriteErr(w, err)
 		return
 	}
+	slog.DebugContext(r.Context(), "Logged cardio workout", "user_id", user.ID, "workout_id", result.ID, "type", workout.Type)
+	result.InUnits(user.Units)
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(result)
 }
 
 // LogWeightsWorkout handles the request to log a weights workout
 func LogWeightsWorkout(w http.ResponseWriter, r *http.Request) {
 	var weightsLog models.WeightsLog
-	if err := json.NewDecoder(r.Body).Decode(&weightsLog); err != nil {
-		log.Printf("Error decoding weights log request: %v", err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := apierror.Decode(r, &weightsLog); err != nil {
+		slog.DebugContext(r.Context(), "Error decoding weights log request", "err", err)
+		apierror.WriteErr(w, err)
 		return
 	}
-	log.Printf("Received weights log request: %+v", weightsLog)
-	if err := models.SaveWeightsLog(weightsLog); err != nil {
-		log.Printf("Error saving weights log: %v", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	user := auth.UserFromContext(r.Context())
+	result, err := models.SaveWeightsLog(r.Context(), user.ID, user.Units, weightsLog)
+	if err != nil {
+		apierror.Log(r.Context(), "Error saving weights log", err)
+		apierror.WriteErr(w, err)
 		return
 	}
+	slog.DebugContext(r.Context(), "Logged weights workout", "user_id", user.ID, "weights_log_id", result.ID, "exercises", len(weightsLog.Exercises))
+	result.InUnits(user.Units)
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(result)
 }
 
-// GetLoggedCardioWorkouts handles the request to get all logged cardio workouts
+// GetLoggedCardioWorkouts handles the request to get a page of logged cardio workouts
 func GetLoggedCardioWorkouts(w http.ResponseWriter, r *http.Request) {
-	log.Println("Received request to fetch logged cardio workouts")
-	workouts, err := models.FetchLoggedCardioWorkouts()
+	user := auth.UserFromContext(r.Context())
+	q, err := parseLogQuery(r, user.ID, user.Location())
 	if err != nil {
-		log.Printf("Error fetching logged cardio workouts: %v", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.WriteErr(w, err)
 		return
 	}
-	log.Printf("Fetched logged cardio workouts: %+v", workouts)
+	workouts, err := models.FetchLoggedCardioWorkouts(r.Context(), q)
+	if err != nil {
+		apierror.Log(r.Context(), "Error fetching logged cardio workouts", err)
+		apierror.WriteErr(w, err)
+		return
+	}
+	slog.DebugContext(r.Context(), "Fetched logged cardio workouts", "count", len(workouts.Items), "total", workouts.Total)
+	for i := range workouts.Items {
+		workouts.Items[i].InUnits(user.Units)
+	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(workouts)
 }
 
-// GetLoggedWeightsWorkouts handles the request to get all logged weights workouts
+// GetLoggedWeightsWorkouts handles the request to get a page of logged weights workouts
 func GetLoggedWeightsWorkouts(w http.ResponseWriter, r *http.Request) {
-	log.Println("Received request to fetch logged weights workouts")
-	workouts, err := models.FetchLoggedWeightsWorkouts()
+	user := auth.UserFromContext(r.Context())
+	q, err := parseLogQuery(r, user.ID, user.Location())
+	if err != nil {
+		apierror.WriteErr(w, err)
+		return
+	}
+	workouts, err := models.FetchLoggedWeightsWorkouts(r.Context(), q)
 	if err != nil {
-		log.Printf("Error fetching logged weights workouts: %v", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Log(r.Context(), "Error fetching logged weights workouts", err)
+		apierror.WriteErr(w, err)
 		return
 	}
-	log.Printf("Fetched logged weights workouts: %+v", workouts)
+	slog.DebugContext(r.Context(), "Fetched logged weights workouts", "count", len(workouts.Items), "total", workouts.Total)
+	for i := range workouts.Items {
+		workouts.Items[i].InUnits(user.Units)
+	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(workouts)
 }
@@ -87,31 +154,35 @@ func GetLoggedWeightsWorkouts(w http.ResponseWriter, r *http.Request) {
 func GetWeightWorkouts(w http.ResponseWriter, r *http.Request) {
 	workoutType := r.URL.Query().Get("type")
 	if workoutType == "" {
-		http.Error(w, "Missing workout type", http.StatusBadRequest)
+		apierror.WriteErr(w, fieldError("type", "is required"))
 		return
 	}
-	log.Printf("Fetching weight workouts for type: %s", workoutType)
-	weightWorkouts, err := models.FetchWeightWorkouts(workoutType)
+	weightWorkouts, err := models.FetchWeightWorkouts(r.Context(), workoutType)
 	if err != nil {
-		log.Printf("Error fetching weight workouts: %v", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Log(r.Context(), "Error fetching weight workouts", err)
+		apierror.WriteErr(w, err)
 		return
 	}
-	log.Printf("Fetched weight workouts: %+v", weightWorkouts)
+	slog.DebugContext(r.Context(), "Fetched weight workouts", "type", workoutType, "count", len(weightWorkouts))
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(weightWorkouts)
 }
 
 // GetLastLoggedCardioWorkout handles the request to get the last logged cardio workout
 func GetLastLoggedCardioWorkout(w http.ResponseWriter, r *http.Request) {
-	log.Println("Fetching last logged cardio workout")
-	workout, err := models.FetchLastLoggedCardioWorkout()
+	user := auth.UserFromContext(r.Context())
+	workout, err := models.FetchLastLoggedCardioWorkout(r.Context(), user.ID)
 	if err != nil {
-		log.Printf("Error fetching last logged cardio workout: %v", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Log(r.Context(), "Error fetching last logged cardio workout", err)
+		apierror.WriteErr(w, err)
 		return
 	}
-	log.Printf("Fetched last logged cardio workout: %+v", workout)
+	if workout == nil {
+		apierror.Write(w, http.StatusNotFound, apierror.CodeNotFound, "no cardio workout found")
+		return
+	}
+	slog.DebugContext(r.Context(), "Fetched last logged cardio workout", "workout_id", workout.ID)
+	workout.InUnits(user.Units)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(workout)
 }
@@ -120,225 +191,80 @@ func GetLastLoggedCardioWorkout(w http.ResponseWriter, r *http.Request) {
 func GetLastLoggedWeightsWorkout(w http.ResponseWriter, r *http.Request) {
 	workoutType := r.URL.Query().Get("type")
 	if workoutType == "" {
-		http.Error(w, "Missing workout type", http.StatusBadRequest)
+		apierror.WriteErr(w, fieldError("type", "is required"))
 		return
 	}
-	log.Printf("Fetching last logged weights workout for type: %s", workoutType)
-	weightsLog, err := models.FetchLastLoggedWeightsWorkout(workoutType)
+	user := auth.UserFromContext(r.Context())
+	weightsLog, err := models.FetchLastLoggedWeightsWorkout(r.Context(), user.ID, workoutType)
 	if err != nil {
-		log.Printf("Error fetching last logged weights workout: %v", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Log(r.Context(), "Error fetching last logged weights workout", err)
+		apierror.WriteErr(w, err)
 		return
 	}
 	if weightsLog == nil {
-		http.Error(w, "No weights workout found", http.StatusNotFound)
+		apierror.Write(w, http.StatusNotFound, apierror.CodeNotFound, "no weights workout found")
 		return
 	}
-	log.Printf("Fetched last logged weights workout: %+v", weightsLog)
+	slog.DebugContext(r.Context(), "Fetched last logged weights workout", "weights_log_id", weightsLog.ID)
+	weightsLog.InUnits(user.Units)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(weightsLog)
 }
 
-// Admin Section - Add, Update, Delete, View
-// AddRecord handles the request to add a record to a table
-func AddRecord(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	table := vars["table"]
-
-	var err error
-	switch table {
-	case "workouts":
-		var workout models.Workout
-		if err = json.NewDecoder(r.Body).Decode(&workout); err == nil {
-			err = models.AddWorkout(workout)
-		}
-	case "weights_logs":
-		var weightsLog models.WeightsLog
-		if err = json.NewDecoder(r.Body).Decode(&weightsLog); err == nil {
-			err = models.AddWeightsLog(weightsLog)
-		}
-	case "exercises":
-		var exercise models.Exercise
-		if err = json.NewDecoder(r.Body).Decode(&exercise); err == nil {
-			err = models.AddExercise(exercise)
-		}
-	case "wods":
-		var wod models.WOD
-		if err = json.NewDecoder(r.Body).Decode(&wod); err == nil {
-			err = models.AddWOD(wod)
-		}
-	case "weight_workouts":
-		var weightWorkout models.WeightWorkout
-		if err = json.NewDecoder(r.Body).Decode(&weightWorkout); err == nil {
-			err = models.AddWeightWorkout(weightWorkout)
-		}
-	default:
-		http.Error(w, "Invalid table name", http.StatusBadRequest)
-		return
-	}
-
-	if err != nil {
-		log.Printf("Error adding record to %s: %v", table, err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	w.WriteHeader(http.StatusCreated)
-}
-
-// UpdateRecord handles the request to update a record in a table
-func UpdateRecord(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	table := vars["table"]
-
-	var err error
-	switch table {
-	case "workouts":
-		var workout models.Workout
-		if err = json.NewDecoder(r.Body).Decode(&workout); err == nil {
-			log.Printf("Received update request for workouts: %+v", workout)
-			err = models.UpdateWorkout(workout)
-		}
-	case "weights_logs":
-		var weightsLog models.WeightsLog
-		if err = json.NewDecoder(r.Body).Decode(&weightsLog); err == nil {
-			log.Printf("Received update request for weights_logs: %+v", weightsLog)
-			err = models.UpdateWeightsLog(weightsLog)
-		}
-	case "exercises":
-		var exercise models.Exercise
-		if err = json.NewDecoder(r.Body).Decode(&exercise); err == nil {
-			log.Printf("Received update request for exercises: %+v", exercise)
-			err = models.UpdateExercise(exercise)
-		}
-	case "wods":
-		var wod models.WOD
-		if err = json.NewDecoder(r.Body).Decode(&wod); err == nil {
-			log.Printf("Received update request for wods: %+v", wod)
-			err = models.UpdateWOD(wod)
-		}
-	case "weight_workouts":
-		var weightWorkout models.WeightWorkout
-		if err = json.NewDecoder(r.Body).Decode(&weightWorkout); err == nil {
-			log.Printf("Received update request for weight_workouts: %+v", weightWorkout)
-			err = models.UpdateWeightWorkout(weightWorkout)
-		}
-	default:
-		http.Error(w, "Invalid table name", http.StatusBadRequest)
-		return
-	}
-
+// GetPersonalRecords handles the request to get the user's personal records.
+// The kind and exercise parameters filter them, and history=true returns
+// every record set rather than only the current ones.
+func GetPersonalRecords(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	history, _ := strconv.ParseBool(params.Get("history"))
+	user := auth.UserFromContext(r.Context())
+	records, err := models.FetchPersonalRecords(r.Context(), user.ID, params.Get("kind"), params.Get("exercise"), history)
 	if err != nil {
-		log.Printf("Error updating record in %s: %v", table, err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.WriteErr(w, err)
 		return
 	}
-
-	w.WriteHeader(http.StatusOK)
-}
-
-// DeleteRecord handles the request to delete a record from a table
-func DeleteRecord(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	table := vars["table"]
-
-	var id struct {
-		ID int `json:"id"`
-	}
-	if err := json.NewDecoder(r.Body).Decode(&id); err != nil {
-		log.Printf("Error decoding delete request: %v", err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	log.Printf("Received delete request for table %s with ID %d", table, id.ID)
-
-	var err error
-	switch table {
-	case "workouts":
-		err = models.DeleteWorkout(id.ID)
-	case "weights_logs":
-		err = models.DeleteWeightsLog(id.ID)
-	case "exercises":
-		err = models.DeleteExercise(id.ID)
-	case "wods":
-		err = models.DeleteWOD(id.ID)
-	case "weight_workouts":
-		err = models.DeleteWeightWorkout(id.ID)
-	default:
-		http.Error(w, "Invalid table name", http.StatusBadRequest)
-		return
+	for i := range records {
+		records[i].InUnits(user.Units)
 	}
-
-	if err != nil {
-		log.Printf("Error deleting record from %s: %v", table, err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	w.WriteHeader(http.StatusOK)
-}
-
-// ViewRecords handles the request to view records from a table
-func ViewRecords(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	table := vars["table"]
-
-	var records interface{}
-	var err error
-	switch table {
-	case "workouts":
-		records, err = models.ViewWorkouts()
-	case "weights_logs":
-		records, err = models.ViewWeightsLogs()
-	case "exercises":
-		records, err = models.ViewExercises()
-	case "wods":
-		records, err = models.ViewWODs()
-	case "weight_workouts":
-		records, err = models.ViewWeightWorkouts()
-	default:
-		http.Error(w, "Invalid table name", http.StatusBadRequest)
-		return
-	}
-
-	if err != nil {
-		log.Printf("Error viewing records from %s: %v", table, err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(records)
 }
 
-// EmptyTable handles the request to empty a table
-func EmptyTable(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	table := vars["table"]
-
-	var err error
-	switch table {
-	case "workouts":
-		err = models.EmptyWorkouts()
-	case "weights_logs":
-		err = models.EmptyWeightsLogs()
-	case "exercises":
-		err = models.EmptyExercises()
-	case "wods":
-		err = models.EmptyWODs()
-	case "weight_workouts":
-		err = models.EmptyWeightWorkouts()
-	default:
-		http.Error(w, "Invalid table name", http.StatusBadRequest)
+// GetWeightsSuggestion handles the request to suggest the weights and reps of
+// the next session of a workout type
+func GetWeightsSuggestion(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	opts := models.ProgressionOptions{WorkoutType: params.Get("type"), Strategy: params.Get("strategy")}
+	if opts.WorkoutType == "" {
+		apierror.WriteErr(w, fieldError("type", "is required"))
 		return
 	}
-
+	// In a fixed order, so the same request always reports the same field
+	for _, param := range []struct {
+		name  string
+		value *int
+	}{
+		{"sessions", &opts.Sessions},
+		{"min_reps", &opts.MinReps},
+		{"max_reps", &opts.MaxReps},
+	} {
+		if v := params.Get(param.name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				apierror.WriteErr(w, fieldError(param.name, "must be a positive integer"))
+				return
+			}
+			*param.value = n
+		}
+	}
+	user := auth.UserFromContext(r.Context())
+	opts.UserID, opts.Unit = user.ID, models.WeightUnit(user.Units)
+	suggestion, err := models.SuggestWeights(r.Context(), opts)
 	if err != nil {
-		log.Printf("Error emptying table %s: %v", table, err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Log(r.Context(), "Error suggesting weights", err)
+		apierror.WriteErr(w, err)
 		return
 	}
-
-	w.WriteHeader(http.StatusOK)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(suggestion)
 }
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"encoding/json"
+	"momentum/internal/apierror"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetWeightsSuggestionInvalidParams(t *testing.T) {
+	tests := []struct {
+		query string
+		field string
+	}{
+		{query: "", field: "type"},
+		{query: "type=push&sessions=0", field: "sessions"},
+		{query: "type=push&min_reps=x", field: "min_reps"},
+		{query: "type=push&max_reps=-1", field: "max_reps"},
+		{query: "type=push&max_reps=x&min_reps=x&sessions=x", field: "sessions"},
+		{query: "type=push&max_reps=x&min_reps=x", field: "min_reps"},
+	}
+	for _, tt := range tests {
+		// Repeat each request, since the first invalid field must not vary
+		for range 5 {
+			w := httptest.NewRecorder()
+			GetWeightsSuggestion(w, httptest.NewRequest(http.MethodGet, "/workout/suggest/weights?"+tt.query, nil))
+			var resp apierror.Response
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			if w.Code != http.StatusBadRequest || len(resp.Details) != 1 || resp.Details[0].Field != tt.field {
+				t.Fatalf("%q: got %d %+v, want 400 for %s", tt.query, w.Code, resp, tt.field)
+			}
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Weights logs are counted under this workout type, as their types are free
+// text.
+const WeightsType = "weights"
+
+// registry holds the metrics served by Handler.
+var registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "momentum",
+		Name:      "http_requests_total",
+		Help:      "HTTP requests served, by route, method and status code.",
+	}, []string{"route", "method", "code"})
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "momentum",
+		Name:      "http_request_duration_seconds",
+		Help:      "Time taken to serve HTTP requests, by route and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method"})
+	workoutsLogged = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "momentum",
+		Name:      "workouts_logged_total",
+		Help:      "Workouts logged, by cardio type, other for other cardio workouts, or weights.",
+	}, []string{"type"})
+	personalRecords = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "momentum",
+		Name:      "personal_records_total",
+		Help:      "Personal records set, by kind.",
+	}, []string{"kind"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpDuration,
+		workoutsLogged,
+		personalRecords,
+	)
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// RegisterDB adds the connection pool statistics of a database, labelled
+// with its name.
+func RegisterDB(db *sql.DB, name string) {
+	registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// Middleware counts and times the requests matched by a mux router, by the
+// path template of their route so that IDs in paths do not add series.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		labels := prometheus.Labels{"route": routeName(r)}
+		handler := promhttp.InstrumentHandlerCounter(httpRequests.MustCurryWith(labels), next)
+		handler = promhttp.InstrumentHandlerDuration(httpDuration.MustCurryWith(labels), handler)
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// routeName returns the path template of the route matching a request.
+func routeName(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if tpl, err := route.GetPathTemplate(); err == nil {
+			return tpl
+		}
+	}
+	return "unmatched"
+}
+
+// WorkoutLogged counts a logged workout of a type.
+func WorkoutLogged(workoutType string) {
+	workoutsLogged.WithLabelValues(workoutType).Inc()
+}
+
+// PersonalRecordSet counts a new personal record of a kind.
+func PersonalRecordSet(kind string) {
+	personalRecords.WithLabelValues(kind).Inc()
+}
@@ -0,0 +1,201 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Status describes whether a migration has been applied to the database.
+type Status struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt *time.Time
+}
+
+type appliedMigration struct {
+	Version   int       `db:"version"`
+	AppliedAt time.Time `db:"applied_at"`
+}
+
+// ensureTable creates the schema_migrations bookkeeping table if needed.
+func ensureTable(db *sqlx.DB) error {
+	_, err := db.Exec(`
+    CREATE TABLE IF NOT EXISTS schema_migrations (
+        version INT PRIMARY KEY,
+        name VARCHAR(100) NOT NULL,
+        applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+    )`)
+	return err
+}
+
+// applied returns the applied migrations keyed by version.
+func applied(db *sqlx.DB) (map[int]time.Time, error) {
+	if err := ensureTable(db); err != nil {
+		return nil, err
+	}
+	var rows []appliedMigration
+	if err := db.Select(&rows, "SELECT version, applied_at FROM schema_migrations"); err != nil {
+		return nil, err
+	}
+	versions := make(map[int]time.Time, len(rows))
+	for _, row := range rows {
+		versions[row.Version] = row.AppliedAt
+	}
+	return versions, nil
+}
+
+// Up applies every pending migration in version order.
+func Up(db *sqlx.DB) error {
+	done, err := applied(db)
+	if err != nil {
+		return err
+	}
+	for _, m := range All {
+		if _, ok := done[m.Version]; ok {
+			continue
+		}
+		slog.Info("Applying migration", "version", m.Version, "name", m.Name)
+		if err := run(db, statements(db, m, m.Up), false, "INSERT INTO schema_migrations (version, name) VALUES ($1, $2)", m.Version, m.Name); err != nil {
+			return fmt.Errorf("migration %d_%s: %w", m.Version, m.Name, err)
+		}
+	}
+	return nil
+}
+
+// Down rolls back the given number of most recently applied migrations.
+func Down(db *sqlx.DB, steps int) error {
+	done, err := applied(db)
+	if err != nil {
+		return err
+	}
+	for i := len(All) - 1; i >= 0 && steps > 0; i-- {
+		m := All[i]
+		if _, ok := done[m.Version]; !ok {
+			continue
+		}
+		slog.Info("Rolling back migration", "version", m.Version, "name", m.Name)
+		down, rebuild := m.Down, false
+		if db.DriverName() == "sqlite3" && m.SQLiteDown != "" {
+			down, rebuild = m.SQLiteDown, true
+		}
+		if err := run(db, statements(db, m, down), rebuild, "DELETE FROM schema_migrations WHERE version = $1", m.Version); err != nil {
+			return fmt.Errorf("rollback %d_%s: %w", m.Version, m.Name, err)
+		}
+		steps--
+	}
+	return nil
+}
+
+// GetStatus reports the state of every known migration.
+func GetStatus(db *sqlx.DB) ([]Status, error) {
+	done, err := applied(db)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]Status, 0, len(All))
+	for _, m := range All {
+		status := Status{Version: m.Version, Name: m.Name}
+		if at, ok := done[m.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = &at
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// sqliteReplacer rewrites the Postgres-specific parts of migration SQL.
+var sqliteReplacer = strings.NewReplacer(
+	"SERIAL PRIMARY KEY", "INTEGER PRIMARY KEY AUTOINCREMENT",
+	"NOW()", "CURRENT_TIMESTAMP",
+)
+
+// statements returns the SQL to execute for one direction of a migration on
+// the database's dialect.
+func statements(db *sqlx.DB, m Migration, sql string) string {
+	if db.DriverName() != "sqlite3" {
+		if m.SQLiteOnly {
+			return ""
+		}
+		return sql
+	}
+	if m.PostgresOnly {
+		return ""
+	}
+	return sqliteReplacer.Replace(sql)
+}
+
+// run executes a migration body and its bookkeeping statement in a single
+// transaction so a failed migration leaves no partial state behind. A SQLite
+// table rebuild runs with foreign key enforcement off, since dropping a
+// referenced table would fail or cascade, and the keys are checked before
+// committing instead.
+func run(db *sqlx.DB, body string, rebuild bool, bookkeeping string, args ...interface{}) error {
+	ctx := context.Background()
+	conn, err := db.Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if rebuild {
+		var enforced bool
+		if err := conn.GetContext(ctx, &enforced, "PRAGMA foreign_keys"); err != nil {
+			return err
+		}
+		if enforced {
+			if _, err := conn.ExecContext(ctx, "PRAGMA foreign_keys = OFF"); err != nil {
+				return err
+			}
+			defer conn.ExecContext(ctx, "PRAGMA foreign_keys = ON")
+		}
+	}
+	tx, err := conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(body) != "" {
+		if _, err := tx.Exec(body); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if rebuild {
+		if err := checkForeignKeys(tx); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if _, err := tx.Exec(db.Rebind(bookkeeping), args...); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// checkForeignKeys fails if any row of a SQLite database references a
+// missing parent row.
+func checkForeignKeys(tx *sqlx.Tx) error {
+	rows, err := tx.Query("PRAGMA foreign_key_check")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	if rows.Next() {
+		var table string
+		var rowID sql.NullInt64
+		var parent string
+		var fkID int
+		if err := rows.Scan(&table, &rowID, &parent, &fkID); err != nil {
+			return err
+		}
+		return fmt.Errorf("row %d of %s references a missing %s row", rowID.Int64, table, parent)
+	}
+	return rows.Err()
+}
@@ -0,0 +1,140 @@
+package migrations
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// openSQLite returns a migrated in-memory SQLite database with a user, a
+// cardio workout and a weights log.
+func openSQLite(t *testing.T, dsn string) *sqlx.DB {
+	t.Helper()
+	db, err := sqlx.Connect("sqlite3", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	if err := Up(db); err != nil {
+		t.Fatal(err)
+	}
+	db.MustExec(`INSERT INTO users (id, username, password_hash) VALUES (1, 'ada', 'hash')`)
+	db.MustExec(`INSERT INTO sessions (token_hash, user_id, expires_at) VALUES ('token', 1, '2030-01-01 00:00:00+00:00')`)
+	db.MustExec(`INSERT INTO workouts (user_id, type, cardio_type_id, duration, distance, date) VALUES (1, 'Run', 1, 1800, 5, '2025-03-01 07:30:00+00:00')`)
+	db.MustExec(`INSERT INTO weights_logs (user_id, workout_type, date) VALUES (1, 'push', '2025-03-02 18:00:00+00:00')`)
+	return db
+}
+
+func TestDownAndUpOnSQLite(t *testing.T) {
+	dsns := map[string]string{
+		"foreign keys off": ":memory:",
+		"foreign keys on":  "file::memory:?_foreign_keys=1",
+	}
+	steps := []int{1, 5, 10, 13, len(All) - 1, len(All)}
+	for name, dsn := range dsns {
+		for _, n := range steps {
+			t.Run(fmt.Sprintf("%s/down %d", name, n), func(t *testing.T) {
+				db := openSQLite(t, dsn)
+				if err := Down(db, n); err != nil {
+					t.Fatalf("rolling back %d migrations: %v", n, err)
+				}
+				if got := countApplied(t, db); got != len(All)-n {
+					t.Fatalf("%d migrations applied after rolling back %d, want %d", got, n, len(All)-n)
+				}
+				if err := Up(db); err != nil {
+					t.Fatalf("reapplying %d migrations: %v", n, err)
+				}
+				if got := countApplied(t, db); got != len(All) {
+					t.Fatalf("%d migrations applied, want %d", got, len(All))
+				}
+
+				// Logs outlive every rollback that keeps their tables
+				wantLogs := 1
+				if n == len(All) {
+					wantLogs = 0
+				}
+				for _, table := range []string{"workouts", "weights_logs"} {
+					var count int
+					if err := db.Get(&count, "SELECT COUNT(*) FROM "+table); err != nil {
+						t.Fatal(err)
+					}
+					if count != wantLogs {
+						t.Errorf("%d rows in %s after rolling back %d migrations, want %d", count, table, n, wantLogs)
+					}
+				}
+			})
+		}
+	}
+}
+
+func countApplied(t *testing.T, db *sqlx.DB) int {
+	t.Helper()
+	statuses, err := GetStatus(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var count int
+	for _, status := range statuses {
+		if status.Applied {
+			count++
+		}
+	}
+	return count
+}
+
+func TestSQLiteTimestampsInUTC(t *testing.T) {
+	db := openSQLite(t, ":memory:")
+	// Back to version 16, before timestamps were moved to UTC
+	if err := Down(db, len(All)-16); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		stored string
+		want   string
+	}{
+		{stored: "2025-03-30 01:30:00.123456789+02:00", want: "2025-03-29 23:30:00.123456789+00:00"},
+		{stored: "2025-03-01 07:30:00-05:00", want: "2025-03-01 12:30:00+00:00"},
+		{stored: "2025-03-01 07:30:00.5+00:00", want: "2025-03-01 07:30:00.5+00:00"},
+		{stored: "2025-03-01 07:30:00", want: "2025-03-01 07:30:00"},
+	}
+	db.MustExec("DELETE FROM workouts")
+	for i, tt := range tests {
+		db.MustExec("INSERT INTO workouts (id, type, date) VALUES ($1, 'Run', $2)", i+1, tt.stored)
+	}
+	if err := Up(db); err != nil {
+		t.Fatal(err)
+	}
+	for i, tt := range tests {
+		var got string
+		if err := db.Get(&got, "SELECT CAST(date AS TEXT) FROM workouts WHERE id = $1", i+1); err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("%s migrated to %s, want %s", tt.stored, got, tt.want)
+		}
+	}
+}
+
+func TestSQLiteSeeds(t *testing.T) {
+	db := openSQLite(t, ":memory:")
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{query: "SELECT COUNT(*) FROM wods", want: 8},
+		{query: "SELECT COUNT(*) FROM weight_workouts", want: 29},
+		{query: "SELECT COUNT(*) FROM weight_workouts WHERE muscle_group = ''", want: 0},
+	}
+	for _, tt := range tests {
+		var got int
+		if err := db.Get(&got, tt.query); err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("%s = %d, want %d", tt.query, got, tt.want)
+		}
+	}
+}
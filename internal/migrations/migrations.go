@@ -0,0 +1,740 @@
+package migrations
+
+// Migration is a single numbered schema change together with the SQL needed
+// to roll it back.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+	// PostgresOnly marks migrations that SQLite has no use for or cannot
+	// run, such as changes of column types, which SQLite replaces with type
+	// affinity. They are recorded there without running.
+	PostgresOnly bool
+	// SQLiteOnly marks migrations that only SQLite needs, such as rewriting
+	// how it stores values, which Postgres column types already take care
+	// of. Postgres records them without running.
+	SQLiteOnly bool
+	// SQLiteDown replaces Down on SQLite when the rollback drops a column
+	// that SQLite cannot drop in place, such as a foreign key, and has to
+	// rebuild the table instead. It runs with foreign keys checked once at
+	// the end.
+	SQLiteDown string
+}
+
+// All lists every migration in the order it must be applied. New migrations
+// are appended with the next version number; released migrations must never
+// be edited. Statements are written for Postgres and translated for SQLite by
+// the runner, so keep to syntax both databases understand.
+var All = []Migration{
+	{
+		Version: 1,
+		Name:    "create_initial_schema",
+		Up: `
+    CREATE TABLE IF NOT EXISTS workouts (
+        id SERIAL PRIMARY KEY,
+        type VARCHAR(50),
+        duration FLOAT,
+        distance FLOAT,
+        date TIMESTAMP
+    );
+
+    CREATE TABLE IF NOT EXISTS workout_logs (
+        id SERIAL PRIMARY KEY,
+        exercise VARCHAR(50),
+        time FLOAT,
+        distance FLOAT,
+        weight FLOAT,
+        reps INT,
+        sets INT,
+        date TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    );
+
+    CREATE TABLE IF NOT EXISTS weights_logs (
+        id SERIAL PRIMARY KEY,
+        workout_type VARCHAR(50),
+        date TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    );
+
+    CREATE TABLE IF NOT EXISTS exercises (
+        id SERIAL PRIMARY KEY,
+        weights_log_id INT REFERENCES weights_logs(id),
+        name VARCHAR(50),
+        set1 INT,
+        set2 INT,
+        set3 INT
+    );
+
+    CREATE TABLE IF NOT EXISTS wods (
+        id SERIAL PRIMARY KEY,
+        type VARCHAR(50),
+        duration FLOAT,
+        distance FLOAT,
+        date TIMESTAMP
+    );
+
+    CREATE TABLE IF NOT EXISTS weight_workouts (
+        id SERIAL PRIMARY KEY,
+        workout_type VARCHAR(50),
+        exercise VARCHAR(50)
+    );
+    `,
+		Down: `
+    DROP TABLE IF EXISTS weight_workouts;
+    DROP TABLE IF EXISTS wods;
+    DROP TABLE IF EXISTS exercises;
+    DROP TABLE IF EXISTS weights_logs;
+    DROP TABLE IF EXISTS workout_logs;
+    DROP TABLE IF EXISTS workouts;
+    `,
+	},
+	{
+		// Databases created before migrations existed may still have the
+		// original integer columns, since CREATE TABLE IF NOT EXISTS never
+		// touched a table that was already there.
+		Version:      2,
+		Name:         "store_durations_as_float",
+		PostgresOnly: true,
+		Up: `
+    ALTER TABLE workouts ALTER COLUMN duration TYPE FLOAT;
+    ALTER TABLE workout_logs ALTER COLUMN time TYPE FLOAT;
+    ALTER TABLE wods ALTER COLUMN duration TYPE FLOAT;
+    `,
+		// Narrowing back to an integer would silently drop fractional
+		// seconds, so rolling back leaves the columns as they are.
+		Down: ``,
+	},
+	{
+		// SQLite cannot name the columns of a VALUES list, so migration 18
+		// seeds it instead.
+		Version:      3,
+		Name:         "seed_wods",
+		PostgresOnly: true,
+		Up: `
+    INSERT INTO wods (type, duration, distance, date)
+    SELECT seed.type, seed.duration, seed.distance, NOW()
+    FROM (VALUES
+        ('Walk', 60, 5.0),
+        ('Run - Intervals', 30, 5.0),
+        ('Run', 30, 5.0),
+        ('Crosstrainer', 30, 5.0),
+        ('Row - 2 mins on 1 min off', 30, 5.0),
+        ('Row - 10 x 500m', 30, 5.0),
+        ('Row', 30, 5.0),
+        ('Bike', 60, 20.0)
+    ) AS seed (type, duration, distance)
+    WHERE NOT EXISTS (SELECT 1 FROM wods);
+    `,
+		Down: `
+    DELETE FROM wods WHERE type IN (
+        'Walk', 'Run - Intervals', 'Run', 'Crosstrainer',
+        'Row - 2 mins on 1 min off', 'Row - 10 x 500m', 'Row', 'Bike'
+    );
+    `,
+	},
+	{
+		// Seeded on SQLite by migration 18.
+		Version:      4,
+		Name:         "seed_weight_workouts",
+		PostgresOnly: true,
+		Up: `
+    INSERT INTO weight_workouts (workout_type, exercise)
+    SELECT seed.workout_type, seed.exercise
+    FROM (VALUES
+        ('push', 'Flat Dumbbells'),
+        ('push', 'Flat Flys'),
+        ('push', 'Seated Dumbbell front raises'),
+        ('push', 'Seated Dumbbell side raises'),
+        ('push', 'Seated Dumbbell shoulder press'),
+        ('push', 'Tricep Pushdowns'),
+        ('push', 'Incline Smith'),
+        ('push', 'Close Grip Incline Smith'),
+        ('push', 'Overhead Rope (Cables)'),
+        ('push', 'Assisted Dips/Dip machine'),
+        ('pull', 'Deadlifts'),
+        ('pull', 'Bent Over Rows (Underhand)'),
+        ('pull', 'Shrugs (Barbell or dumbbell)'),
+        ('pull', 'Lat Pulldown'),
+        ('pull', 'Upright Rows (Barbell or Rope)'),
+        ('pull', 'Rear Delt Raises (Dumbbell)'),
+        ('pull', 'Single Preacher Dumbbell Curls'),
+        ('pull', 'EZ Bar Standing Curls'),
+        ('pull', 'Double Dumbbell Hammer Curls'),
+        ('legs', 'Barbell Squat'),
+        ('legs', 'Straight leg deadlifts'),
+        ('legs', 'Front squat (added)'),
+        ('legs', 'Leg Press'),
+        ('legs', 'Calf Raises on Leg Press'),
+        ('legs', 'Leg Extensions'),
+        ('legs', 'Hamstring curls (Machine)'),
+        ('legs', 'Dumbbell lunges'),
+        ('legs', 'Ab/Crunch Machine'),
+        ('legs', 'Captains Chair Leg or Knee Raises')
+    ) AS seed (workout_type, exercise)
+    WHERE NOT EXISTS (SELECT 1 FROM weight_workouts);
+    `,
+		Down: `
+    DELETE FROM weight_workouts WHERE exercise IN (
+        'Flat Dumbbells', 'Flat Flys', 'Seated Dumbbell front raises',
+        'Seated Dumbbell side raises', 'Seated Dumbbell shoulder press',
+        'Tricep Pushdowns', 'Incline Smith', 'Close Grip Incline Smith',
+        'Overhead Rope (Cables)', 'Assisted Dips/Dip machine',
+        'Deadlifts', 'Bent Over Rows (Underhand)', 'Shrugs (Barbell or dumbbell)',
+        'Lat Pulldown', 'Upright Rows (Barbell or Rope)', 'Rear Delt Raises (Dumbbell)',
+        'Single Preacher Dumbbell Curls', 'EZ Bar Standing Curls',
+        'Double Dumbbell Hammer Curls', 'Barbell Squat', 'Straight leg deadlifts',
+        'Front squat (added)', 'Leg Press', 'Calf Raises on Leg Press',
+        'Leg Extensions', 'Hamstring curls (Machine)', 'Dumbbell lunges',
+        'Ab/Crunch Machine', 'Captains Chair Leg or Knee Raises'
+    );
+    `,
+	},
+	{
+		// Records logged before accounts existed keep a NULL user_id and
+		// are only visible through the admin endpoints.
+		Version: 5,
+		Name:    "create_users_and_sessions",
+		Up: `
+    CREATE TABLE users (
+        id SERIAL PRIMARY KEY,
+        username VARCHAR(50) NOT NULL UNIQUE,
+        password_hash VARCHAR(100) NOT NULL,
+        created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+    );
+
+    CREATE TABLE sessions (
+        token_hash VARCHAR(64) PRIMARY KEY,
+        user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+        expires_at TIMESTAMP NOT NULL
+    );
+
+    ALTER TABLE workouts ADD COLUMN user_id INT REFERENCES users(id);
+    ALTER TABLE weights_logs ADD COLUMN user_id INT REFERENCES users(id);
+
+    CREATE INDEX workouts_user_id_date_idx ON workouts (user_id, date);
+    CREATE INDEX weights_logs_user_id_date_idx ON weights_logs (user_id, date);
+    `,
+		Down: `
+    DROP INDEX weights_logs_user_id_date_idx;
+    DROP INDEX workouts_user_id_date_idx;
+    ALTER TABLE weights_logs DROP COLUMN user_id;
+    ALTER TABLE workouts DROP COLUMN user_id;
+    DROP TABLE sessions;
+    DROP TABLE users;
+    `,
+		SQLiteDown: `
+    DROP INDEX weights_logs_user_id_date_idx;
+    DROP INDEX workouts_user_id_date_idx;
+
+    CREATE TABLE weights_logs_down (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        workout_type VARCHAR(50),
+        date TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    );
+    INSERT INTO weights_logs_down (id, workout_type, date)
+    SELECT id, workout_type, date FROM weights_logs;
+    DROP TABLE weights_logs;
+    ALTER TABLE weights_logs_down RENAME TO weights_logs;
+
+    CREATE TABLE workouts_down (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        type VARCHAR(50),
+        duration FLOAT,
+        distance FLOAT,
+        date TIMESTAMP
+    );
+    INSERT INTO workouts_down (id, type, duration, distance, date)
+    SELECT id, type, duration, distance, date FROM workouts;
+    DROP TABLE workouts;
+    ALTER TABLE workouts_down RENAME TO workouts;
+
+    DROP TABLE sessions;
+    DROP TABLE users;
+    `,
+	},
+	{
+		Version: 6,
+		Name:    "add_user_roles",
+		Up: `
+    ALTER TABLE users ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'user';
+    `,
+		Down: `
+    ALTER TABLE users DROP COLUMN role;
+    `,
+	},
+	{
+		// The logging form labelled set1..set3 as the weight lifted in each
+		// set, so those values become set weights with unknown reps.
+		Version: 7,
+		Name:    "create_exercise_sets",
+		Up: `
+    CREATE TABLE exercise_sets (
+        id SERIAL PRIMARY KEY,
+        exercise_id INT NOT NULL REFERENCES exercises(id) ON DELETE CASCADE,
+        set_number INT NOT NULL,
+        reps INT NOT NULL DEFAULT 0,
+        weight FLOAT NOT NULL DEFAULT 0,
+        unit VARCHAR(2) NOT NULL DEFAULT 'kg',
+        rpe FLOAT,
+        set_type VARCHAR(20) NOT NULL DEFAULT 'working'
+    );
+
+    CREATE INDEX exercise_sets_exercise_id_idx ON exercise_sets (exercise_id, set_number);
+
+    INSERT INTO exercise_sets (exercise_id, set_number, weight)
+    SELECT id, 1, set1 FROM exercises WHERE set1 IS NOT NULL
+    UNION ALL
+    SELECT id, 2, set2 FROM exercises WHERE set2 IS NOT NULL
+    UNION ALL
+    SELECT id, 3, set3 FROM exercises WHERE set3 IS NOT NULL;
+
+    ALTER TABLE exercises DROP COLUMN set1;
+    ALTER TABLE exercises DROP COLUMN set2;
+    ALTER TABLE exercises DROP COLUMN set3;
+    `,
+		Down: `
+    ALTER TABLE exercises ADD COLUMN set1 INT;
+    ALTER TABLE exercises ADD COLUMN set2 INT;
+    ALTER TABLE exercises ADD COLUMN set3 INT;
+
+    UPDATE exercises SET
+        set1 = (SELECT CAST(MAX(weight) AS INT) FROM exercise_sets WHERE exercise_id = exercises.id AND set_number = 1),
+        set2 = (SELECT CAST(MAX(weight) AS INT) FROM exercise_sets WHERE exercise_id = exercises.id AND set_number = 2),
+        set3 = (SELECT CAST(MAX(weight) AS INT) FROM exercise_sets WHERE exercise_id = exercises.id AND set_number = 3);
+
+    DROP TABLE exercise_sets;
+    `,
+	},
+	{
+		// Workouts are classified by matching their type, or the part before
+		// " - " as in "Row - 10 x 500m", against the names and aliases.
+		Version: 8,
+		Name:    "create_cardio_types",
+		Up: `
+    CREATE TABLE cardio_types (
+        id SERIAL PRIMARY KEY,
+        name VARCHAR(50) NOT NULL UNIQUE,
+        aliases TEXT NOT NULL DEFAULT '',
+        category VARCHAR(50) NOT NULL
+    );
+
+    INSERT INTO cardio_types (name, aliases, category)
+    SELECT seed.column1, seed.column2, seed.column3
+    FROM (VALUES
+        ('run', 'running,jog,jogging,treadmill', 'running'),
+        ('bike', 'cycle,cycling,ride,spin', 'cycling'),
+        ('row', 'rowing,rower,erg', 'rowing'),
+        ('walk', 'walking,hike', 'walking'),
+        ('crosstrainer', 'cross trainer,elliptical', 'elliptical'),
+        ('swim', 'swimming', 'swimming')
+    ) AS seed;
+
+    ALTER TABLE workouts ADD COLUMN cardio_type_id INT REFERENCES cardio_types(id) ON DELETE SET NULL;
+
+    UPDATE workouts SET cardio_type_id = (
+        SELECT c.id FROM cardio_types c
+        WHERE LOWER(workouts.type) = c.name
+           OR LOWER(workouts.type) LIKE c.name || ' - %'
+           OR ',' || c.aliases || ',' LIKE '%,' || LOWER(workouts.type) || ',%'
+        ORDER BY c.id
+        LIMIT 1
+    );
+
+    CREATE INDEX workouts_cardio_type_id_idx ON workouts (cardio_type_id);
+    `,
+		Down: `
+    DROP INDEX workouts_cardio_type_id_idx;
+    ALTER TABLE workouts DROP COLUMN cardio_type_id;
+    DROP TABLE cardio_types;
+    `,
+		SQLiteDown: `
+    DROP INDEX workouts_cardio_type_id_idx;
+    DROP INDEX workouts_user_id_date_idx;
+
+    CREATE TABLE workouts_down (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        type VARCHAR(50),
+        duration FLOAT,
+        distance FLOAT,
+        date TIMESTAMP,
+        user_id INT REFERENCES users(id)
+    );
+    INSERT INTO workouts_down (id, type, duration, distance, date, user_id)
+    SELECT id, type, duration, distance, date, user_id FROM workouts;
+    DROP TABLE workouts;
+    ALTER TABLE workouts_down RENAME TO workouts;
+
+    CREATE INDEX workouts_user_id_date_idx ON workouts (user_id, date);
+    DROP TABLE cardio_types;
+    `,
+	},
+	{
+		// Global assignments have no user_id; the unique index treats them
+		// as user 0 so there is at most one per day in each scope.
+		Version: 9,
+		Name:    "create_wod_assignments",
+		Up: `
+    CREATE TABLE wod_assignments (
+        id SERIAL PRIMARY KEY,
+        user_id INT REFERENCES users(id) ON DELETE CASCADE,
+        day DATE NOT NULL,
+        wod_id INT REFERENCES wods(id) ON DELETE SET NULL,
+        type VARCHAR(50) NOT NULL,
+        duration INT NOT NULL,
+        distance FLOAT NOT NULL,
+        created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+    );
+
+    CREATE UNIQUE INDEX wod_assignments_user_id_day_idx ON wod_assignments (COALESCE(user_id, 0), day);
+    `,
+		Down: `
+    DROP INDEX wod_assignments_user_id_day_idx;
+    DROP TABLE wod_assignments;
+    `,
+	},
+	{
+		Version: 10,
+		Name:    "add_wod_assignment_kind_and_reasons",
+		Up: `
+    ALTER TABLE wod_assignments ADD COLUMN kind VARCHAR(20) NOT NULL DEFAULT 'cardio';
+    ALTER TABLE wod_assignments ADD COLUMN reasons TEXT NOT NULL DEFAULT '[]';
+    `,
+		Down: `
+    DELETE FROM wod_assignments WHERE kind <> 'cardio';
+    ALTER TABLE wod_assignments DROP COLUMN reasons;
+    ALTER TABLE wod_assignments DROP COLUMN kind;
+    `,
+	},
+	{
+		// Seeds a 12-week push/pull/legs block of three 4-week waves, each
+		// ending in a deload week. Main lifts are prescribed as a percentage
+		// of the one-rep max; accessories are a fixed 3 x 12.
+		Version: 11,
+		Name:    "create_programs",
+		Up: `
+    CREATE TABLE programs (
+        id SERIAL PRIMARY KEY,
+        name VARCHAR(100) NOT NULL UNIQUE,
+        description TEXT NOT NULL DEFAULT '',
+        days_per_week INT NOT NULL,
+        created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+    );
+
+    CREATE TABLE program_weeks (
+        id SERIAL PRIMARY KEY,
+        program_id INT NOT NULL REFERENCES programs(id) ON DELETE CASCADE,
+        week_number INT NOT NULL,
+        focus VARCHAR(100) NOT NULL DEFAULT '',
+        UNIQUE (program_id, week_number)
+    );
+
+    CREATE TABLE program_days (
+        id SERIAL PRIMARY KEY,
+        program_week_id INT NOT NULL REFERENCES program_weeks(id) ON DELETE CASCADE,
+        day_number INT NOT NULL,
+        workout_type VARCHAR(50) NOT NULL,
+        UNIQUE (program_week_id, day_number)
+    );
+
+    CREATE TABLE program_exercises (
+        id SERIAL PRIMARY KEY,
+        program_day_id INT NOT NULL REFERENCES program_days(id) ON DELETE CASCADE,
+        position INT NOT NULL,
+        name VARCHAR(100) NOT NULL,
+        sets INT NOT NULL,
+        reps INT NOT NULL,
+        percent_1rm FLOAT
+    );
+
+    CREATE INDEX program_exercises_program_day_id_idx ON program_exercises (program_day_id, position);
+
+    CREATE TABLE program_enrolments (
+        id SERIAL PRIMARY KEY,
+        user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+        program_id INT NOT NULL REFERENCES programs(id) ON DELETE CASCADE,
+        started_on DATE NOT NULL,
+        ended_at TIMESTAMP,
+        created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+    );
+
+    CREATE INDEX program_enrolments_user_id_idx ON program_enrolments (user_id, ended_at);
+
+    INSERT INTO programs (name, description, days_per_week)
+    VALUES ('12-Week Push Pull Legs', 'Three 4-week waves of rising intensity on push, pull and legs days, each ending with a deload week.', 3);
+
+    INSERT INTO program_weeks (program_id, week_number, focus)
+    SELECT p.id, seed.column1, seed.column2
+    FROM programs p, (VALUES
+        (1, 'Volume'), (2, 'Volume'), (3, 'Volume'), (4, 'Deload'),
+        (5, 'Strength'), (6, 'Strength'), (7, 'Strength'), (8, 'Deload'),
+        (9, 'Intensity'), (10, 'Intensity'), (11, 'Intensity'), (12, 'Deload')
+    ) AS seed
+    WHERE p.name = '12-Week Push Pull Legs';
+
+    INSERT INTO program_days (program_week_id, day_number, workout_type)
+    SELECT w.id, seed.column1, seed.column2
+    FROM program_weeks w
+    JOIN programs p ON p.id = w.program_id,
+    (VALUES (1, 'push'), (3, 'pull'), (5, 'legs')) AS seed
+    WHERE p.name = '12-Week Push Pull Legs';
+
+    INSERT INTO program_exercises (program_day_id, position, name, sets, reps, percent_1rm)
+    SELECT d.id, ex.column2, ex.column3,
+        CASE WHEN ex.column4 = 1 THEN scheme.column2 ELSE 3 END,
+        CASE WHEN ex.column4 = 1 THEN scheme.column3 ELSE 12 END,
+        CASE WHEN ex.column4 = 1 THEN scheme.column4 ELSE NULL END
+    FROM program_days d
+    JOIN program_weeks w ON w.id = d.program_week_id
+    JOIN programs p ON p.id = w.program_id
+    JOIN (VALUES
+        (1, 4, 10, 65.0), (2, 4, 10, 67.5), (3, 4, 8, 70.0), (4, 3, 8, 55.0),
+        (5, 4, 8, 72.5), (6, 4, 6, 75.0), (7, 5, 5, 77.5), (8, 3, 5, 60.0),
+        (9, 4, 5, 80.0), (10, 5, 3, 82.5), (11, 5, 3, 85.0), (12, 3, 3, 65.0)
+    ) AS scheme ON scheme.column1 = w.week_number
+    JOIN (VALUES
+        ('push', 1, 'Incline Smith', 1),
+        ('push', 2, 'Seated Dumbbell shoulder press', 1),
+        ('push', 3, 'Tricep Pushdowns', 0),
+        ('push', 4, 'Seated Dumbbell side raises', 0),
+        ('pull', 1, 'Deadlifts', 1),
+        ('pull', 2, 'Bent Over Rows (Underhand)', 1),
+        ('pull', 3, 'Lat Pulldown', 0),
+        ('pull', 4, 'EZ Bar Standing Curls', 0),
+        ('legs', 1, 'Barbell Squat', 1),
+        ('legs', 2, 'Leg Press', 1),
+        ('legs', 3, 'Hamstring curls (Machine)', 0),
+        ('legs', 4, 'Calf Raises on Leg Press', 0)
+    ) AS ex ON ex.column1 = d.workout_type
+    WHERE p.name = '12-Week Push Pull Legs';
+    `,
+		Down: `
+    DROP INDEX program_enrolments_user_id_idx;
+    DROP TABLE program_enrolments;
+    DROP INDEX program_exercises_program_day_id_idx;
+    DROP TABLE program_exercises;
+    DROP TABLE program_days;
+    DROP TABLE program_weeks;
+    DROP TABLE programs;
+    `,
+	},
+	{
+		// Each row is a record when it was set; the current record of a kind
+		// and exercise (and weight, for most_reps) is the newest row. Rows go
+		// with the log that set them, so deleting a log restores the
+		// previous record.
+		Version: 12,
+		Name:    "create_personal_records",
+		Up: `
+    CREATE TABLE personal_records (
+        id SERIAL PRIMARY KEY,
+        user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+        kind VARCHAR(30) NOT NULL,
+        exercise VARCHAR(100) NOT NULL,
+        value FLOAT NOT NULL,
+        weight FLOAT NOT NULL DEFAULT 0,
+        reps INT NOT NULL DEFAULT 0,
+        weights_log_id INT REFERENCES weights_logs(id) ON DELETE CASCADE,
+        workout_id INT REFERENCES workouts(id) ON DELETE CASCADE,
+        achieved_at TIMESTAMP NOT NULL
+    );
+
+    CREATE INDEX personal_records_user_id_idx ON personal_records (user_id, kind, exercise);
+    `,
+		Down: `
+    DROP INDEX personal_records_user_id_idx;
+    DROP TABLE personal_records;
+    `,
+	},
+	{
+		// Muscle groups of the seeded exercises, for the sets per muscle group
+		// analytics. Exercises added later default to an empty group.
+		Version: 13,
+		Name:    "add_weight_workout_muscle_group",
+		Up: `
+    ALTER TABLE weight_workouts ADD COLUMN muscle_group VARCHAR(50) NOT NULL DEFAULT '';
+
+    UPDATE weight_workouts SET muscle_group = COALESCE((
+        SELECT seed.column2 FROM (VALUES
+        ('Flat Dumbbells', 'chest'),
+        ('Flat Flys', 'chest'),
+        ('Seated Dumbbell front raises', 'shoulders'),
+        ('Seated Dumbbell side raises', 'shoulders'),
+        ('Seated Dumbbell shoulder press', 'shoulders'),
+        ('Tricep Pushdowns', 'triceps'),
+        ('Incline Smith', 'chest'),
+        ('Close Grip Incline Smith', 'triceps'),
+        ('Overhead Rope (Cables)', 'triceps'),
+        ('Assisted Dips/Dip machine', 'triceps'),
+        ('Deadlifts', 'back'),
+        ('Bent Over Rows (Underhand)', 'back'),
+        ('Shrugs (Barbell or dumbbell)', 'back'),
+        ('Lat Pulldown', 'back'),
+        ('Upright Rows (Barbell or Rope)', 'shoulders'),
+        ('Rear Delt Raises (Dumbbell)', 'shoulders'),
+        ('Single Preacher Dumbbell Curls', 'biceps'),
+        ('EZ Bar Standing Curls', 'biceps'),
+        ('Double Dumbbell Hammer Curls', 'biceps'),
+        ('Barbell Squat', 'quads'),
+        ('Straight leg deadlifts', 'hamstrings'),
+        ('Front squat (added)', 'quads'),
+        ('Leg Press', 'quads'),
+        ('Calf Raises on Leg Press', 'calves'),
+        ('Leg Extensions', 'quads'),
+        ('Hamstring curls (Machine)', 'hamstrings'),
+        ('Dumbbell lunges', 'quads'),
+        ('Ab/Crunch Machine', 'core'),
+        ('Captains Chair Leg or Knee Raises', 'core')
+        ) AS seed WHERE seed.column1 = weight_workouts.exercise
+    ), '');
+    `,
+		Down: `
+    ALTER TABLE weight_workouts DROP COLUMN muscle_group;
+    `,
+	},
+	{
+		// Weights logged in pounds are converted to kilograms. The conversion
+		// cannot be undone: rolling back leaves every set in kilograms, since
+		// which sets were logged in pounds is not kept.
+		Version: 14,
+		Name:    "add_user_units",
+		Up: `
+    ALTER TABLE users ADD COLUMN units VARCHAR(10) NOT NULL DEFAULT 'metric';
+
+    UPDATE exercise_sets SET weight = weight * 0.45359237, unit = 'kg' WHERE unit = 'lb';
+    `,
+		Down: `
+    ALTER TABLE users DROP COLUMN units;
+    `,
+	},
+	{
+		// Timestamps become instants rather than wall-clock times. Existing
+		// values are read in the session time zone, so set PGTZ to the time
+		// zone the server ran in when applying or rolling back this migration.
+		// SQLite keeps the UTC offset in the stored text, and migration 17
+		// rewrites it in UTC.
+		Version:      15,
+		Name:         "use_timestamptz",
+		PostgresOnly: true,
+		Up: `
+    ALTER TABLE workouts ALTER COLUMN date TYPE TIMESTAMPTZ;
+    ALTER TABLE workout_logs ALTER COLUMN date TYPE TIMESTAMPTZ;
+    ALTER TABLE weights_logs ALTER COLUMN date TYPE TIMESTAMPTZ;
+    ALTER TABLE wods ALTER COLUMN date TYPE TIMESTAMPTZ;
+    ALTER TABLE users ALTER COLUMN created_at TYPE TIMESTAMPTZ;
+    ALTER TABLE sessions ALTER COLUMN expires_at TYPE TIMESTAMPTZ;
+    ALTER TABLE wod_assignments ALTER COLUMN created_at TYPE TIMESTAMPTZ;
+    ALTER TABLE programs ALTER COLUMN created_at TYPE TIMESTAMPTZ;
+    ALTER TABLE program_enrolments ALTER COLUMN ended_at TYPE TIMESTAMPTZ;
+    ALTER TABLE program_enrolments ALTER COLUMN created_at TYPE TIMESTAMPTZ;
+    ALTER TABLE personal_records ALTER COLUMN achieved_at TYPE TIMESTAMPTZ;
+    `,
+		Down: `
+    ALTER TABLE workouts ALTER COLUMN date TYPE TIMESTAMP;
+    ALTER TABLE workout_logs ALTER COLUMN date TYPE TIMESTAMP;
+    ALTER TABLE weights_logs ALTER COLUMN date TYPE TIMESTAMP;
+    ALTER TABLE wods ALTER COLUMN date TYPE TIMESTAMP;
+    ALTER TABLE users ALTER COLUMN created_at TYPE TIMESTAMP;
+    ALTER TABLE sessions ALTER COLUMN expires_at TYPE TIMESTAMP;
+    ALTER TABLE wod_assignments ALTER COLUMN created_at TYPE TIMESTAMP;
+    ALTER TABLE programs ALTER COLUMN created_at TYPE TIMESTAMP;
+    ALTER TABLE program_enrolments ALTER COLUMN ended_at TYPE TIMESTAMP;
+    ALTER TABLE program_enrolments ALTER COLUMN created_at TYPE TIMESTAMP;
+    ALTER TABLE personal_records ALTER COLUMN achieved_at TYPE TIMESTAMP;
+    `,
+	},
+	{
+		// An empty time zone means the server's.
+		Version: 16,
+		Name:    "add_user_time_zone",
+		Up: `
+    ALTER TABLE users ADD COLUMN time_zone VARCHAR(64) NOT NULL DEFAULT '';
+    `,
+		Down: `
+    ALTER TABLE users DROP COLUMN time_zone;
+    `,
+	}, {
+		// Timestamps were written with the UTC offset of the server, so text
+		// comparisons went wrong for values written under another offset,
+		// such as across a daylight saving time change. Values with a
+		// non-zero offset are rewritten in UTC in the format the driver
+		// writes, keeping their fractional seconds, which an offset of whole
+		// minutes does not change. Rolling back leaves them in UTC, which
+		// reads back as the same instants.
+		Version:    17,
+		Name:       "store_sqlite_timestamps_in_utc",
+		SQLiteOnly: true,
+		Up: `
+    UPDATE workouts SET date = STRFTIME('%Y-%m-%d %H:%M:%S', date) || SUBSTR(date, 20, LENGTH(date) - 25) || '+00:00' WHERE date GLOB '*[+-][0-9][0-9]:[0-9][0-9]' AND date NOT LIKE '%+00:00';
+    UPDATE workout_logs SET date = STRFTIME('%Y-%m-%d %H:%M:%S', date) || SUBSTR(date, 20, LENGTH(date) - 25) || '+00:00' WHERE date GLOB '*[+-][0-9][0-9]:[0-9][0-9]' AND date NOT LIKE '%+00:00';
+    UPDATE weights_logs SET date = STRFTIME('%Y-%m-%d %H:%M:%S', date) || SUBSTR(date, 20, LENGTH(date) - 25) || '+00:00' WHERE date GLOB '*[+-][0-9][0-9]:[0-9][0-9]' AND date NOT LIKE '%+00:00';
+    UPDATE wods SET date = STRFTIME('%Y-%m-%d %H:%M:%S', date) || SUBSTR(date, 20, LENGTH(date) - 25) || '+00:00' WHERE date GLOB '*[+-][0-9][0-9]:[0-9][0-9]' AND date NOT LIKE '%+00:00';
+    UPDATE users SET created_at = STRFTIME('%Y-%m-%d %H:%M:%S', created_at) || SUBSTR(created_at, 20, LENGTH(created_at) - 25) || '+00:00' WHERE created_at GLOB '*[+-][0-9][0-9]:[0-9][0-9]' AND created_at NOT LIKE '%+00:00';
+    UPDATE sessions SET expires_at = STRFTIME('%Y-%m-%d %H:%M:%S', expires_at) || SUBSTR(expires_at, 20, LENGTH(expires_at) - 25) || '+00:00' WHERE expires_at GLOB '*[+-][0-9][0-9]:[0-9][0-9]' AND expires_at NOT LIKE '%+00:00';
+    UPDATE wod_assignments SET created_at = STRFTIME('%Y-%m-%d %H:%M:%S', created_at) || SUBSTR(created_at, 20, LENGTH(created_at) - 25) || '+00:00' WHERE created_at GLOB '*[+-][0-9][0-9]:[0-9][0-9]' AND created_at NOT LIKE '%+00:00';
+    UPDATE programs SET created_at = STRFTIME('%Y-%m-%d %H:%M:%S', created_at) || SUBSTR(created_at, 20, LENGTH(created_at) - 25) || '+00:00' WHERE created_at GLOB '*[+-][0-9][0-9]:[0-9][0-9]' AND created_at NOT LIKE '%+00:00';
+    UPDATE program_enrolments SET ended_at = STRFTIME('%Y-%m-%d %H:%M:%S', ended_at) || SUBSTR(ended_at, 20, LENGTH(ended_at) - 25) || '+00:00' WHERE ended_at GLOB '*[+-][0-9][0-9]:[0-9][0-9]' AND ended_at NOT LIKE '%+00:00';
+    UPDATE program_enrolments SET created_at = STRFTIME('%Y-%m-%d %H:%M:%S', created_at) || SUBSTR(created_at, 20, LENGTH(created_at) - 25) || '+00:00' WHERE created_at GLOB '*[+-][0-9][0-9]:[0-9][0-9]' AND created_at NOT LIKE '%+00:00';
+    UPDATE personal_records SET achieved_at = STRFTIME('%Y-%m-%d %H:%M:%S', achieved_at) || SUBSTR(achieved_at, 20, LENGTH(achieved_at) - 25) || '+00:00' WHERE achieved_at GLOB '*[+-][0-9][0-9]:[0-9][0-9]' AND achieved_at NOT LIKE '%+00:00';
+    `,
+		Down: ``,
+	}, {
+		// The seeds of migrations 3 and 4, which name the columns of their
+		// VALUES lists in a way SQLite does not support, with the muscle
+		// groups of migration 13. Databases seeded before keep their rows.
+		// The seeds belong to versions 3 and 4, so rolling back leaves them.
+		Version:    18,
+		Name:       "seed_sqlite_wods_and_weight_workouts",
+		SQLiteOnly: true,
+		Up: `
+    INSERT INTO wods (type, duration, distance, date)
+    SELECT seed.column1, seed.column2, seed.column3, CURRENT_TIMESTAMP
+    FROM (VALUES
+        ('Walk', 60, 5.0),
+        ('Run - Intervals', 30, 5.0),
+        ('Run', 30, 5.0),
+        ('Crosstrainer', 30, 5.0),
+        ('Row - 2 mins on 1 min off', 30, 5.0),
+        ('Row - 10 x 500m', 30, 5.0),
+        ('Row', 30, 5.0),
+        ('Bike', 60, 20.0)
+    ) AS seed
+    WHERE NOT EXISTS (SELECT 1 FROM wods);
+
+    INSERT INTO weight_workouts (workout_type, exercise, muscle_group)
+    SELECT seed.column1, seed.column2, seed.column3
+    FROM (VALUES
+        ('push', 'Flat Dumbbells', 'chest'),
+        ('push', 'Flat Flys', 'chest'),
+        ('push', 'Seated Dumbbell front raises', 'shoulders'),
+        ('push', 'Seated Dumbbell side raises', 'shoulders'),
+        ('push', 'Seated Dumbbell shoulder press', 'shoulders'),
+        ('push', 'Tricep Pushdowns', 'triceps'),
+        ('push', 'Incline Smith', 'chest'),
+        ('push', 'Close Grip Incline Smith', 'triceps'),
+        ('push', 'Overhead Rope (Cables)', 'triceps'),
+        ('push', 'Assisted Dips/Dip machine', 'triceps'),
+        ('pull', 'Deadlifts', 'back'),
+        ('pull', 'Bent Over Rows (Underhand)', 'back'),
+        ('pull', 'Shrugs (Barbell or dumbbell)', 'back'),
+        ('pull', 'Lat Pulldown', 'back'),
+        ('pull', 'Upright Rows (Barbell or Rope)', 'shoulders'),
+        ('pull', 'Rear Delt Raises (Dumbbell)', 'shoulders'),
+        ('pull', 'Single Preacher Dumbbell Curls', 'biceps'),
+        ('pull', 'EZ Bar Standing Curls', 'biceps'),
+        ('pull', 'Double Dumbbell Hammer Curls', 'biceps'),
+        ('legs', 'Barbell Squat', 'quads'),
+        ('legs', 'Straight leg deadlifts', 'hamstrings'),
+        ('legs', 'Front squat (added)', 'quads'),
+        ('legs', 'Leg Press', 'quads'),
+        ('legs', 'Calf Raises on Leg Press', 'calves'),
+        ('legs', 'Leg Extensions', 'quads'),
+        ('legs', 'Hamstring curls (Machine)', 'hamstrings'),
+        ('legs', 'Dumbbell lunges', 'quads'),
+        ('legs', 'Ab/Crunch Machine', 'core'),
+        ('legs', 'Captains Chair Leg or Knee Raises', 'core')
+    ) AS seed
+    WHERE NOT EXISTS (SELECT 1 FROM weight_workouts);
+    `,
+		Down: ``,
+	},
+}
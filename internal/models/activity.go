@@ -0,0 +1,22 @@
+package models
+
+import (
+	"context"
+	"time"
+)
+
+// DayActivity counts the logs of a user on a calendar day of their time zone.
+type DayActivity struct {
+	Day             string  `json:"day"`                                    // Calendar day, as YYYY-MM-DD
+	CardioWorkouts  int     `json:"cardio_workouts" db:"cardio_workouts"`   // Number of cardio workouts
+	Duration        float64 `json:"duration"`                               // Total cardio duration in seconds
+	Distance        float64 `json:"distance"`                               // Total cardio distance in kilometers
+	WeightsSessions int     `json:"weights_sessions" db:"weights_sessions"` // Number of weights logs
+}
+
+// FetchDailyActivity retrieves the user's activity on each day of their time
+// zone from from up to but excluding to, oldest first. Days without logs are
+// left out.
+func FetchDailyActivity(ctx context.Context, userID int, loc *time.Location, from, to time.Time) ([]DayActivity, error) {
+	return store.FetchDailyActivity(ctx, userID, loc, from, to)
+}
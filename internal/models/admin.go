@@ -0,0 +1,222 @@
+package models
+
+import (
+	"context"
+	"sort"
+)
+
+// AdminResource is a table managed through the admin API. Records are passed
+// as pointers to the table's record type, such as *Workout, as returned by
+// New and Get.
+type AdminResource interface {
+	// Name is the table name used in the admin URLs, e.g. weights_logs.
+	Name() string
+	// New returns a pointer to an empty record to decode a request into.
+	New() interface{}
+	// ID returns the ID of a record and SetID changes it.
+	ID(record interface{}) int
+	SetID(record interface{}, id int)
+	// List returns every record and Get the record with an ID, or
+	// ErrNotFound.
+	List(ctx context.Context) (interface{}, error)
+	Get(ctx context.Context, id int) (interface{}, error)
+	// Add and Update validate a record before storing it. Update and Delete
+	// return ErrNotFound when no record has the record's ID.
+	Add(ctx context.Context, record interface{}) error
+	Update(ctx context.Context, record interface{}) error
+	Delete(ctx context.Context, id int) error
+	// Empty deletes every record of the table.
+	Empty(ctx context.Context) error
+}
+
+// adminTable registers a table of records of type T with the admin API: how
+// records are identified and validated, and the model functions that store
+// them. Validate may also normalise the record; it runs before add and
+// update. Fetch returns one record, or nil when there is none.
+type adminTable[T any] struct {
+	name     string
+	id       func(*T) *int
+	validate func(*T) error
+	add      func(context.Context, T) error
+	update   func(context.Context, T) error
+	delete   func(context.Context, int) error
+	view     func(context.Context) ([]T, error)
+	fetch    func(context.Context, int) (*T, error)
+	empty    func(context.Context) error
+}
+
+// adminTables are the tables of the admin API by name.
+var adminTables = map[string]AdminResource{}
+
+// registerAdminTable adds a table to the admin API.
+func registerAdminTable[T any](table adminTable[T]) {
+	adminTables[table.name] = &table
+}
+
+// LookupAdminResource returns the admin table with a name, if there is one.
+func LookupAdminResource(name string) (AdminResource, bool) {
+	resource, ok := adminTables[name]
+	return resource, ok
+}
+
+// AdminResourceNames returns the names of the admin tables in order.
+func AdminResourceNames() []string {
+	names := make([]string, 0, len(adminTables))
+	for name := range adminTables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Name implements AdminResource.
+func (t *adminTable[T]) Name() string {
+	return t.name
+}
+
+// New implements AdminResource.
+func (t *adminTable[T]) New() interface{} {
+	return new(T)
+}
+
+// ID implements AdminResource.
+func (t *adminTable[T]) ID(record interface{}) int {
+	return *t.id(record.(*T))
+}
+
+// SetID implements AdminResource.
+func (t *adminTable[T]) SetID(record interface{}, id int) {
+	*t.id(record.(*T)) = id
+}
+
+// List implements AdminResource.
+func (t *adminTable[T]) List(ctx context.Context) (interface{}, error) {
+	records, err := t.view(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if records == nil {
+		records = []T{}
+	}
+	return records, nil
+}
+
+// Get implements AdminResource.
+func (t *adminTable[T]) Get(ctx context.Context, id int) (interface{}, error) {
+	record, err := t.fetch(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return nil, ErrNotFound
+	}
+	return record, nil
+}
+
+// Add implements AdminResource.
+func (t *adminTable[T]) Add(ctx context.Context, record interface{}) error {
+	r := record.(*T)
+	if err := t.validate(r); err != nil {
+		return err
+	}
+	return t.add(ctx, *r)
+}
+
+// Update implements AdminResource.
+func (t *adminTable[T]) Update(ctx context.Context, record interface{}) error {
+	r := record.(*T)
+	if err := t.validate(r); err != nil {
+		return err
+	}
+	return t.update(ctx, *r)
+}
+
+// Delete implements AdminResource.
+func (t *adminTable[T]) Delete(ctx context.Context, id int) error {
+	return t.delete(ctx, id)
+}
+
+// Empty implements AdminResource.
+func (t *adminTable[T]) Empty(ctx context.Context) error {
+	return t.empty(ctx)
+}
+
+func init() {
+	registerAdminTable(adminTable[Workout]{
+		name:     "workouts",
+		id:       func(w *Workout) *int { return &w.ID },
+		validate: func(w *Workout) error { return w.validateStored().OrNil() },
+		add:      AddWorkout,
+		update:   UpdateWorkout,
+		delete:   DeleteWorkout,
+		view:     ViewWorkouts,
+		fetch:    FetchWorkout,
+		empty:    EmptyWorkouts,
+	})
+	registerAdminTable(adminTable[WeightsLog]{
+		name:     "weights_logs",
+		id:       func(l *WeightsLog) *int { return &l.ID },
+		validate: func(l *WeightsLog) error { return l.validateStored().OrNil() },
+		add:      AddWeightsLog,
+		update:   UpdateWeightsLog,
+		delete:   DeleteWeightsLog,
+		view:     ViewWeightsLogs,
+		fetch:    FetchWeightsLog,
+		empty:    EmptyWeightsLogs,
+	})
+	registerAdminTable(adminTable[Exercise]{
+		name:     "exercises",
+		id:       func(e *Exercise) *int { return &e.ID },
+		validate: func(e *Exercise) error { return e.validate().OrNil() },
+		add:      AddExercise,
+		update:   UpdateExercise,
+		delete:   DeleteExercise,
+		view:     ViewExercises,
+		fetch:    FetchExercise,
+		empty:    EmptyExercises,
+	})
+	registerAdminTable(adminTable[WOD]{
+		name:     "wods",
+		id:       func(w *WOD) *int { return &w.ID },
+		validate: func(w *WOD) error { return w.validate().OrNil() },
+		add:      AddWOD,
+		update:   UpdateWOD,
+		delete:   DeleteWOD,
+		view:     ViewWODs,
+		fetch:    FetchWOD,
+		empty:    EmptyWODs,
+	})
+	registerAdminTable(adminTable[CardioType]{
+		name:     "cardio_types",
+		id:       func(c *CardioType) *int { return &c.ID },
+		validate: prepareCardioType,
+		add:      AddCardioType,
+		update:   UpdateCardioType,
+		delete:   DeleteCardioType,
+		view:     ViewCardioTypes,
+		fetch:    FetchCardioType,
+		empty:    EmptyCardioTypes,
+	})
+	registerAdminTable(adminTable[Program]{
+		name:     "programs",
+		id:       func(p *Program) *int { return &p.ID },
+		validate: prepareProgram,
+		add:      AddProgram,
+		update:   UpdateProgram,
+		delete:   DeleteProgram,
+		view:     ViewPrograms,
+		fetch:    FetchProgram,
+		empty:    EmptyPrograms,
+	})
+	registerAdminTable(adminTable[WeightWorkout]{
+		name:     "weight_workouts",
+		id:       func(w *WeightWorkout) *int { return &w.ID },
+		validate: func(w *WeightWorkout) error { return w.validate().OrNil() },
+		add:      AddWeightWorkout,
+		update:   UpdateWeightWorkout,
+		delete:   DeleteWeightWorkout,
+		view:     ViewWeightWorkouts,
+		fetch:    FetchWeightWorkout,
+		empty:    EmptyWeightWorkouts,
+	})
+}
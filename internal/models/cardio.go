@@ -0,0 +1,195 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCardioType is returned when a cardio type is missing its name or
+// category.
+var ErrInvalidCardioType = errors.New("invalid cardio type")
+
+// CardioType is an entry in the cardio type catalogue. Logged workouts whose
+// type matches the name or one of the aliases are treated as cardio and
+// reference the entry.
+type CardioType struct {
+	ID       int        `json:"id"`
+	Name     string     `json:"name"`     // Canonical lower-case name (e.g., run)
+	Aliases  StringList `json:"aliases"`  // Other names for the same activity (e.g., jog)
+	Category string     `json:"category"` // Sport category (e.g., running)
+}
+
+// Periods that cardio totals are grouped by. Weeks start on Monday.
+const (
+	PeriodDay   = "day"
+	PeriodWeek  = "week"
+	PeriodMonth = "month"
+)
+
+// CardioTotal sums the cardio workouts of one cardio type in a period.
+type CardioTotal struct {
+	Period        string  `json:"period"`                             // First day of the period, as YYYY-MM-DD
+	CardioType    string  `json:"cardio_type" db:"cardio_type"`       // Canonical name of the cardio type
+	Workouts      int     `json:"workouts"`                           // Number of workouts
+	Distance      float64 `json:"distance"`                           // Total distance in kilometers
+	Duration      float64 `json:"duration"`                           // Total duration in seconds
+	PacedDuration float64 `json:"paced_duration" db:"paced_duration"` // Duration of the workouts with a distance, for pace
+}
+
+// CardioSplit is the fastest time of a cardio type over a distance, from the
+// average pace of the workouts at least that long.
+type CardioSplit struct {
+	CardioType string  `json:"cardio_type" db:"cardio_type"`
+	Distance   float64 `json:"distance"` // Kilometers
+	Seconds    float64 `json:"seconds"`
+}
+
+// FetchCardioTotals retrieves the totals of the user's cardio workouts from
+// from up to but excluding to, by period of the user's time zone and cardio
+// type.
+func FetchCardioTotals(ctx context.Context, userID int, period string, loc *time.Location, from, to time.Time) ([]CardioTotal, error) {
+	return store.FetchCardioTotals(ctx, userID, period, loc, from, to)
+}
+
+// FetchCardioBestSplits retrieves the user's fastest time of each cardio type
+// over each distance from from up to but excluding to.
+func FetchCardioBestSplits(ctx context.Context, userID int, distances []float64, from, to time.Time) ([]CardioSplit, error) {
+	return store.FetchCardioBestSplits(ctx, userID, distances, from, to)
+}
+
+// Matches reports whether a logged workout type belongs to this cardio type.
+// Only the part before " - " is compared, so "Row - 10 x 500m" is a row.
+func (c CardioType) Matches(workoutType string) bool {
+	key := strings.ToLower(strings.TrimSpace(workoutType))
+	if base, _, ok := strings.Cut(key, " - "); ok {
+		key = strings.TrimSpace(base)
+	}
+	if key == c.Name {
+		return true
+	}
+	for _, alias := range c.Aliases {
+		if key == alias {
+			return true
+		}
+	}
+	return false
+}
+
+// matchCardio returns the first cardio type matching a workout type, or nil
+// if it is not a cardio workout.
+func matchCardio(cardioTypes []CardioType, workoutType string) *CardioType {
+	for i := range cardioTypes {
+		if cardioTypes[i].Matches(workoutType) {
+			return &cardioTypes[i]
+		}
+	}
+	return nil
+}
+
+// classifyCardio returns the ID of the first cardio type matching a workout
+// type, or nil if it is not a cardio workout.
+func classifyCardio(cardioTypes []CardioType, workoutType string) *int {
+	if cardioType := matchCardio(cardioTypes, workoutType); cardioType != nil {
+		id := cardioType.ID
+		return &id
+	}
+	return nil
+}
+
+// classifyWorkout sets the cardio type of a workout from the catalogue.
+func classifyWorkout(ctx context.Context, workout *Workout) error {
+	cardioTypes, err := store.ViewCardioTypes(ctx)
+	if err != nil {
+		return err
+	}
+	workout.CardioTypeID = classifyCardio(cardioTypes, workout.Type)
+	return nil
+}
+
+// ReclassifyWorkouts updates the cardio type of every logged workout to match
+// the current catalogue.
+func ReclassifyWorkouts(ctx context.Context) error {
+	cardioTypes, err := store.ViewCardioTypes(ctx)
+	if err != nil {
+		return err
+	}
+	workoutTypes, err := store.FetchWorkoutTypes(ctx)
+	if err != nil {
+		return err
+	}
+	for _, workoutType := range workoutTypes {
+		if err := store.SetWorkoutCardioType(ctx, workoutType, classifyCardio(cardioTypes, workoutType)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// prepareCardioType normalizes the name and aliases of a cardio type and
+// checks the required fields.
+func prepareCardioType(cardioType *CardioType) error {
+	cardioType.Name = strings.ToLower(strings.TrimSpace(cardioType.Name))
+	cardioType.Category = strings.ToLower(strings.TrimSpace(cardioType.Category))
+	v := newValidation(ErrInvalidCardioType)
+	if cardioType.Name == "" || strings.Contains(cardioType.Name, ",") {
+		v.Add("name", "is required and cannot contain commas")
+	}
+	if cardioType.Category == "" {
+		v.Add("category", "is required")
+	}
+	if err := v.OrNil(); err != nil {
+		return err
+	}
+	aliases := StringList{}
+	for _, alias := range cardioType.Aliases {
+		if alias = strings.ToLower(strings.TrimSpace(alias)); alias != "" && !strings.Contains(alias, ",") {
+			aliases = append(aliases, alias)
+		}
+	}
+	cardioType.Aliases = aliases
+	return nil
+}
+
+// AddCardioType adds a new cardio type, prepared by prepareCardioType, to the
+// catalogue and reclassifies the logged workouts
+func AddCardioType(ctx context.Context, cardioType CardioType) error {
+	if err := store.AddCardioType(ctx, cardioType); err != nil {
+		return err
+	}
+	return ReclassifyWorkouts(ctx)
+}
+
+// UpdateCardioType updates an existing cardio type, prepared by
+// prepareCardioType, and reclassifies the logged workouts
+func UpdateCardioType(ctx context.Context, cardioType CardioType) error {
+	if err := store.UpdateCardioType(ctx, cardioType); err != nil {
+		return err
+	}
+	return ReclassifyWorkouts(ctx)
+}
+
+// DeleteCardioType deletes a cardio type and reclassifies the logged workouts
+func DeleteCardioType(ctx context.Context, id int) error {
+	if err := store.DeleteCardioType(ctx, id); err != nil {
+		return err
+	}
+	return ReclassifyWorkouts(ctx)
+}
+
+// ViewCardioTypes retrieves the cardio type catalogue
+func ViewCardioTypes(ctx context.Context) ([]CardioType, error) {
+	return store.ViewCardioTypes(ctx)
+}
+
+// FetchCardioType retrieves a cardio type by ID, or nil if there is none
+func FetchCardioType(ctx context.Context, id int) (*CardioType, error) {
+	return store.FetchCardioType(ctx, id)
+}
+
+// EmptyCardioTypes deletes every cardio type, leaving no workout classified
+// as cardio
+func EmptyCardioTypes(ctx context.Context) error {
+	return store.EmptyCardioTypes(ctx)
+}
@@ -0,0 +1,312 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// ErrInvalidProgram is returned when a program's structure is incomplete or
+// inconsistent.
+var ErrInvalidProgram = errors.New("invalid program")
+
+// ErrProgramNotFound is returned when enrolling in a program that does not exist.
+var ErrProgramNotFound = errors.New("program not found")
+
+// WODKindProgram marks a workout of the day prescribed by the user's program.
+const WODKindProgram = "program"
+
+// oneRepMaxWindow is how far back logged sets are used to estimate a one-rep
+// max for percentage-based loads.
+const oneRepMaxWindow = 90 * 24 * time.Hour
+
+// Program is a multi-week training plan.
+type Program struct {
+	ID          int           `json:"id"`
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	DaysPerWeek int           `json:"days_per_week" db:"days_per_week"` // Training days in each week
+	CreatedAt   time.Time     `json:"created_at" db:"created_at"`
+	Weeks       []ProgramWeek `json:"weeks,omitempty"`
+}
+
+// ProgramWeek is one week of a program.
+type ProgramWeek struct {
+	ID         int          `json:"id"`
+	ProgramID  int          `json:"program_id" db:"program_id"`
+	WeekNumber int          `json:"week_number" db:"week_number"` // 1-based
+	Focus      string       `json:"focus"`                        // e.g., Volume, Deload
+	Days       []ProgramDay `json:"days"`
+}
+
+// ProgramDay is a training day of a program week. Days of the week without a
+// ProgramDay are rest days.
+type ProgramDay struct {
+	ID            int               `json:"id"`
+	ProgramWeekID int               `json:"program_week_id" db:"program_week_id"`
+	DayNumber     int               `json:"day_number" db:"day_number"`     // 1-7 within the week
+	WorkoutType   string            `json:"workout_type" db:"workout_type"` // e.g., push
+	Exercises     []ProgramExercise `json:"exercises"`
+}
+
+// ProgramExercise is an exercise prescribed for a program day.
+type ProgramExercise struct {
+	ID           int      `json:"id"`
+	ProgramDayID int      `json:"program_day_id" db:"program_day_id"`
+	Position     int      `json:"position"` // 1-based order within the day
+	Name         string   `json:"name"`
+	Sets         int      `json:"sets"`
+	Reps         int      `json:"reps"`
+	Percent1RM   *float64 `json:"percent_1rm,omitempty" db:"percent_1rm"` // Load as a percentage of the one-rep max (nil for a free choice)
+}
+
+// Enrolment records a user following a program from a start day.
+type Enrolment struct {
+	ID        int        `json:"id"`
+	UserID    int        `json:"user_id" db:"user_id"`
+	ProgramID int        `json:"program_id" db:"program_id"`
+	StartedOn time.Time  `json:"started_on" db:"started_on"` // First day of week 1, as midnight UTC
+	EndedAt   *time.Time `json:"ended_at,omitempty" db:"ended_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
+
+// ProgramSession is the session a program prescribes for a day.
+type ProgramSession struct {
+	Kind        string               `json:"kind"` // Always WODKindProgram
+	Day         time.Time            `json:"day"`
+	ProgramID   int                  `json:"program_id"`
+	ProgramName string               `json:"program_name"`
+	Week        int                  `json:"week"`
+	DayNumber   int                  `json:"day_number"`
+	Focus       string               `json:"focus"`
+	Rest        bool                 `json:"rest"`                   // No training prescribed for the day
+	WorkoutType string               `json:"workout_type,omitempty"` // e.g., push
+	Exercises   []PrescribedExercise `json:"exercises,omitempty"`
+}
+
+// PrescribedExercise is a program exercise with the load worked out for the user.
+type PrescribedExercise struct {
+	Name         string   `json:"name"`
+	Sets         int      `json:"sets"`
+	Reps         int      `json:"reps"`
+	Percent1RM   *float64 `json:"percent_1rm,omitempty"`
+	TargetWeight *float64 `json:"target_weight,omitempty"` // Load worked out from the estimated one-rep max, when known
+	Unit         string   `json:"unit,omitempty"`
+}
+
+// ViewPrograms retrieves all programs without their weeks
+func ViewPrograms(ctx context.Context) ([]Program, error) {
+	return store.ViewPrograms(ctx)
+}
+
+// FetchProgram retrieves a program with its weeks, days and exercises, or nil
+// if there is none.
+func FetchProgram(ctx context.Context, id int) (*Program, error) {
+	return store.FetchProgram(ctx, id)
+}
+
+// prepareProgram fills in defaults for omitted numbering and checks that the
+// program is well formed.
+func prepareProgram(program *Program) error {
+	v := newValidation(ErrInvalidProgram)
+	program.Name = strings.TrimSpace(program.Name)
+	if program.Name == "" {
+		v.Add("name", "is required")
+	}
+	if program.DaysPerWeek < 1 || program.DaysPerWeek > 7 {
+		v.Add("days_per_week", "must be between 1 and 7")
+	}
+	weeks := map[int]bool{}
+	for i := range program.Weeks {
+		week := &program.Weeks[i]
+		weekPath := fmt.Sprintf("weeks[%d]", i)
+		if week.WeekNumber == 0 {
+			week.WeekNumber = i + 1
+		}
+		if weeks[week.WeekNumber] {
+			v.Add(fieldPath(weekPath, "week_number"), "week %d is defined twice", week.WeekNumber)
+		}
+		weeks[week.WeekNumber] = true
+		days := map[int]bool{}
+		for j := range week.Days {
+			day := &week.Days[j]
+			dayPath := fieldPath(weekPath, fmt.Sprintf("days[%d]", j))
+			if day.DayNumber < 1 || day.DayNumber > 7 {
+				v.Add(fieldPath(dayPath, "day_number"), "must be between 1 and 7")
+			} else if days[day.DayNumber] {
+				v.Add(fieldPath(dayPath, "day_number"), "day %d of week %d is defined twice", day.DayNumber, week.WeekNumber)
+			}
+			days[day.DayNumber] = true
+			for k := range day.Exercises {
+				exercise := &day.Exercises[k]
+				exercisePath := fieldPath(dayPath, fmt.Sprintf("exercises[%d]", k))
+				if exercise.Position == 0 {
+					exercise.Position = k + 1
+				}
+				if strings.TrimSpace(exercise.Name) == "" {
+					v.Add(fieldPath(exercisePath, "name"), "is required")
+				}
+				if exercise.Sets < 1 {
+					v.Add(fieldPath(exercisePath, "sets"), "must be at least 1")
+				}
+				if exercise.Reps < 1 {
+					v.Add(fieldPath(exercisePath, "reps"), "must be at least 1")
+				}
+				if p := exercise.Percent1RM; p != nil && (*p <= 0 || *p > 110) {
+					v.Add(fieldPath(exercisePath, "percent_1rm"), "must be between 0 and 110")
+				}
+			}
+		}
+	}
+	return v.OrNil()
+}
+
+// EnrolInProgram starts the user on a program from the day of start in its
+// location, ending any program they were following.
+func EnrolInProgram(ctx context.Context, userID, programID int, start time.Time) (*Enrolment, error) {
+	program, err := store.FetchProgram(ctx, programID)
+	if err != nil {
+		return nil, err
+	}
+	if program == nil {
+		return nil, ErrProgramNotFound
+	}
+	enrolment, err := store.CreateEnrolment(ctx, Enrolment{UserID: userID, ProgramID: programID, StartedOn: DayOf(start, start.Location()), CreatedAt: time.Now()})
+	if err != nil {
+		return nil, err
+	}
+	slog.InfoContext(ctx, "Enrolled user in program", "user_id", userID, "program_id", programID, "started_on", enrolment.StartedOn.Format(time.DateOnly))
+	return enrolment, nil
+}
+
+// FetchActiveEnrolment retrieves the program the user is following, or nil.
+func FetchActiveEnrolment(ctx context.Context, userID int) (*Enrolment, error) {
+	return store.FetchActiveEnrolment(ctx, userID)
+}
+
+// EndEnrolment stops the user following their current program.
+func EndEnrolment(ctx context.Context, userID int) error {
+	return store.EndEnrolment(ctx, userID, time.Now())
+}
+
+// FetchProgramSession returns the session the user's program prescribes for
+// the day containing now in the user's time zone, or nil if the user is not
+// following a program or has finished it.
+func FetchProgramSession(ctx context.Context, userID int, loc *time.Location, now time.Time) (*ProgramSession, error) {
+	enrolment, err := FetchActiveEnrolment(ctx, userID)
+	if err != nil || enrolment == nil {
+		return nil, err
+	}
+	day := DayOf(now, loc)
+	if day.Before(enrolment.StartedOn) {
+		return nil, nil
+	}
+	program, err := FetchProgram(ctx, enrolment.ProgramID)
+	if err != nil || program == nil {
+		return nil, err
+	}
+	elapsed := int(day.Sub(enrolment.StartedOn).Hours() / 24)
+	weekNumber, dayNumber := elapsed/7+1, elapsed%7+1
+	var week *ProgramWeek
+	for i := range program.Weeks {
+		if program.Weeks[i].WeekNumber == weekNumber {
+			week = &program.Weeks[i]
+		}
+	}
+	if week == nil {
+		return nil, nil
+	}
+
+	session := &ProgramSession{
+		Kind:        WODKindProgram,
+		Day:         day,
+		ProgramID:   program.ID,
+		ProgramName: program.Name,
+		Week:        weekNumber,
+		DayNumber:   dayNumber,
+		Focus:       week.Focus,
+		Rest:        true,
+	}
+	for _, programDay := range week.Days {
+		if programDay.DayNumber != dayNumber {
+			continue
+		}
+		session.Rest = false
+		session.WorkoutType = programDay.WorkoutType
+		maxes, err := estimateOneRepMaxes(ctx, userID, now)
+		if err != nil {
+			return nil, err
+		}
+		for _, exercise := range programDay.Exercises {
+			prescribed := PrescribedExercise{Name: exercise.Name, Sets: exercise.Sets, Reps: exercise.Reps, Percent1RM: exercise.Percent1RM}
+			if oneRepMax, ok := maxes[strings.ToLower(exercise.Name)]; ok && exercise.Percent1RM != nil {
+				target := roundTo(oneRepMax*(*exercise.Percent1RM)/100, 2.5)
+				prescribed.TargetWeight = &target
+				prescribed.Unit = UnitKg
+			}
+			session.Exercises = append(session.Exercises, prescribed)
+		}
+	}
+	return session, nil
+}
+
+// estimateOneRepMaxes returns the best estimated one-rep max in kilograms of
+// each exercise the user logged recently, keyed by lower-case name. Estimates
+// use the Epley formula on working sets.
+func estimateOneRepMaxes(ctx context.Context, userID int, now time.Time) (map[string]float64, error) {
+	weightsLogs, err := store.FetchWeightsLogsBetween(ctx, &userID, now.Add(-oneRepMaxWindow), now)
+	if err != nil {
+		return nil, err
+	}
+	maxes := map[string]float64{}
+	for _, weightsLog := range weightsLogs {
+		for _, exercise := range weightsLog.Exercises {
+			for _, set := range exercise.Sets {
+				if set.SetType != SetTypeWorking || set.Reps < 1 || set.Weight <= 0 {
+					continue
+				}
+				estimate := Epley(toKg(set.Weight, set.Unit), set.Reps)
+				name := strings.ToLower(exercise.Name)
+				if estimate > maxes[name] {
+					maxes[name] = estimate
+				}
+			}
+		}
+	}
+	return maxes, nil
+}
+
+// AddProgram adds a new program, prepared by prepareProgram, with its weeks,
+// days and exercises
+func AddProgram(ctx context.Context, program Program) error {
+	program.CreatedAt = time.Now()
+	return store.AddProgram(ctx, program)
+}
+
+// UpdateProgram updates an existing program, prepared by prepareProgram,
+// replacing its weeks unless none are given
+func UpdateProgram(ctx context.Context, program Program) error {
+	if program.Weeks == nil {
+		existing, err := FetchProgram(ctx, program.ID)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			program.Weeks = existing.Weeks
+		}
+	}
+	return store.UpdateProgram(ctx, program)
+}
+
+// DeleteProgram deletes a program, its weeks and its enrolments
+func DeleteProgram(ctx context.Context, id int) error {
+	return store.DeleteProgram(ctx, id)
+}
+
+// EmptyPrograms deletes every program and enrolment
+func EmptyPrograms(ctx context.Context) error {
+	return store.EmptyPrograms(ctx)
+}
@@ -0,0 +1,291 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// ErrInvalidProgression is returned for unknown progression strategies or
+// inconsistent rep ranges.
+var ErrInvalidProgression = errors.New("invalid progression options")
+
+// Progression strategies. StrategyAuto uses linear progression for low-rep
+// strength work and double progression otherwise.
+const (
+	StrategyAuto   = "auto"
+	StrategyLinear = "linear"
+	StrategyDouble = "double"
+	StrategyDeload = "deload"
+)
+
+// Defaults and limits of ProgressionOptions.
+const (
+	DefaultProgressionSessions = 5
+	MaxProgressionSessions     = 20
+	DefaultMinReps             = 8
+	DefaultMaxReps             = 12
+)
+
+// linearMaxReps is the highest rep count auto treats as strength work that
+// progresses linearly.
+const linearMaxReps = 6
+
+// stallSessions is how many sessions in a row without a better estimated
+// one-rep max count as a stall that calls for a deload.
+const stallSessions = 3
+
+// deloadFactor is the share of the working weight kept after a stall.
+const deloadFactor = 0.9
+
+// ProgressionOptions controls the weights suggested for the next session.
+type ProgressionOptions struct {
+	UserID      int
+	WorkoutType string
+	Sessions    int    // Number of previous sessions considered
+	Strategy    string // StrategyAuto, StrategyLinear or StrategyDouble
+	MinReps     int    // Rep range of double progression
+	MaxReps     int
+	Unit        string // Weight unit of the suggestion, kg by default
+}
+
+// normalize fills in defaults and checks the options. Invalid fields are
+// named after the query parameters of the suggestion endpoint.
+func (o *ProgressionOptions) normalize() error {
+	v := newValidation(ErrInvalidProgression)
+	o.WorkoutType = strings.ToLower(strings.TrimSpace(o.WorkoutType))
+	if o.Sessions <= 0 {
+		o.Sessions = DefaultProgressionSessions
+	}
+	if o.Sessions > MaxProgressionSessions {
+		o.Sessions = MaxProgressionSessions
+	}
+	switch o.Strategy {
+	case "":
+		o.Strategy = StrategyAuto
+	case StrategyAuto, StrategyLinear, StrategyDouble:
+	default:
+		v.Add("strategy", "must be %s, %s or %s", StrategyAuto, StrategyLinear, StrategyDouble)
+	}
+	switch o.Unit {
+	case "":
+		o.Unit = UnitKg
+	case UnitKg, UnitLb:
+	default:
+		v.Add("unit", "must be %s or %s", UnitKg, UnitLb)
+	}
+	if o.MinReps == 0 && o.MaxReps == 0 {
+		o.MinReps, o.MaxReps = DefaultMinReps, DefaultMaxReps
+	}
+	if o.MinReps < 1 {
+		v.Add("min_reps", "must be at least 1")
+	} else if o.MaxReps < o.MinReps {
+		v.Add("max_reps", "must be at least min_reps (%d)", o.MinReps)
+	}
+	return v.OrNil()
+}
+
+// WeightsSuggestion is the recommended next session of a weights workout type.
+type WeightsSuggestion struct {
+	WorkoutType string               `json:"workout_type"`
+	Sessions    int                  `json:"sessions"` // Previous sessions found, at most the number requested
+	Exercises   []ExerciseSuggestion `json:"exercises"`
+}
+
+// ExerciseSuggestion is the recommended weight and reps of one exercise.
+// Weight is zero for exercises without logged working sets.
+type ExerciseSuggestion struct {
+	Name       string  `json:"name"`
+	Strategy   string  `json:"strategy,omitempty"` // linear, double or deload
+	Sets       int     `json:"sets,omitempty"`
+	Reps       int     `json:"reps,omitempty"`
+	Weight     float64 `json:"weight"`
+	Unit       string  `json:"unit,omitempty"`
+	LastWeight float64 `json:"last_weight,omitempty"`
+	LastReps   []int   `json:"last_reps,omitempty"` // Reps of each set at the last working weight
+	Reason     string  `json:"reason"`
+}
+
+// exercisePerformance is how an exercise went in one session, in the unit of
+// the suggestion.
+type exercisePerformance struct {
+	weight    float64 // Heaviest working weight
+	reps      []int   // Reps of the sets at that weight
+	oneRepMax float64 // Best Epley estimate of the session
+}
+
+// SuggestWeights recommends the weight and reps of each exercise of a workout
+// type for the next session, from the user's last sessions of that type.
+func SuggestWeights(ctx context.Context, opts ProgressionOptions) (*WeightsSuggestion, error) {
+	if err := opts.normalize(); err != nil {
+		return nil, err
+	}
+	page, err := FetchLoggedWeightsWorkouts(ctx, LogQuery{UserID: opts.UserID, Type: opts.WorkoutType, Limit: opts.Sessions})
+	if err != nil {
+		return nil, err
+	}
+	catalogue, err := FetchWeightWorkouts(ctx, opts.WorkoutType)
+	if err != nil {
+		return nil, err
+	}
+
+	// Exercises in catalogue order, then any others that were logged
+	var names []string
+	seen := map[string]bool{}
+	addName := func(name string) {
+		if key := strings.ToLower(name); !seen[key] {
+			seen[key] = true
+			names = append(names, name)
+		}
+	}
+	for _, weightWorkout := range catalogue {
+		addName(weightWorkout.Exercise)
+	}
+	for _, weightsLog := range page.Items {
+		for _, exercise := range weightsLog.Exercises {
+			addName(exercise.Name)
+		}
+	}
+
+	suggestion := &WeightsSuggestion{WorkoutType: opts.WorkoutType, Sessions: len(page.Items), Exercises: []ExerciseSuggestion{}}
+	for _, name := range names {
+		suggestion.Exercises = append(suggestion.Exercises, suggestExercise(name, page.Items, opts))
+	}
+	return suggestion, nil
+}
+
+// suggestExercise applies the progression rules to the sessions, newest
+// first, of one exercise.
+func suggestExercise(name string, weightsLogs []WeightsLog, opts ProgressionOptions) ExerciseSuggestion {
+	suggestion := ExerciseSuggestion{Name: name}
+	unit := opts.Unit
+	var history []exercisePerformance
+	for _, weightsLog := range weightsLogs {
+		for _, exercise := range weightsLog.Exercises {
+			if !strings.EqualFold(exercise.Name, name) {
+				continue
+			}
+			if performance, ok := performanceOf(exercise.Sets, unit); ok {
+				history = append(history, performance)
+			}
+		}
+	}
+	if len(history) == 0 {
+		suggestion.Reason = fmt.Sprintf("no working sets in the last %d sessions; pick a weight you can lift for %d-%d reps", opts.Sessions, opts.MinReps, opts.MaxReps)
+		return suggestion
+	}
+
+	last := history[0]
+	increment := 2.5
+	if unit == UnitLb {
+		increment = 5
+	}
+	suggestion.Unit = unit
+	suggestion.Sets = len(last.reps)
+	suggestion.LastWeight = last.weight
+	suggestion.LastReps = last.reps
+	minDone, maxDone := last.reps[0], last.reps[0]
+	for _, reps := range last.reps {
+		minDone = min(minDone, reps)
+		maxDone = max(maxDone, reps)
+	}
+
+	strategy := opts.Strategy
+	if strategy == StrategyAuto {
+		strategy = StrategyDouble
+		if maxDone <= linearMaxReps {
+			strategy = StrategyLinear
+		}
+	}
+
+	if stalled(history) {
+		suggestion.Strategy = StrategyDeload
+		suggestion.Weight = roundTo(last.weight*deloadFactor, increment/2)
+		suggestion.Reps = maxDone
+		if strategy == StrategyDouble {
+			suggestion.Reps = opts.MinReps
+		}
+		suggestion.Reason = fmt.Sprintf("no progress in the last %d sessions; deload to %.0f%% and build back up", stallSessions, deloadFactor*100)
+		return suggestion
+	}
+
+	suggestion.Strategy = strategy
+	switch strategy {
+	case StrategyLinear:
+		suggestion.Reps = maxDone
+		if minDone >= maxDone {
+			suggestion.Weight = last.weight + increment
+			suggestion.Reason = fmt.Sprintf("all %d sets of %d done at %g %s; add %g %s", len(last.reps), maxDone, last.weight, unit, increment, unit)
+		} else {
+			suggestion.Weight = last.weight
+			suggestion.Reason = fmt.Sprintf("not every set reached %d reps at %g %s; repeat the weight", maxDone, last.weight, unit)
+		}
+	case StrategyDouble:
+		if minDone >= opts.MaxReps {
+			suggestion.Weight = last.weight + increment
+			suggestion.Reps = opts.MinReps
+			suggestion.Reason = fmt.Sprintf("every set reached the top of the %d-%d range; add %g %s and start again at %d reps", opts.MinReps, opts.MaxReps, increment, unit, opts.MinReps)
+		} else {
+			suggestion.Weight = last.weight
+			suggestion.Reps = min(opts.MaxReps, max(opts.MinReps, minDone+1))
+			suggestion.Reason = fmt.Sprintf("keep %g %s and aim for %d reps on every set before adding weight at %d", last.weight, unit, suggestion.Reps, opts.MaxReps)
+		}
+	}
+	return suggestion
+}
+
+// stalled reports whether the last stallSessions sessions failed to beat the
+// best estimated one-rep max of the sessions before them at a comparable
+// weight. Sessions after a deload are not counted as a stall.
+func stalled(history []exercisePerformance) bool {
+	if len(history) <= stallSessions {
+		return false
+	}
+	var before, beforeWeight float64
+	for _, performance := range history[stallSessions:] {
+		before = math.Max(before, performance.oneRepMax)
+		beforeWeight = math.Max(beforeWeight, performance.weight)
+	}
+	for _, performance := range history[:stallSessions] {
+		if performance.oneRepMax > before || performance.weight < beforeWeight*0.95 {
+			return false
+		}
+	}
+	return true
+}
+
+// performanceOf summarises the working sets of an exercise in a unit. It
+// reports false when there are no working sets with reps and weight.
+func performanceOf(sets []ExerciseSet, unit string) (exercisePerformance, bool) {
+	var performance exercisePerformance
+	for _, set := range sets {
+		if set.SetType != SetTypeWorking || set.Reps < 1 || set.Weight <= 0 {
+			continue
+		}
+		weight := FromKg(toKg(set.Weight, set.Unit), unit)
+		switch {
+		case weight > performance.weight:
+			performance.weight = weight
+			performance.reps = []int{set.Reps}
+		case weight == performance.weight:
+			performance.reps = append(performance.reps, set.Reps)
+		}
+		performance.oneRepMax = math.Max(performance.oneRepMax, Epley(weight, set.Reps))
+	}
+	return performance, performance.weight > 0
+}
+
+// Epley estimates a one-rep max as weight × (1 + reps / 30).
+func Epley(weight float64, reps int) float64 {
+	if reps == 1 {
+		return weight
+	}
+	return weight * (1 + float64(reps)/30)
+}
+
+// roundTo rounds a weight to the nearest multiple of step.
+func roundTo(weight, step float64) float64 {
+	return math.Round(weight/step) * step
+}
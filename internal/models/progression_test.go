@@ -0,0 +1,123 @@
+package models
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEpley(t *testing.T) {
+	tests := []struct {
+		weight float64
+		reps   int
+		want   float64
+	}{
+		{weight: 100, reps: 1, want: 100},
+		{weight: 100, reps: 10, want: 133.33},
+		{weight: 60, reps: 5, want: 70},
+		{weight: 0, reps: 8, want: 0},
+	}
+	for _, tt := range tests {
+		if got := Epley(tt.weight, tt.reps); math.Abs(got-tt.want) > 0.01 {
+			t.Errorf("Epley(%g, %d) = %g, want %g", tt.weight, tt.reps, got, tt.want)
+		}
+	}
+}
+
+// session returns a weights log with one exercise and a working set of weight
+// kg for each rep count.
+func session(name string, weight float64, reps ...int) WeightsLog {
+	exercise := Exercise{Name: name}
+	for i, r := range reps {
+		exercise.Sets = append(exercise.Sets, ExerciseSet{SetNumber: i + 1, Reps: r, Weight: weight, Unit: UnitKg, SetType: SetTypeWorking})
+	}
+	return WeightsLog{WorkoutType: "push", Exercises: []Exercise{exercise}}
+}
+
+func TestSuggestExercise(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     ProgressionOptions
+		sessions []WeightsLog // Newest first
+		strategy string
+		weight   float64
+		reps     int
+	}{
+		{
+			name:     "no working sets",
+			sessions: nil,
+			weight:   0,
+		},
+		{
+			name:     "linear adds weight after every set is done",
+			sessions: []WeightsLog{session("Bench", 100, 5, 5, 5)},
+			strategy: StrategyLinear,
+			weight:   102.5,
+			reps:     5,
+		},
+		{
+			name:     "linear repeats the weight after a missed set",
+			sessions: []WeightsLog{session("Bench", 100, 5, 5, 4)},
+			strategy: StrategyLinear,
+			weight:   100,
+			reps:     5,
+		},
+		{
+			name:     "double adds weight at the top of the range",
+			sessions: []WeightsLog{session("Bench", 60, 12, 12, 12)},
+			strategy: StrategyDouble,
+			weight:   62.5,
+			reps:     8,
+		},
+		{
+			name:     "double adds a rep within the range",
+			sessions: []WeightsLog{session("Bench", 60, 10, 9, 9)},
+			strategy: StrategyDouble,
+			weight:   60,
+			reps:     10,
+		},
+		{
+			name:     "pounds use 5 lb increments",
+			opts:     ProgressionOptions{Unit: UnitLb},
+			sessions: []WeightsLog{session("Bench", 100, 5, 5, 5)},
+			strategy: StrategyLinear,
+			weight:   225.5,
+			reps:     5,
+		},
+		{
+			name: "deload after three sessions without progress",
+			sessions: []WeightsLog{
+				session("Bench", 100, 5, 5, 5),
+				session("Bench", 100, 5, 5, 4),
+				session("Bench", 100, 5, 5, 5),
+				session("Bench", 100, 5, 5, 5),
+			},
+			strategy: StrategyDeload,
+			weight:   90,
+			reps:     5,
+		},
+		{
+			name: "no deload while the estimate improves",
+			sessions: []WeightsLog{
+				session("Bench", 100, 6, 5, 5),
+				session("Bench", 100, 5, 5, 5),
+				session("Bench", 100, 5, 5, 5),
+				session("Bench", 100, 5, 5, 5),
+			},
+			strategy: StrategyLinear,
+			weight:   100,
+			reps:     6,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.opts
+			if err := opts.normalize(); err != nil {
+				t.Fatal(err)
+			}
+			got := suggestExercise("Bench", tt.sessions, opts)
+			if got.Strategy != tt.strategy || got.Weight != tt.weight || got.Reps != tt.reps {
+				t.Errorf("got %s %g x %d, want %s %g x %d (%s)", got.Strategy, got.Weight, got.Reps, tt.strategy, tt.weight, tt.reps, got.Reason)
+			}
+		})
+	}
+}
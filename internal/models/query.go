@@ -0,0 +1,102 @@
+package models
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Page size limits for the log endpoints.
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 500
+)
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// LogQuery filters and paginates a user's logged workouts. Results are
+// ordered newest first; Cursor takes precedence over Offset when both are set.
+type LogQuery struct {
+	UserID int
+	From   *time.Time // Inclusive lower bound on the workout date
+	To     *time.Time // Exclusive upper bound on the workout date
+	Type   string     // Workout type, matched case-insensitively
+	Limit  int
+	Offset int
+	Cursor *Cursor
+	// CardioTypeID is the cardio type Type resolves to through the names and
+	// aliases of the catalogue. Cardio workouts of that type match as well.
+	CardioTypeID *int
+}
+
+// Cursor marks the last item of a page so the next page can continue after it
+// even if new workouts are logged in between.
+type Cursor struct {
+	Date time.Time
+	ID   int
+}
+
+// Page is a single page of results from a paginated endpoint.
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"` // Empty on the last page
+	Total      int    `json:"total"`                 // Number of items matching the filters
+}
+
+// Encode returns the opaque string form of the cursor used in API responses.
+func (c Cursor) Encode() string {
+	raw := fmt.Sprintf("%d|%s", c.ID, c.Date.Format(time.RFC3339Nano))
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeCursor parses a cursor produced by Cursor.Encode.
+func DecodeCursor(s string) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	id, date, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+	i, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	d, err := time.Parse(time.RFC3339Nano, date)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &Cursor{Date: d.Local(), ID: i}, nil
+}
+
+// normalize clamps the page size to the allowed range.
+func (q *LogQuery) normalize() {
+	if q.Limit <= 0 {
+		q.Limit = DefaultPageSize
+	}
+	if q.Limit > MaxPageSize {
+		q.Limit = MaxPageSize
+	}
+	if q.Offset < 0 {
+		q.Offset = 0
+	}
+}
+
+// buildPage trims the extra item fetched to detect a following page and sets
+// the next cursor from the last item kept.
+func buildPage[T any](items []T, total int, limit int, cursorOf func(T) Cursor) Page[T] {
+	page := Page[T]{Items: items, Total: total}
+	if len(items) > limit {
+		page.Items = items[:limit]
+		page.NextCursor = cursorOf(page.Items[limit-1]).Encode()
+	}
+	if page.Items == nil {
+		page.Items = []T{}
+	}
+	return page
+}
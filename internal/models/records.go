@@ -0,0 +1,269 @@
+package models
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"momentum/internal/metrics"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kinds of personal record.
+const (
+	RecordHeaviestSet = "heaviest_set" // Heaviest weight lifted for at least one rep
+	RecordBestE1RM    = "best_e1rm"    // Best Epley estimate of the one-rep max
+	RecordMostReps    = "most_reps"    // Most reps in one set at a given weight
+	RecordFastest5K   = "fastest_5k"   // Fastest 5 km, from the average pace of a run of at least 5 km
+	RecordLongestRide = "longest_ride" // Longest distance ridden in one workout
+)
+
+// fiveK is the distance of the fastest_5k record in kilometers.
+const fiveK = 5.0
+
+// PersonalRecord is a best performance of a user. Weights records are kept in
+// kilograms, whatever unit the set was logged in.
+type PersonalRecord struct {
+	ID           int       `json:"id"`
+	UserID       int       `json:"user_id" db:"user_id"`
+	Kind         string    `json:"kind"`
+	Exercise     string    `json:"exercise"`                                     // Exercise name, or cardio type name for cardio records
+	Value        float64   `json:"value"`                                        // kg, reps, seconds or km depending on the kind
+	Weight       float64   `json:"weight,omitempty"`                             // Weight of the set in kg
+	Reps         int       `json:"reps,omitempty"`                               // Reps of the set
+	WeightsLogID *int      `json:"weights_log_id,omitempty" db:"weights_log_id"` // Weights log that set the record
+	WorkoutID    *int      `json:"workout_id,omitempty" db:"workout_id"`         // Cardio workout that set the record
+	AchievedAt   time.Time `json:"achieved_at" db:"achieved_at"`
+	Previous     *float64  `json:"previous,omitempty" db:"-"` // Value of the record it beat, when returned for a new log
+	Unit         string    `json:"unit,omitempty" db:"-"`     // Unit of Value and Weight in responses
+}
+
+// key identifies what a record is a best of.
+func (r PersonalRecord) key() string {
+	key := r.Kind + "/" + strings.ToLower(r.Exercise)
+	if r.Kind == RecordMostReps {
+		key += "/" + strconv.FormatFloat(r.Weight, 'f', 1, 64)
+	}
+	return key
+}
+
+// beats reports whether the record is better than another of the same key.
+func (r PersonalRecord) beats(other PersonalRecord) bool {
+	if r.Kind == RecordFastest5K {
+		return r.Value < other.Value
+	}
+	return r.Value > other.Value
+}
+
+// LogResult is returned when a workout is logged.
+type LogResult struct {
+	ID             int              `json:"id"`
+	PersonalRecord bool             `json:"personal_record"` // Whether the log set at least one new record
+	Records        []PersonalRecord `json:"records"`         // The new records
+}
+
+// FetchPersonalRecords retrieves the user's current records, or every record
+// ever set when history is true, optionally only those of a kind or an
+// exercise.
+func FetchPersonalRecords(ctx context.Context, userID int, kind, exercise string, history bool) ([]PersonalRecord, error) {
+	records, err := store.FetchPersonalRecords(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !history {
+		records = currentRecords(records)
+	}
+	filtered := []PersonalRecord{}
+	for _, r := range records {
+		if (kind == "" || r.Kind == kind) && (exercise == "" || strings.EqualFold(r.Exercise, exercise)) {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered, nil
+}
+
+// currentRecords returns the newest record of each key from the records in the
+// order they were set.
+func currentRecords(records []PersonalRecord) []PersonalRecord {
+	latest := map[string]int{}
+	var order []string
+	for i, r := range records {
+		key := r.key()
+		if _, ok := latest[key]; !ok {
+			order = append(order, key)
+		}
+		latest[key] = i
+	}
+	current := make([]PersonalRecord, 0, len(order))
+	for _, key := range order {
+		current = append(current, records[latest[key]])
+	}
+	return current
+}
+
+// weightsRecords returns the best performances of each exercise of a weights log.
+func weightsRecords(weightsLog WeightsLog) []PersonalRecord {
+	best := map[string]PersonalRecord{}
+	var order []string
+	consider := func(r PersonalRecord) {
+		key := r.key()
+		current, ok := best[key]
+		if !ok {
+			order = append(order, key)
+		}
+		if !ok || r.beats(current) {
+			best[key] = r
+		}
+	}
+	for _, exercise := range weightsLog.Exercises {
+		for _, set := range exercise.Sets {
+			if set.SetType == SetTypeWarmup || set.Reps < 1 || set.Weight <= 0 {
+				continue
+			}
+			weight := toKg(set.Weight, set.Unit)
+			for _, r := range []PersonalRecord{
+				{Kind: RecordHeaviestSet, Value: weight},
+				{Kind: RecordBestE1RM, Value: math.Round(Epley(weight, set.Reps)*10) / 10},
+				{Kind: RecordMostReps, Value: float64(set.Reps)},
+			} {
+				r.Exercise, r.Weight, r.Reps, r.AchievedAt = exercise.Name, weight, set.Reps, weightsLog.Date
+				consider(r)
+			}
+		}
+	}
+	records := make([]PersonalRecord, 0, len(order))
+	for _, key := range order {
+		records = append(records, best[key])
+	}
+	return records
+}
+
+// cardioRecords returns the records a cardio workout could set.
+func cardioRecords(workout Workout, cardioType *CardioType) []PersonalRecord {
+	if cardioType == nil {
+		return nil
+	}
+	switch {
+	case cardioType.Category == "running" && workout.Distance >= fiveK && workout.Duration > 0:
+		seconds := math.Round(workout.Duration * fiveK / workout.Distance)
+		return []PersonalRecord{{Kind: RecordFastest5K, Exercise: cardioType.Name, Value: seconds, AchievedAt: workout.Date}}
+	case cardioType.Category == "cycling" && workout.Distance > 0:
+		return []PersonalRecord{{Kind: RecordLongestRide, Exercise: cardioType.Name, Value: workout.Distance, AchievedAt: workout.Date}}
+	}
+	return nil
+}
+
+// beatRecords returns the candidates that beat the records in current, which
+// maps record keys to the current record, and updates it with them.
+func beatRecords(current map[string]PersonalRecord, userID int, candidates []PersonalRecord) []PersonalRecord {
+	records := []PersonalRecord{}
+	for _, candidate := range candidates {
+		candidate.UserID = userID
+		previous, ok := current[candidate.key()]
+		if ok && !candidate.beats(previous) {
+			continue
+		}
+		if ok {
+			value := previous.Value
+			candidate.Previous = &value
+		}
+		current[candidate.key()] = candidate
+		records = append(records, candidate)
+	}
+	return records
+}
+
+// recordPersonalBests stores the candidates that beat the user's current
+// records and returns them.
+func recordPersonalBests(ctx context.Context, userID int, candidates []PersonalRecord) ([]PersonalRecord, error) {
+	if len(candidates) == 0 {
+		return []PersonalRecord{}, nil
+	}
+	records, err := store.AddPersonalRecords(ctx, userID, func(history []PersonalRecord) []PersonalRecord {
+		current := make(map[string]PersonalRecord, len(history))
+		for _, r := range currentRecords(history) {
+			current[r.key()] = r
+		}
+		return beatRecords(current, userID, candidates)
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range records {
+		slog.InfoContext(ctx, "New personal record", "user_id", userID, "kind", r.Kind, "exercise", r.Exercise, "value", r.Value)
+		metrics.PersonalRecordSet(r.Kind)
+	}
+	return records, nil
+}
+
+// recomputeRecords rebuilds the record history of the owners of logs changed
+// through the admin API by replaying their logs in date order, since editing
+// or deleting a log can change every record set after it. Logs without an
+// owner have no records.
+func recomputeRecords(ctx context.Context, owners ...*int) error {
+	done := map[int]bool{}
+	for _, owner := range owners {
+		if owner == nil || done[*owner] {
+			continue
+		}
+		done[*owner] = true
+		if err := replayRecords(ctx, *owner); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// replayRecords replaces the records of a user with those their logs set.
+func replayRecords(ctx context.Context, userID int) error {
+	cardioTypes, err := store.ViewCardioTypes(ctx)
+	if err != nil {
+		return err
+	}
+	from, to := time.Time{}, time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+	workouts, err := store.FetchWorkoutsBetween(ctx, &userID, from, to)
+	if err != nil {
+		return err
+	}
+	weightsLogs, err := store.FetchWeightsLogsBetween(ctx, &userID, from, to)
+	if err != nil {
+		return err
+	}
+	var candidates []PersonalRecord
+	for _, workout := range workouts {
+		id := workout.ID
+		for _, r := range cardioRecords(workout, matchCardio(cardioTypes, workout.Type)) {
+			r.WorkoutID = &id
+			candidates = append(candidates, r)
+		}
+	}
+	for _, weightsLog := range weightsLogs {
+		id := weightsLog.ID
+		for _, r := range weightsRecords(weightsLog) {
+			r.WeightsLogID = &id
+			candidates = append(candidates, r)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].AchievedAt.Before(candidates[j].AchievedAt) })
+	records := beatRecords(map[string]PersonalRecord{}, userID, candidates)
+	for i := range records {
+		records[i].Previous = nil
+	}
+	return store.ReplacePersonalRecords(ctx, userID, records)
+}
+
+// logResult detects the records set by a new log. The log is already saved,
+// so failing to store its records is logged rather than returned.
+func logResult(ctx context.Context, id, userID int, candidates []PersonalRecord) *LogResult {
+	result := &LogResult{ID: id, Records: []PersonalRecord{}}
+	records, err := recordPersonalBests(ctx, userID, candidates)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error detecting personal records", "log_id", id, "err", err)
+		return result
+	}
+	result.Records = records
+	result.PersonalRecord = len(records) > 0
+	return result
+}
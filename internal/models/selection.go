@@ -0,0 +1,242 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WeeklyTarget is the training volume the workout of the day aims for over
+// any seven consecutive days. A zero target is ignored.
+type WeeklyTarget struct {
+	CardioMinutes   int
+	WeightsSessions int
+}
+
+// DefaultWeeklyTarget follows the common guideline of 150 minutes of cardio
+// and a few strength sessions a week.
+var DefaultWeeklyTarget = WeeklyTarget{CardioMinutes: 150, WeightsSessions: 3}
+
+var weeklyTarget = DefaultWeeklyTarget
+
+// historyDays is how far back the selection looks for the last session of the
+// push/pull/legs rotation.
+const historyDays = 14
+
+// SetWeeklyTarget sets the weekly volume used to choose between cardio and
+// weights sessions.
+func SetWeeklyTarget(target WeeklyTarget) error {
+	if target.CardioMinutes < 0 || target.WeightsSessions < 0 {
+		return fmt.Errorf("weekly target cannot be negative: %+v", target)
+	}
+	weeklyTarget = target
+	return nil
+}
+
+// trainingSession is a past session as seen by the WOD selection.
+type trainingSession struct {
+	UserID   int // Who trained (0 for logs without an owner)
+	Day      time.Time
+	Kind     string  // WODKindCardio or WODKindWeights
+	Modality string  // Cardio category (e.g., running) or weights type (e.g., push)
+	Minutes  float64 // Cardio duration
+}
+
+// candidate is a possible workout of the day with its score.
+type candidate struct {
+	assignment WODAssignment
+	modality   string
+	score      float64
+	reasons    []string
+}
+
+// selectWOD picks the workout of the day from the WODs and the next session of
+// the weights rotation, balancing the recent training history. Candidates that
+// repeat yesterday's modality are penalised, the kind furthest from its weekly
+// target is preferred, and cardio modalities done less this week win ties.
+// When the history holds several users' training, the weekly volume is their
+// average. Remaining ties are broken by a hash of the day so the pick is
+// repeatable.
+func selectWOD(day time.Time, owner *int, wods []WOD, weightsTypes []string, cardioTypes []CardioType, history []trainingSession) (WODAssignment, bool) {
+	yesterday := day.AddDate(0, 0, -1)
+	weekStart := day.AddDate(0, 0, -6)
+	yesterdays := map[string]bool{}
+	weekly := map[string]int{}
+	trainees := map[int]bool{}
+	var cardioMinutes, weightsSessions float64
+	var lastWeights *trainingSession
+	for i, session := range history {
+		if session.Day.Equal(yesterday) {
+			yesterdays[session.Modality] = true
+		}
+		if !session.Day.Before(weekStart) {
+			trainees[session.UserID] = true
+			weekly[session.Modality]++
+			if session.Kind == WODKindCardio {
+				cardioMinutes += session.Minutes
+			} else {
+				weightsSessions++
+			}
+		}
+		if session.Kind == WODKindWeights && (lastWeights == nil || !session.Day.Before(lastWeights.Day)) {
+			lastWeights = &history[i]
+		}
+	}
+	if n := len(trainees); n > 1 {
+		cardioMinutes /= float64(n)
+		weightsSessions /= float64(n)
+	}
+
+	var candidates []candidate
+	for _, wod := range wods {
+		modality := strings.ToLower(wod.Type)
+		if cardioType := matchCardio(cardioTypes, wod.Type); cardioType != nil {
+			modality = cardioType.Category
+		}
+		id := wod.ID
+		c := candidate{
+			assignment: WODAssignment{Kind: WODKindCardio, WODID: &id, Type: wod.Type, Duration: wod.Duration, Distance: wod.Distance},
+			modality:   modality,
+		}
+		c.addVolume("cardio", "minutes", cardioMinutes, weeklyTarget.CardioMinutes)
+		if remaining := float64(weeklyTarget.CardioMinutes) - cardioMinutes; remaining > 0 && float64(wod.Duration) <= remaining {
+			c.score++
+		}
+		if n := weekly[modality]; n > 0 {
+			c.score -= float64(n)
+			c.reasons = append(c.reasons, fmt.Sprintf("%s done %d times in the last 7 days", modality, n))
+		} else {
+			c.reasons = append(c.reasons, fmt.Sprintf("no %s in the last 7 days", modality))
+		}
+		candidates = append(candidates, c)
+	}
+	if len(weightsTypes) > 0 {
+		next, reason := weightsTypes[0], fmt.Sprintf("starts the %s rotation", strings.Join(weightsTypes, "/"))
+		if lastWeights != nil {
+			for i, weightsType := range weightsTypes {
+				if weightsType == lastWeights.Modality {
+					next = weightsTypes[(i+1)%len(weightsTypes)]
+					reason = fmt.Sprintf("%s follows %s on %s in the %s rotation", next, lastWeights.Modality, lastWeights.Day.Format("2006-01-02"), strings.Join(weightsTypes, "/"))
+				}
+			}
+		}
+		c := candidate{
+			assignment: WODAssignment{Kind: WODKindWeights, Type: next},
+			modality:   next,
+			reasons:    []string{reason},
+		}
+		c.addVolume("weights", "sessions", weightsSessions, weeklyTarget.WeightsSessions)
+		candidates = append(candidates, c)
+	}
+	if len(candidates) == 0 {
+		return WODAssignment{}, false
+	}
+
+	for i := range candidates {
+		c := &candidates[i]
+		if yesterdays[c.modality] {
+			c.score -= 100
+			c.reasons = append(c.reasons, fmt.Sprintf("repeats yesterday's %s because nothing else was available", c.modality))
+		} else if len(yesterdays) > 0 {
+			c.reasons = append(c.reasons, fmt.Sprintf("avoids repeating yesterday's %s", strings.Join(sortedKeys(yesterdays), " and ")))
+		}
+		c.score += tieBreak(day, owner, c.assignment.Type)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].score > candidates[j].score })
+	best := candidates[0]
+	best.assignment.Reasons = JSONList(best.reasons)
+	return best.assignment, true
+}
+
+// addVolume scores a candidate by how far its kind is from the weekly target.
+func (c *candidate) addVolume(kind, unit string, done float64, target int) {
+	if target <= 0 {
+		return
+	}
+	need := math.Max(0, 1-done/float64(target))
+	c.score += 10 * need
+	if need == 0 {
+		c.reasons = append(c.reasons, fmt.Sprintf("weekly %s target of %d %s already met", kind, target, unit))
+		return
+	}
+	c.reasons = append(c.reasons, fmt.Sprintf("%.0f of %d weekly %s %s done", done, target, kind, unit))
+}
+
+// tieBreak returns a small score in [0, 0.5) derived from the day, owner and
+// workout type, so equally good candidates are picked repeatably but not
+// always in the same order.
+func tieBreak(day time.Time, owner *int, workoutType string) float64 {
+	key := day.Format("2006-01-02") + "/" + workoutType
+	if owner != nil {
+		key += "/" + strconv.Itoa(*owner)
+	}
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return float64(h.Sum32()%1000) / 2000
+}
+
+// sortedKeys returns the keys of a set in order.
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// weightsRotation returns the weights workout types in the order they were
+// first defined, e.g. push, pull, legs.
+func weightsRotation(weightWorkouts []WeightWorkout) []string {
+	sort.SliceStable(weightWorkouts, func(i, j int) bool { return weightWorkouts[i].ID < weightWorkouts[j].ID })
+	seen := map[string]bool{}
+	var types []string
+	for _, weightWorkout := range weightWorkouts {
+		workoutType := strings.ToLower(weightWorkout.WorkoutType)
+		if !seen[workoutType] {
+			seen[workoutType] = true
+			types = append(types, workoutType)
+		}
+	}
+	return types
+}
+
+// trainingHistory returns the sessions the selection of the workout of the day
+// for a day is based on: the owner's logged workouts, or every user's for
+// shared picks. Logs are sorted into the days of the requesting user's time
+// zone.
+func trainingHistory(ctx context.Context, owner *int, day time.Time, loc *time.Location, cardioTypes []CardioType) ([]trainingSession, error) {
+	start, _ := DayBounds(day.AddDate(0, 0, -historyDays), loc)
+	end, _ := DayBounds(day, loc)
+	workouts, err := store.FetchWorkoutsBetween(ctx, owner, start, end)
+	if err != nil {
+		return nil, err
+	}
+	var sessions []trainingSession
+	for _, workout := range workouts {
+		if cardioType := matchCardio(cardioTypes, workout.Type); cardioType != nil {
+			sessions = append(sessions, trainingSession{UserID: trainee(workout.UserID), Day: DayOf(workout.Date, loc), Kind: WODKindCardio, Modality: cardioType.Category, Minutes: workout.Duration / 60})
+		}
+	}
+	weightsLogs, err := store.FetchWeightsLogsBetween(ctx, owner, start, end)
+	if err != nil {
+		return nil, err
+	}
+	for _, weightsLog := range weightsLogs {
+		sessions = append(sessions, trainingSession{UserID: trainee(weightsLog.UserID), Day: DayOf(weightsLog.Date, loc), Kind: WODKindWeights, Modality: strings.ToLower(weightsLog.WorkoutType)})
+	}
+	return sessions, nil
+}
+
+// trainee returns the owner of a log, or 0 for logs without one.
+func trainee(userID *int) int {
+	if userID == nil {
+		return 0
+	}
+	return *userID
+}
@@ -0,0 +1,97 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSelectWOD(t *testing.T) {
+	day := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	cardioTypes := []CardioType{
+		{ID: 1, Name: "run", Aliases: StringList{"running", "jog"}, Category: "running"},
+		{ID: 2, Name: "bike", Aliases: StringList{"cycling"}, Category: "cycling"},
+	}
+	wods := []WOD{{ID: 1, Type: "Run", Duration: 30}, {ID: 2, Type: "Bike", Duration: 30}}
+	rotation := []string{"push", "pull", "legs"}
+	run := func(userID, daysAgo int, minutes float64) trainingSession {
+		return trainingSession{UserID: userID, Day: day.AddDate(0, 0, -daysAgo), Kind: WODKindCardio, Modality: "running", Minutes: minutes}
+	}
+	lift := func(userID, daysAgo int, workoutType string) trainingSession {
+		return trainingSession{UserID: userID, Day: day.AddDate(0, 0, -daysAgo), Kind: WODKindWeights, Modality: workoutType}
+	}
+
+	tests := []struct {
+		name     string
+		target   WeeklyTarget
+		wods     []WOD
+		rotation []string
+		history  []trainingSession
+		kind     string
+		typ      string
+	}{
+		{
+			name:    "avoids repeating yesterday's modality",
+			target:  WeeklyTarget{CardioMinutes: 150},
+			wods:    wods,
+			history: []trainingSession{run(1, 1, 30)},
+			kind:    WODKindCardio,
+			typ:     "Bike",
+		},
+		{
+			name:     "picks the next weights session once the cardio target is met",
+			target:   WeeklyTarget{CardioMinutes: 150, WeightsSessions: 3},
+			wods:     wods,
+			rotation: rotation,
+			history:  []trainingSession{run(1, 2, 50), run(1, 3, 50), run(1, 4, 50), lift(1, 5, "push")},
+			kind:     WODKindWeights,
+			typ:      "pull",
+		},
+		{
+			name:     "wraps around the weights rotation",
+			target:   WeeklyTarget{CardioMinutes: 150, WeightsSessions: 3},
+			wods:     wods,
+			rotation: rotation,
+			history:  []trainingSession{run(1, 2, 75), run(1, 3, 75), lift(1, 4, "legs"), lift(1, 10, "pull")},
+			kind:     WODKindWeights,
+			typ:      "push",
+		},
+		{
+			name:     "starts the rotation without weights history",
+			target:   WeeklyTarget{CardioMinutes: 150, WeightsSessions: 3},
+			rotation: rotation,
+			kind:     WODKindWeights,
+			typ:      "push",
+		},
+		{
+			name:     "averages the weekly volume over the trainees of a shared pick",
+			target:   WeeklyTarget{CardioMinutes: 150, WeightsSessions: 3},
+			wods:     wods,
+			rotation: rotation,
+			history: []trainingSession{
+				run(1, 2, 50), run(1, 3, 50), lift(1, 4, "push"), lift(1, 5, "pull"), lift(1, 6, "legs"),
+				run(2, 2, 50), run(2, 3, 50), lift(2, 4, "push"), lift(2, 5, "pull"), lift(2, 6, "legs"),
+			},
+			kind: WODKindCardio,
+			typ:  "Bike",
+		},
+	}
+	t.Cleanup(func() { weeklyTarget = DefaultWeeklyTarget })
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := SetWeeklyTarget(tt.target); err != nil {
+				t.Fatal(err)
+			}
+			got, ok := selectWOD(day, nil, tt.wods, tt.rotation, cardioTypes, tt.history)
+			if !ok {
+				t.Fatal("no workout of the day selected")
+			}
+			if got.Kind != tt.kind || got.Type != tt.typ {
+				t.Errorf("got %s %s, want %s %s (%v)", got.Kind, got.Type, tt.kind, tt.typ, got.Reasons)
+			}
+		})
+	}
+
+	if _, ok := selectWOD(day, nil, nil, nil, cardioTypes, nil); ok {
+		t.Error("selected a workout of the day without WODs or weight workouts")
+	}
+}
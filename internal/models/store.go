@@ -0,0 +1,163 @@
+package models
+
+import (
+	"context"
+	"time"
+)
+
+// Store is the persistence backend behind the model functions. Implementations
+// live in the store package; the active one is chosen at startup with SetStore.
+type Store interface {
+	// Ping checks that the backend can serve requests.
+	Ping(ctx context.Context) error
+
+	// SaveWorkout and SaveWeightsLog return the ID of the new log.
+	SaveWorkout(ctx context.Context, workout Workout) (int, error)
+	SaveWeightsLog(ctx context.Context, weightsLog WeightsLog) (int, error)
+	// FetchLoggedCardioWorkouts and FetchLoggedWeightsWorkouts return up to
+	// q.Limit+1 matching records, newest first, so callers can tell whether
+	// another page follows, along with the total number of matches ignoring
+	// the cursor and offset.
+	FetchLoggedCardioWorkouts(ctx context.Context, q LogQuery) ([]Workout, int, error)
+	FetchLoggedWeightsWorkouts(ctx context.Context, q LogQuery) ([]WeightsLog, int, error)
+	FetchWeightWorkouts(ctx context.Context, workoutType string) ([]WeightWorkout, error)
+	// FetchLastLoggedCardioWorkout and FetchLastLoggedWeightsWorkout return
+	// nil without an error when the user has not logged a cardio workout, or
+	// a weights workout of the given type.
+	FetchLastLoggedCardioWorkout(ctx context.Context, userID int) (*Workout, error)
+	FetchLastLoggedWeightsWorkout(ctx context.Context, userID int, workoutType string) (*WeightsLog, error)
+	// FetchWorkoutTypes returns the distinct types of all logged workouts and
+	// SetWorkoutCardioType sets the cardio type of every workout of a type.
+	FetchWorkoutTypes(ctx context.Context) ([]string, error)
+	SetWorkoutCardioType(ctx context.Context, workoutType string, cardioTypeID *int) error
+	// SetWorkoutDate redates a workout and the records it set.
+	SetWorkoutDate(ctx context.Context, id int, date time.Time) error
+	// FetchWorkoutsBetween and FetchWeightsLogsBetween return the user's
+	// logs from from up to but excluding to, oldest first, or every user's
+	// when userID is nil.
+	FetchWorkoutsBetween(ctx context.Context, userID *int, from, to time.Time) ([]Workout, error)
+	FetchWeightsLogsBetween(ctx context.Context, userID *int, from, to time.Time) ([]WeightsLog, error)
+	// FetchCardioTotals aggregates the user's cardio workouts in a time range
+	// by PeriodDay, PeriodWeek or PeriodMonth of a time zone and cardio type,
+	// ordered by period and cardio type. FetchCardioBestSplits returns, for
+	// each cardio type and distance, the fastest time at the average pace of
+	// a workout at least that long, ordered by cardio type and distance.
+	FetchCardioTotals(ctx context.Context, userID int, period string, loc *time.Location, from, to time.Time) ([]CardioTotal, error)
+	FetchCardioBestSplits(ctx context.Context, userID int, distances []float64, from, to time.Time) ([]CardioSplit, error)
+	// FetchDailyActivity counts the user's logs in a time range by day of a
+	// time zone, ordered by day. Days without logs are left out.
+	FetchDailyActivity(ctx context.Context, userID int, loc *time.Location, from, to time.Time) ([]DayActivity, error)
+
+	// WOD assignments are owned by a user, or shared when userID is nil.
+	// FetchWODAssignment returns nil without an error when the day has no
+	// assignment yet. CreateWODAssignment returns the stored assignment,
+	// which is an existing one if another request assigned the day first.
+	FetchWODAssignment(ctx context.Context, userID *int, day time.Time) (*WODAssignment, error)
+	CreateWODAssignment(ctx context.Context, assignment WODAssignment) (*WODAssignment, error)
+	FetchWODAssignments(ctx context.Context, userID *int, from, to time.Time) ([]WODAssignment, error)
+
+	// CreateEnrolment ends the user's active enrolment, if any, and stores
+	// the new one. FetchActiveEnrolment returns nil without an error when
+	// the user is not following a program.
+	CreateEnrolment(ctx context.Context, enrolment Enrolment) (*Enrolment, error)
+	FetchActiveEnrolment(ctx context.Context, userID int) (*Enrolment, error)
+	EndEnrolment(ctx context.Context, userID int, at time.Time) error
+
+	// FetchPersonalRecords returns every record the user set, in the order
+	// they were set. AddPersonalRecords passes that history to beat and
+	// saves the new records it returns, setting their IDs, and
+	// ReplacePersonalRecords replaces the whole history of a user. Both run
+	// one at a time per user, across every server sharing the database, so
+	// two logs cannot both beat the same record. Deleting a log also deletes
+	// the records it set, and emptying the exercises deletes every weights
+	// record.
+	FetchPersonalRecords(ctx context.Context, userID int) ([]PersonalRecord, error)
+	AddPersonalRecords(ctx context.Context, userID int, beat func(history []PersonalRecord) []PersonalRecord) ([]PersonalRecord, error)
+	ReplacePersonalRecords(ctx context.Context, userID int, records []PersonalRecord) error
+
+	CreateUser(ctx context.Context, user User) (*User, error)
+	// FetchUserByUsername and FetchUserByID return nil without an error
+	// when no such user exists.
+	FetchUserByUsername(ctx context.Context, username string) (*User, error)
+	FetchUserByID(ctx context.Context, id int) (*User, error)
+	SetUserRole(ctx context.Context, id int, role string) error
+	// SetUserPassword also ends every session of the user.
+	SetUserPassword(ctx context.Context, id int, passwordHash string) error
+	SetUserUnits(ctx context.Context, id int, units string) error
+	SetUserTimeZone(ctx context.Context, id int, timeZone string) error
+	CreateSession(ctx context.Context, session Session) error
+	// FetchSession returns nil without an error for unknown tokens.
+	FetchSession(ctx context.Context, tokenHash string) (*Session, error)
+	DeleteSession(ctx context.Context, tokenHash string) error
+	DeleteExpiredSessions(ctx context.Context, now time.Time) error
+
+	// The Update and Delete methods of the admin tables return ErrNotFound
+	// when no record has the given ID, and the Fetch methods return nil
+	// without an error.
+	AddWorkout(ctx context.Context, workout Workout) error
+	UpdateWorkout(ctx context.Context, workout Workout) error
+	DeleteWorkout(ctx context.Context, id int) error
+	ViewWorkouts(ctx context.Context) ([]Workout, error)
+	FetchWorkout(ctx context.Context, id int) (*Workout, error)
+	EmptyWorkouts(ctx context.Context) error
+
+	AddWeightsLog(ctx context.Context, weightsLog WeightsLog) error
+	UpdateWeightsLog(ctx context.Context, weightsLog WeightsLog) error
+	DeleteWeightsLog(ctx context.Context, id int) error
+	ViewWeightsLogs(ctx context.Context) ([]WeightsLog, error)
+	FetchWeightsLog(ctx context.Context, id int) (*WeightsLog, error)
+	EmptyWeightsLogs(ctx context.Context) error
+
+	AddExercise(ctx context.Context, exercise Exercise) error
+	UpdateExercise(ctx context.Context, exercise Exercise) error
+	DeleteExercise(ctx context.Context, id int) error
+	ViewExercises(ctx context.Context) ([]Exercise, error)
+	FetchExercise(ctx context.Context, id int) (*Exercise, error)
+	EmptyExercises(ctx context.Context) error
+
+	AddWOD(ctx context.Context, wod WOD) error
+	UpdateWOD(ctx context.Context, wod WOD) error
+	DeleteWOD(ctx context.Context, id int) error
+	ViewWODs(ctx context.Context) ([]WOD, error)
+	FetchWOD(ctx context.Context, id int) (*WOD, error)
+	EmptyWODs(ctx context.Context) error
+
+	AddCardioType(ctx context.Context, cardioType CardioType) error
+	UpdateCardioType(ctx context.Context, cardioType CardioType) error
+	// DeleteCardioType and EmptyCardioTypes clear the cardio type of the
+	// workouts that referenced the deleted entries.
+	DeleteCardioType(ctx context.Context, id int) error
+	ViewCardioTypes(ctx context.Context) ([]CardioType, error)
+	FetchCardioType(ctx context.Context, id int) (*CardioType, error)
+	EmptyCardioTypes(ctx context.Context) error
+
+	// ViewPrograms returns programs without their weeks; FetchProgram returns
+	// one program with its weeks, days and exercises, or nil without an error
+	// when there is none. UpdateProgram replaces the weeks of the program, and
+	// DeleteProgram and EmptyPrograms also delete the enrolments.
+	AddProgram(ctx context.Context, program Program) error
+	UpdateProgram(ctx context.Context, program Program) error
+	DeleteProgram(ctx context.Context, id int) error
+	ViewPrograms(ctx context.Context) ([]Program, error)
+	FetchProgram(ctx context.Context, id int) (*Program, error)
+	EmptyPrograms(ctx context.Context) error
+
+	AddWeightWorkout(ctx context.Context, weightWorkout WeightWorkout) error
+	UpdateWeightWorkout(ctx context.Context, weightWorkout WeightWorkout) error
+	DeleteWeightWorkout(ctx context.Context, id int) error
+	ViewWeightWorkouts(ctx context.Context) ([]WeightWorkout, error)
+	FetchWeightWorkout(ctx context.Context, id int) (*WeightWorkout, error)
+	EmptyWeightWorkouts(ctx context.Context) error
+}
+
+var store Store
+
+// SetStore sets the backend used by the model functions.
+func SetStore(s Store) {
+	store = s
+}
+
+// Ping checks that the store can serve requests.
+func Ping(ctx context.Context) error {
+	return store.Ping(ctx)
+}
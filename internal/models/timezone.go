@@ -0,0 +1,58 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// ErrInvalidTimeZone is returned for time zones that are not in the IANA
+// time zone database.
+var ErrInvalidTimeZone = errors.New("invalid time zone")
+
+// checkTimeZone rejects unknown time zones. An empty time zone stands for the
+// server's.
+func checkTimeZone(name string) error {
+	v := newValidation(ErrInvalidTimeZone)
+	if _, err := time.LoadLocation(name); err != nil || name == "Local" {
+		v.Add("time_zone", "must be an IANA time zone such as Europe/London")
+	}
+	return v.OrNil()
+}
+
+// SetUserTimeZone changes the time zone whose midnight starts the user's days.
+func SetUserTimeZone(ctx context.Context, id int, name string) error {
+	if err := checkTimeZone(name); err != nil {
+		return err
+	}
+	return store.SetUserTimeZone(ctx, id, name)
+}
+
+// Location returns the user's time zone, or the server's when they have not
+// chosen one or it is no longer known.
+func (u *User) Location() *time.Location {
+	if u.TimeZone == "" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(u.TimeZone)
+	if err != nil {
+		slog.Warn("Unknown user time zone", "user_id", u.ID, "time_zone", u.TimeZone, "err", err)
+		return time.Local
+	}
+	return loc
+}
+
+// DayOf returns the calendar day of a time in a time zone, as midnight UTC.
+func DayOf(t time.Time, loc *time.Location) time.Time {
+	y, m, d := t.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// DayBounds returns the start and end of a calendar day, as returned by DayOf,
+// in a time zone. Days are not always 24 hours long around daylight saving
+// time changes.
+func DayBounds(day time.Time, loc *time.Location) (time.Time, time.Time) {
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, loc)
+	return start, time.Date(day.Year(), day.Month(), day.Day()+1, 0, 0, 0, 0, loc)
+}
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func loadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc
+}
+
+func TestDayOf(t *testing.T) {
+	berlin, newYork := loadLocation(t, "Europe/Berlin"), loadLocation(t, "America/New_York")
+	tests := []struct {
+		t    time.Time
+		loc  *time.Location
+		want string
+	}{
+		{t: time.Date(2025, 3, 29, 23, 30, 0, 0, time.UTC), loc: time.UTC, want: "2025-03-29"},
+		{t: time.Date(2025, 3, 29, 23, 30, 0, 0, time.UTC), loc: berlin, want: "2025-03-30"},
+		{t: time.Date(2025, 3, 30, 3, 0, 0, 0, time.UTC), loc: newYork, want: "2025-03-29"},
+		{t: time.Date(2025, 12, 31, 23, 0, 0, 0, time.UTC), loc: berlin, want: "2026-01-01"},
+		{t: time.Date(2025, 3, 30, 1, 0, 0, 0, berlin), loc: time.UTC, want: "2025-03-30"},
+	}
+	for _, tt := range tests {
+		got := DayOf(tt.t, tt.loc)
+		if got.Format(time.DateOnly) != tt.want || got.Location() != time.UTC || got.Hour() != 0 {
+			t.Errorf("DayOf(%s, %s) = %s, want %s at midnight UTC", tt.t, tt.loc, got, tt.want)
+		}
+	}
+}
+
+func TestDayBounds(t *testing.T) {
+	berlin := loadLocation(t, "Europe/Berlin")
+	tests := []struct {
+		day    time.Time
+		loc    *time.Location
+		start  time.Time
+		length time.Duration
+	}{
+		{day: time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC), loc: time.UTC, start: time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC), length: 24 * time.Hour},
+		{day: time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC), loc: berlin, start: time.Date(2025, 3, 9, 23, 0, 0, 0, time.UTC), length: 24 * time.Hour},
+		// Clocks go forward an hour in Berlin on the last Sunday of March
+		{day: time.Date(2025, 3, 30, 0, 0, 0, 0, time.UTC), loc: berlin, start: time.Date(2025, 3, 29, 23, 0, 0, 0, time.UTC), length: 23 * time.Hour},
+		// And back an hour on the last Sunday of October
+		{day: time.Date(2025, 10, 26, 0, 0, 0, 0, time.UTC), loc: berlin, start: time.Date(2025, 10, 25, 22, 0, 0, 0, time.UTC), length: 25 * time.Hour},
+	}
+	for _, tt := range tests {
+		start, end := DayBounds(tt.day, tt.loc)
+		if !start.Equal(tt.start) || end.Sub(start) != tt.length {
+			t.Errorf("DayBounds(%s, %s) = %s, %s; want %s lasting %s", tt.day.Format(time.DateOnly), tt.loc, start, end, tt.start, tt.length)
+		}
+		if day := DayOf(start, tt.loc); !day.Equal(tt.day) {
+			t.Errorf("day %s starts on %s", tt.day.Format(time.DateOnly), day.Format(time.DateOnly))
+		}
+	}
+}
+
+func TestUserLocation(t *testing.T) {
+	if err := checkTimeZone("Europe/Berlin"); err != nil {
+		t.Errorf("Europe/Berlin rejected: %v", err)
+	}
+	for _, name := range []string{"Local", "Mars/Olympus_Mons"} {
+		if err := checkTimeZone(name); err == nil {
+			t.Errorf("%s accepted", name)
+		}
+	}
+	tests := []struct {
+		timeZone string
+		want     string
+	}{
+		{timeZone: "Europe/Berlin", want: "Europe/Berlin"},
+		{timeZone: "", want: time.Local.String()},
+		{timeZone: "Mars/Olympus_Mons", want: time.Local.String()},
+	}
+	for _, tt := range tests {
+		user := User{TimeZone: tt.timeZone}
+		if got := user.Location().String(); got != tt.want {
+			t.Errorf("time zone %q: got location %s, want %s", tt.timeZone, got, tt.want)
+		}
+	}
+}
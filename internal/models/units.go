@@ -0,0 +1,172 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"math"
+)
+
+// ErrInvalidUnits is returned for unknown unit systems.
+var ErrInvalidUnits = errors.New("invalid units")
+
+// Unit systems a user can prefer. Distances are stored in kilometers and
+// weights in kilograms whatever was logged, and every read converts them to
+// the user's unit system.
+const (
+	UnitsMetric   = "metric"
+	UnitsImperial = "imperial"
+)
+
+// Distance units accepted when logging a cardio workout.
+const (
+	UnitKm = "km"
+	UnitMi = "mi"
+)
+
+// KmPerMile converts miles to kilometers.
+const KmPerMile = 1.609344
+
+// kgPerLb converts pounds to kilograms.
+const kgPerLb = 0.45359237
+
+// checkUnits rejects unknown unit systems.
+func checkUnits(units string) error {
+	v := newValidation(ErrInvalidUnits)
+	if units != UnitsMetric && units != UnitsImperial {
+		v.Add("units", "must be %s or %s", UnitsMetric, UnitsImperial)
+	}
+	return v.OrNil()
+}
+
+// WeightUnit returns the weight unit of a unit system.
+func WeightUnit(units string) string {
+	if units == UnitsImperial {
+		return UnitLb
+	}
+	return UnitKg
+}
+
+// DistanceUnit returns the distance unit of a unit system.
+func DistanceUnit(units string) string {
+	if units == UnitsImperial {
+		return UnitMi
+	}
+	return UnitKm
+}
+
+// SetUserUnits changes the unit system a user reads and logs values in.
+func SetUserUnits(ctx context.Context, id int, units string) error {
+	if err := checkUnits(units); err != nil {
+		return err
+	}
+	return store.SetUserUnits(ctx, id, units)
+}
+
+// toKm converts a distance in a distance unit to kilometers.
+func toKm(distance float64, unit string) float64 {
+	if unit == UnitMi {
+		return distance * KmPerMile
+	}
+	return distance
+}
+
+// FromKm converts a distance in kilometers to a distance unit, rounded to 0.01.
+func FromKm(distance float64, unit string) float64 {
+	if unit == UnitMi {
+		distance /= KmPerMile
+	}
+	return math.Round(distance*100) / 100
+}
+
+// toKg converts a weight in a weight unit to kilograms without rounding, so
+// a weight logged in pounds reads back unchanged.
+func toKg(weight float64, unit string) float64 {
+	if unit == UnitLb {
+		return weight * kgPerLb
+	}
+	return weight
+}
+
+// FromKg converts a weight in kilograms to a weight unit, rounded to 0.1.
+func FromKg(weight float64, unit string) float64 {
+	if unit == UnitLb {
+		weight /= kgPerLb
+	}
+	return math.Round(weight*10) / 10
+}
+
+// InUnits converts the distance of a workout from kilometers to a unit system.
+func (w *Workout) InUnits(units string) {
+	w.DistanceUnit = DistanceUnit(units)
+	w.Distance = FromKm(w.Distance, w.DistanceUnit)
+}
+
+// InUnits converts the weights of a weights log from kilograms to a unit system.
+func (l *WeightsLog) InUnits(units string) {
+	unit := WeightUnit(units)
+	for i := range l.Exercises {
+		for j := range l.Exercises[i].Sets {
+			set := &l.Exercises[i].Sets[j]
+			set.Weight = FromKg(toKg(set.Weight, set.Unit), unit)
+			set.Unit = unit
+		}
+	}
+}
+
+// InUnits converts the distance of an assignment from kilometers to a unit system.
+func (a *WODAssignment) InUnits(units string) {
+	a.DistanceUnit = DistanceUnit(units)
+	a.Distance = FromKm(a.Distance, a.DistanceUnit)
+}
+
+// InUnits converts the weights and distances of a record to a unit system.
+// Times and rep counts are left as they are.
+func (r *PersonalRecord) InUnits(units string) {
+	weightUnit := WeightUnit(units)
+	switch r.Kind {
+	case RecordHeaviestSet, RecordBestE1RM:
+		r.Value = FromKg(r.Value, weightUnit)
+		r.Unit = weightUnit
+		if r.Previous != nil {
+			previous := FromKg(*r.Previous, weightUnit)
+			r.Previous = &previous
+		}
+	case RecordMostReps:
+		r.Unit = "reps"
+	case RecordFastest5K:
+		r.Unit = "s"
+	case RecordLongestRide:
+		r.Unit = DistanceUnit(units)
+		r.Value = FromKm(r.Value, r.Unit)
+		if r.Previous != nil {
+			previous := FromKm(*r.Previous, r.Unit)
+			r.Previous = &previous
+		}
+	}
+	if r.Weight > 0 {
+		r.Weight = FromKg(r.Weight, weightUnit)
+	}
+}
+
+// InUnits converts the records of a log result to a unit system.
+func (l *LogResult) InUnits(units string) {
+	for i := range l.Records {
+		l.Records[i].InUnits(units)
+	}
+}
+
+// InUnits converts the target weights of a program session to a unit system,
+// rounding pounds to 5 lb.
+func (s *ProgramSession) InUnits(units string) {
+	if units != UnitsImperial {
+		return
+	}
+	for i := range s.Exercises {
+		exercise := &s.Exercises[i]
+		if exercise.TargetWeight != nil {
+			target := roundTo(*exercise.TargetWeight/kgPerLb, 5)
+			exercise.TargetWeight = &target
+			exercise.Unit = UnitLb
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package models
+
+import "testing"
+
+func TestConversions(t *testing.T) {
+	tests := []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{name: "miles to kilometers", got: toKm(5, UnitMi), want: 8.04672},
+		{name: "kilometers unchanged", got: toKm(5, UnitKm), want: 5},
+		{name: "kilometers to miles", got: FromKm(8.04672, UnitMi), want: 5},
+		{name: "kilometers rounded to 0.01", got: FromKm(1.235001, UnitKm), want: 1.24},
+		{name: "miles rounded to 0.01", got: FromKm(10, UnitMi), want: 6.21},
+		{name: "pounds to kilograms", got: toKg(100, UnitLb), want: 45.359237},
+		{name: "kilograms unchanged", got: toKg(62.5, UnitKg), want: 62.5},
+		{name: "kilograms to pounds rounded to 0.1", got: FromKg(100, UnitLb), want: 220.5},
+		{name: "kilograms rounded to 0.1", got: FromKg(61.26, UnitKg), want: 61.3},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestPoundsReadBackUnchanged(t *testing.T) {
+	for lb := 0.5; lb <= 1000; lb += 0.5 {
+		if got := FromKg(toKg(lb, UnitLb), UnitLb); got != lb {
+			t.Errorf("%v lb read back as %v lb", lb, got)
+		}
+	}
+}
+
+func TestInUnits(t *testing.T) {
+	weightsLog := WeightsLog{Exercises: []Exercise{{Sets: []ExerciseSet{
+		{Reps: 5, Weight: 225, Unit: UnitLb},
+		{Reps: 5, Weight: 100, Unit: UnitKg},
+	}}}}
+	imperial := weightsLog
+	imperial.Exercises = []Exercise{{Sets: append([]ExerciseSet(nil), weightsLog.Exercises[0].Sets...)}}
+	imperial.InUnits(UnitsImperial)
+	weightsLog.InUnits(UnitsMetric)
+	for i, want := range []ExerciseSet{{Reps: 5, Weight: 102.1, Unit: UnitKg}, {Reps: 5, Weight: 100, Unit: UnitKg}} {
+		if got := weightsLog.Exercises[0].Sets[i]; got != want {
+			t.Errorf("metric set %d: got %+v, want %+v", i, got, want)
+		}
+	}
+	for i, want := range []ExerciseSet{{Reps: 5, Weight: 225, Unit: UnitLb}, {Reps: 5, Weight: 220.5, Unit: UnitLb}} {
+		if got := imperial.Exercises[0].Sets[i]; got != want {
+			t.Errorf("imperial set %d: got %+v, want %+v", i, got, want)
+		}
+	}
+
+	workout := Workout{Distance: 8.04672}
+	workout.InUnits(UnitsImperial)
+	if workout.Distance != 5 || workout.DistanceUnit != UnitMi {
+		t.Errorf("got %v %s, want 5 mi", workout.Distance, workout.DistanceUnit)
+	}
+
+	target := 100.0
+	session := ProgramSession{Exercises: []PrescribedExercise{{TargetWeight: &target, Unit: UnitKg}}}
+	session.InUnits(UnitsImperial)
+	if got := session.Exercises[0]; *got.TargetWeight != 220 || got.Unit != UnitLb {
+		t.Errorf("target of 100 kg read as %v %s, want 220 lb", *got.TargetWeight, got.Unit)
+	}
+}
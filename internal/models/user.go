@@ -0,0 +1,129 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ErrUserExists is returned when registering a username that is already taken.
+var ErrUserExists = errors.New("username is already taken")
+
+// ErrInvalidUser is returned when registering with a missing or too long
+// username, or a password that is too short or too long.
+var ErrInvalidUser = errors.New("invalid user")
+
+// MinPasswordLength is the shortest password accepted at registration.
+const MinPasswordLength = 8
+
+// Longest username and password accepted at registration. Usernames are
+// stored as VARCHAR(50), and bcrypt only hashes the first 72 bytes of a
+// password.
+const (
+	MaxUsernameLength = 50
+	MaxPasswordBytes  = 72
+)
+
+// User roles. Admins can use the /admin endpoints.
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// User represents a Momentum account.
+type User struct {
+	ID           int       `json:"id"`
+	Username     string    `json:"username"`
+	PasswordHash string    `json:"-" db:"password_hash"`
+	Role         string    `json:"role"`
+	Units        string    `json:"units"`                    // Preferred unit system, metric or imperial
+	TimeZone     string    `json:"time_zone" db:"time_zone"` // IANA time zone of the user's days, or "" for the server's
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+// Session represents a logged-in session. Only the SHA-256 hash of the
+// session token is stored, so a leaked database cannot be used to log in.
+type Session struct {
+	TokenHash string    `json:"-" db:"token_hash"`
+	UserID    int       `json:"user_id" db:"user_id"`
+	ExpiresAt time.Time `json:"expires_at" db:"expires_at"`
+}
+
+// ValidateCredentials checks the username and password of a new account.
+func ValidateCredentials(username, password string) error {
+	v := newValidation(ErrInvalidUser)
+	if strings.TrimSpace(username) == "" {
+		v.Add("username", "is required")
+	} else if utf8.RuneCountInString(username) > MaxUsernameLength {
+		v.Add("username", "must be at most %d characters", MaxUsernameLength)
+	}
+	if len(password) < MinPasswordLength {
+		v.Add("password", "must be at least %d characters", MinPasswordLength)
+	} else if len(password) > MaxPasswordBytes {
+		v.Add("password", "must be at most %d bytes", MaxPasswordBytes)
+	}
+	return v.OrNil()
+}
+
+// CreateUser creates a new user with an already hashed password.
+func CreateUser(ctx context.Context, username, passwordHash, role string) (*User, error) {
+	existing, err := store.FetchUserByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrUserExists
+	}
+	return store.CreateUser(ctx, User{Username: username, PasswordHash: passwordHash, Role: role, Units: UnitsMetric, CreatedAt: time.Now()})
+}
+
+// FetchUserByUsername retrieves a user by username, or nil if there is none.
+func FetchUserByUsername(ctx context.Context, username string) (*User, error) {
+	return store.FetchUserByUsername(ctx, username)
+}
+
+// FetchUserByID retrieves a user by ID, or nil if there is none.
+func FetchUserByID(ctx context.Context, id int) (*User, error) {
+	return store.FetchUserByID(ctx, id)
+}
+
+// SetUserRole changes the role of an existing user.
+func SetUserRole(ctx context.Context, id int, role string) error {
+	return store.SetUserRole(ctx, id, role)
+}
+
+// SetUserPassword changes the password hash of an existing user and logs them
+// out everywhere.
+func SetUserPassword(ctx context.Context, id int, passwordHash string) error {
+	return store.SetUserPassword(ctx, id, passwordHash)
+}
+
+// CreateSession stores a new session for a user.
+func CreateSession(ctx context.Context, session Session) error {
+	return store.CreateSession(ctx, session)
+}
+
+// DeleteExpiredSessions removes the sessions that have expired.
+func DeleteExpiredSessions(ctx context.Context) error {
+	return store.DeleteExpiredSessions(ctx, time.Now())
+}
+
+// FetchSession retrieves an unexpired session by token hash, or nil if there
+// is none.
+func FetchSession(ctx context.Context, tokenHash string) (*Session, error) {
+	session, err := store.FetchSession(ctx, tokenHash)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil || session.ExpiresAt.Before(time.Now()) {
+		return nil, nil
+	}
+	return session, nil
+}
+
+// DeleteSession removes a session, logging the user out.
+func DeleteSession(ctx context.Context, tokenHash string) error {
+	return store.DeleteSession(ctx, tokenHash)
+}
@@ -0,0 +1,69 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNotFound is returned when a record to update or delete does not exist.
+var ErrNotFound = errors.New("record not found")
+
+// ErrConflict is returned when a record would duplicate the name or other
+// unique field of an existing one.
+var ErrConflict = errors.New("record already exists")
+
+// FieldError describes what is wrong with one field of a request.
+type FieldError struct {
+	Field   string `json:"field"` // Path of the field, e.g. exercises[0].sets[1].reps
+	Message string `json:"message"`
+}
+
+// ValidationError lists every invalid field of a request rather than only the
+// first. It wraps the error of the kind of input, such as ErrInvalidWorkout,
+// so callers can still match it with errors.Is.
+type ValidationError struct {
+	Err    error
+	Fields []FieldError
+}
+
+// Error implements error.
+func (e *ValidationError) Error() string {
+	details := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		details[i] = f.Field + " " + f.Message
+	}
+	return fmt.Sprintf("%v: %s", e.Err, strings.Join(details, "; "))
+}
+
+// Unwrap returns the error of the kind of input.
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// Add records a problem with a field.
+func (e *ValidationError) Add(field, format string, args ...interface{}) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// OrNil returns the error, or nil when no problem was recorded.
+func (e *ValidationError) OrNil() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+// newValidation starts collecting the invalid fields of an input of a kind.
+func newValidation(kind error) *ValidationError {
+	return &ValidationError{Err: kind}
+}
+
+// fieldPath joins the path of a field to its parent's, e.g. exercises[0] and
+// name to exercises[0].name.
+func fieldPath(parent, field string) string {
+	if parent == "" {
+		return field
+	}
+	return parent + "." + field
+}
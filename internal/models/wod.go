@@ -0,0 +1,202 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// WOD assignment scopes. In the global scope every user gets the same workout
+// of the day; in the user scope each user gets their own pick.
+const (
+	WODScopeGlobal = "global"
+	WODScopeUser   = "user"
+)
+
+// Kinds of workout of the day. Cardio picks come from the WOD list; weights
+// picks are a session of the push/pull/legs rotation from weight_workouts.
+const (
+	WODKindCardio  = "cardio"
+	WODKindWeights = "weights"
+)
+
+// ErrNoWODs is returned when there are no WODs to pick the workout of the day from.
+var ErrNoWODs = errors.New("no workouts of the day are defined")
+
+var wodScope = WODScopeGlobal
+
+// WODAssignment is the workout of the day picked for a calendar day. The WOD
+// is copied so the history is unaffected by later edits to the WOD list.
+type WODAssignment struct {
+	ID           int       `json:"id"`
+	UserID       *int      `json:"user_id,omitempty" db:"user_id"` // nil for assignments shared by all users
+	Day          time.Time `json:"day"`                            // Calendar day, as midnight UTC
+	Kind         string    `json:"kind"`                           // cardio or weights
+	WODID        *int      `json:"wod_id" db:"wod_id"`             // Picked WOD (nil for weights picks or once it has been deleted)
+	Type         string    `json:"type"`                           // WOD type, or weights workout type (e.g., push)
+	Duration     int       `json:"duration"`                       // Duration in minutes
+	Distance     float64   `json:"distance"`                       // Distance in kilometers (if applicable)
+	DistanceUnit string    `json:"distance_unit,omitempty" db:"-"` // Unit of Distance in responses
+	Reasons      JSONList  `json:"reasons"`                        // Why the selection picked this workout
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	Exercises    []string  `json:"exercises,omitempty" db:"-"` // Exercises of a weights pick
+	Completed    bool      `json:"completed" db:"-"`           // Whether the user logged a matching workout that day
+}
+
+// SetWODScope sets whether the workout of the day is shared by all users or
+// picked for each user.
+func SetWODScope(scope string) error {
+	switch scope {
+	case WODScopeGlobal, WODScopeUser:
+		wodScope = scope
+		return nil
+	}
+	return fmt.Errorf("unknown WOD scope %q", scope)
+}
+
+// wodOwner returns the owner of the user's assignments in the current scope.
+func wodOwner(userID int) *int {
+	if wodScope == WODScopeUser {
+		return &userID
+	}
+	return nil
+}
+
+// FetchWorkoutOfTheDay returns the workout of the day for the user's today in
+// their time zone, picking and storing it on the first request of the day.
+func FetchWorkoutOfTheDay(ctx context.Context, userID int, loc *time.Location) (*WODAssignment, error) {
+	day := DayOf(time.Now(), loc)
+	owner := wodOwner(userID)
+	assignment, err := store.FetchWODAssignment(ctx, owner, day)
+	if err != nil {
+		return nil, err
+	}
+	if assignment == nil {
+		if assignment, err = assignWOD(ctx, owner, day, loc); err != nil {
+			return nil, err
+		}
+	}
+	if assignment.Kind == WODKindWeights {
+		weightWorkouts, err := store.FetchWeightWorkouts(ctx, assignment.Type)
+		if err != nil {
+			return nil, err
+		}
+		for _, weightWorkout := range weightWorkouts {
+			assignment.Exercises = append(assignment.Exercises, weightWorkout.Exercise)
+		}
+	}
+	assignments := []WODAssignment{*assignment}
+	if err := markCompleted(ctx, userID, loc, assignments); err != nil {
+		return nil, err
+	}
+	return &assignments[0], nil
+}
+
+// assignWOD selects and stores the workout of the day for a day.
+func assignWOD(ctx context.Context, owner *int, day time.Time, loc *time.Location) (*WODAssignment, error) {
+	wods, err := store.ViewWODs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	weightWorkouts, err := store.ViewWeightWorkouts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cardioTypes, err := store.ViewCardioTypes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	history, err := trainingHistory(ctx, owner, day, loc, cardioTypes)
+	if err != nil {
+		return nil, err
+	}
+	selected, ok := selectWOD(day, owner, wods, weightsRotation(weightWorkouts), cardioTypes, history)
+	if !ok {
+		return nil, ErrNoWODs
+	}
+	selected.UserID = owner
+	selected.Day = day
+	selected.CreatedAt = time.Now()
+	assignment, err := store.CreateWODAssignment(ctx, selected)
+	if err != nil {
+		return nil, err
+	}
+	slog.InfoContext(ctx, "Assigned workout of the day", "kind", assignment.Kind, "type", assignment.Type, "day", day.Format(time.DateOnly), "reasons", strings.Join(assignment.Reasons, "; "))
+	return assignment, nil
+}
+
+// FetchWODHistory returns the user's workouts of the day for the days from
+// from up to but excluding to, newest first. Days are those of the user's
+// time zone.
+func FetchWODHistory(ctx context.Context, userID int, loc *time.Location, from, to time.Time) ([]WODAssignment, error) {
+	assignments, err := store.FetchWODAssignments(ctx, wodOwner(userID), DayOf(from, loc), DayOf(to, loc))
+	if err != nil {
+		return nil, err
+	}
+	if err := markCompleted(ctx, userID, loc, assignments); err != nil {
+		return nil, err
+	}
+	if assignments == nil {
+		assignments = []WODAssignment{}
+	}
+	return assignments, nil
+}
+
+// markCompleted sets Completed on each assignment the user finished, that is
+// when they logged a workout of the same type, or the same cardio type, on
+// the assigned day in their time zone. Weights picks need a weights log of the
+// same type.
+func markCompleted(ctx context.Context, userID int, loc *time.Location, assignments []WODAssignment) error {
+	if len(assignments) == 0 {
+		return nil
+	}
+	from, to := DayBounds(assignments[0].Day, loc)
+	for _, assignment := range assignments[1:] {
+		start, end := DayBounds(assignment.Day, loc)
+		if start.Before(from) {
+			from = start
+		}
+		if end.After(to) {
+			to = end
+		}
+	}
+	workouts, err := store.FetchWorkoutsBetween(ctx, &userID, from, to)
+	if err != nil {
+		return err
+	}
+	weightsLogs, err := store.FetchWeightsLogsBetween(ctx, &userID, from, to)
+	if err != nil {
+		return err
+	}
+	cardioTypes, err := store.ViewCardioTypes(ctx)
+	if err != nil {
+		return err
+	}
+	for i := range assignments {
+		assignment := &assignments[i]
+		if assignment.Kind == WODKindWeights {
+			for _, weightsLog := range weightsLogs {
+				if DayOf(weightsLog.Date, loc).Equal(assignment.Day) && strings.EqualFold(weightsLog.WorkoutType, assignment.Type) {
+					assignment.Completed = true
+					break
+				}
+			}
+			continue
+		}
+		cardioTypeID := classifyCardio(cardioTypes, assignment.Type)
+		for _, workout := range workouts {
+			if !DayOf(workout.Date, loc).Equal(assignment.Day) {
+				continue
+			}
+			sameCardio := cardioTypeID != nil && workout.CardioTypeID != nil && *cardioTypeID == *workout.CardioTypeID
+			if sameCardio || strings.EqualFold(workout.Type, assignment.Type) {
+				assignment.Completed = true
+				break
+			}
+		}
+	}
+	return nil
+}
@@ -1,37 +1,83 @@
 package models
 
 import (
-	"database/sql"
-	"log"
-	"momentum/internal/database"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"momentum/internal/metrics"
+	"sort"
+	"strings"
 	"time"
 )
 
 // Workout represents a workout entry in the database.
 type Workout struct {
-	ID       int       `json:"id"`
-	Type     string    `json:"type"`     // Type of workout (e.g., cardio, strength)
-	Duration float64   `json:"duration"` // Duration in seconds
-	Distance float64   `json:"distance"` // Distance in kilometers (if applicable)
-	Date     time.Time `json:"date"`     // Date of the workout
+	ID           int        `json:"id"`
+	UserID       *int       `json:"user_id,omitempty" db:"user_id"`     // Owner of the workout (nil for records logged before accounts)
+	Type         string     `json:"type"`                               // Type of workout (e.g., cardio, strength)
+	CardioTypeID *int       `json:"cardio_type_id" db:"cardio_type_id"` // Matching entry of the cardio type catalogue (nil if not cardio)
+	Duration     float64    `json:"duration"`                           // Duration in seconds
+	Distance     float64    `json:"distance"`                           // Distance in kilometers (if applicable)
+	DistanceUnit string     `json:"distance_unit,omitempty" db:"-"`     // Unit of Distance in requests and responses (km or mi)
+	Date         time.Time  `json:"date"`                               // Date of the workout
+	PerformedAt  *time.Time `json:"performed_at,omitempty" db:"-"`      // When a logged workout was done, if not now (log requests only)
 }
 
 // WeightsLog represents a log entry for a weights workout.
 type WeightsLog struct {
 	ID          int        `json:"id"`
+	UserID      *int       `json:"user_id,omitempty" db:"user_id"`
 	WorkoutType string     `json:"workout_type" db:"workout_type"`
 	Exercises   []Exercise `json:"exercises"`
 	Date        time.Time  `json:"date"`
+	PerformedAt *time.Time `json:"performed_at,omitempty" db:"-"` // When a logged workout was done, if not now (log requests only)
 }
 
 // Exercise represents an exercise entry in a weights log.
 type Exercise struct {
-	ID           int    `json:"id"`
-	WeightsLogID int    `json:"weights_log_id" db:"weights_log_id"`
-	Name         string `json:"name"`
-	Set1         int    `json:"set1"`
-	Set2         int    `json:"set2"`
-	Set3         int    `json:"set3"`
+	ID           int           `json:"id"`
+	WeightsLogID int           `json:"weights_log_id" db:"weights_log_id"`
+	Name         string        `json:"name"`
+	Sets         []ExerciseSet `json:"sets"`
+}
+
+// Set types and weight units accepted for an exercise set.
+const (
+	SetTypeWarmup  = "warmup"
+	SetTypeWorking = "working"
+	SetTypeDrop    = "drop"
+
+	UnitKg = "kg"
+	UnitLb = "lb"
+)
+
+// ErrInvalidSet is returned when an exercise or one of its sets is invalid.
+var ErrInvalidSet = errors.New("invalid exercise")
+
+// maxClockSkew is how far in the future a log may be dated, to allow for
+// client clocks running a little fast.
+const maxClockSkew = 5 * time.Minute
+
+// ErrInvalidWorkout is returned when a workout, weights log, WOD or weight
+// workout has missing or out of range fields.
+var ErrInvalidWorkout = errors.New("invalid workout")
+
+// ExerciseSet represents a single set performed for an exercise.
+type ExerciseSet struct {
+	ID         int      `json:"id"`
+	ExerciseID int      `json:"exercise_id" db:"exercise_id"`
+	SetNumber  int      `json:"set_number" db:"set_number"` // 1-based position within the exercise
+	Reps       int      `json:"reps"`
+	Weight     float64  `json:"weight"`
+	Unit       string   `json:"unit"`          // kg or lb
+	RPE        *float64 `json:"rpe,omitempty"` // Rate of perceived exertion (if recorded)
+	SetType    string   `json:"set_type" db:"set_type"`
+}
+
+// WeightKg returns the weight of the set in kilograms.
+func (s ExerciseSet) WeightKg() float64 {
+	return toKg(s.Weight, s.Unit)
 }
 
 // WOD represents a workout of the day entry in the database.
@@ -48,325 +94,665 @@ type WeightWorkout struct {
 	ID          int    `json:"id"`
 	WorkoutType string `json:"workout_type" db:"workout_type"`
 	Exercise    string `json:"exercise" db:"exercise"`
+	MuscleGroup string `json:"muscle_group" db:"muscle_group"` // e.g. chest, back, quads
 }
 
-// FetchWorkoutOfTheDay retrieves a random workout of the day from the database.
-func FetchWorkoutOfTheDay() (*WOD, error) {
-	var wod WOD
-	err := database.DB.Get(&wod, "SELECT * FROM wods ORDER BY RANDOM() LIMIT 1")
+// SaveWorkout saves a new workout for the given user to the database and
+// reports the personal records it set. The workout is dated now unless it
+// gives a performed_at. The distance is in the workout's distance unit, or
+// that of the user's unit system when omitted, and is stored in kilometers.
+func SaveWorkout(ctx context.Context, userID int, units string, workout Workout) (*LogResult, error) {
+	if err := workout.validate().OrNil(); err != nil {
+		return nil, err
+	}
+	workout.UserID = &userID
+	workout.Type = strings.TrimSpace(workout.Type)
+	workout.Date, workout.PerformedAt = performedAt(workout.PerformedAt, workout.Date), nil
+	if workout.DistanceUnit == "" {
+		workout.DistanceUnit = DistanceUnit(units)
+	}
+	workout.Distance = toKm(workout.Distance, workout.DistanceUnit)
+	workout.DistanceUnit = UnitKm
+	cardioTypes, err := ViewCardioTypes(ctx)
 	if err != nil {
-		log.Printf("Error fetching workout of the day: %v", err)
 		return nil, err
 	}
-	return &wod, nil
+	workout.CardioTypeID = classifyCardio(cardioTypes, workout.Type)
+	id, err := store.SaveWorkout(ctx, workout)
+	if err != nil {
+		return nil, err
+	}
+	cardioType := matchCardio(cardioTypes, workout.Type)
+	if cardioType != nil {
+		metrics.WorkoutLogged(cardioType.Name)
+	} else {
+		metrics.WorkoutLogged("other")
+	}
+	candidates := cardioRecords(workout, cardioType)
+	for i := range candidates {
+		candidates[i].WorkoutID = &id
+	}
+	return logResult(ctx, id, userID, candidates), nil
 }
 
-// SaveWorkout saves a new workout to the database.
-func SaveWorkout(workout Workout) error {
-	_, err := database.DB.NamedExec(`INSERT INTO workouts (type, duration, distance, date) VALUES (:type, :duration, :distance, :date)`, &workout)
-	return err
+// SaveWeightsLog saves a new weights log for the given user to the database
+// and reports the personal records it set. The log is dated now unless it
+// gives a performed_at. Sets without a unit are in the weight unit of the
+// user's unit system.
+func SaveWeightsLog(ctx context.Context, userID int, units string, weightsLog WeightsLog) (*LogResult, error) {
+	v := weightsLog.validate()
+	if len(weightsLog.Exercises) == 0 {
+		v.Add("exercises", "must have at least one exercise")
+	}
+	if err := v.OrNil(); err != nil {
+		return nil, err
+	}
+	weightsLog.UserID = &userID
+	weightsLog.WorkoutType = strings.TrimSpace(weightsLog.WorkoutType)
+	weightsLog.Date, weightsLog.PerformedAt = performedAt(weightsLog.PerformedAt, weightsLog.Date), nil
+	for i := range weightsLog.Exercises {
+		weightsLog.Exercises[i].Name = strings.TrimSpace(weightsLog.Exercises[i].Name)
+		prepareSets(weightsLog.Exercises[i].Sets, WeightUnit(units))
+	}
+	id, err := store.SaveWeightsLog(ctx, weightsLog)
+	if err != nil {
+		return nil, err
+	}
+	metrics.WorkoutLogged(metrics.WeightsType)
+	candidates := weightsRecords(weightsLog)
+	for i := range candidates {
+		candidates[i].WeightsLogID = &id
+	}
+	return logResult(ctx, id, userID, candidates), nil
 }
 
-// SaveWeightsLog saves a new weights log to the database.
-func SaveWeightsLog(weightsLog WeightsLog) error {
-	weightsLog.Date = time.Now() // Set the current time and date
-	tx := database.DB.MustBegin()
-	var weightsLogID int
-	err := tx.QueryRowx(`INSERT INTO weights_logs (workout_type, date) VALUES ($1, $2) RETURNING id`, weightsLog.WorkoutType, weightsLog.Date).Scan(&weightsLogID)
-	if err != nil {
-		tx.Rollback()
-		return err
+// validate checks the fields of a logged workout.
+func (w Workout) validate() *ValidationError {
+	v := newValidation(ErrInvalidWorkout)
+	if strings.TrimSpace(w.Type) == "" {
+		v.Add("type", "is required")
+	}
+	if w.Duration <= 0 {
+		v.Add("duration", "must be greater than 0")
+	}
+	if w.Distance < 0 {
+		v.Add("distance", "must not be negative")
+	}
+	if w.DistanceUnit != "" && w.DistanceUnit != UnitKm && w.DistanceUnit != UnitMi {
+		v.Add("distance_unit", "must be %s or %s", UnitKm, UnitMi)
+	}
+	checkDates(v, w.Date, w.PerformedAt)
+	return v
+}
+
+// validateStored checks a workout written through the admin API, which stores
+// the distance as given, in kilometers.
+func (w Workout) validateStored() *ValidationError {
+	v := w.validate()
+	if w.DistanceUnit == UnitMi {
+		v.Add("distance_unit", "must be %s, as admin records are stored in kilometers", UnitKm)
+	}
+	return v
+}
+
+// checkDates rejects a log date or performed_at in the future, or too old to
+// be a real workout. A zero date stands for none.
+func checkDates(v *ValidationError, date time.Time, performed *time.Time) {
+	latest := time.Now().Add(maxClockSkew)
+	if date.After(latest) {
+		v.Add("date", "must not be in the future")
+	}
+	if !date.IsZero() && date.Year() < 1970 {
+		v.Add("date", "must not be before 1970")
 	}
-	for _, exercise := range weightsLog.Exercises {
-		exercise.WeightsLogID = weightsLogID
-		_, err := tx.NamedExec(`INSERT INTO exercises (weights_log_id, name, set1, set2, set3) VALUES (:weights_log_id, :name, :set1, :set2, :set3)`, &exercise)
+	if performed == nil {
+		return
+	}
+	if performed.After(latest) {
+		v.Add("performed_at", "must not be in the future")
+	}
+	if performed.Year() < 1970 {
+		v.Add("performed_at", "must not be before 1970")
+	}
+}
+
+// checkStored rejects a log written through the admin API without a date or
+// an existing owner, which would store it as undated or ownerless and drop it
+// from every user's history.
+func checkStored(ctx context.Context, userID *int, date time.Time) error {
+	v := newValidation(ErrInvalidWorkout)
+	if date.IsZero() {
+		v.Add("date", "is required")
+	}
+	if userID == nil {
+		v.Add("user_id", "is required")
+	} else {
+		user, err := store.FetchUserByID(ctx, *userID)
 		if err != nil {
-			tx.Rollback()
 			return err
 		}
+		if user == nil {
+			v.Add("user_id", "must be an existing user")
+		}
 	}
-	return tx.Commit()
+	return v.OrNil()
+}
+
+// performedAt returns when a log was performed, in the server's time zone:
+// the performed_at given by the client, or the date older clients send
+// instead, or now.
+func performedAt(performed *time.Time, date time.Time) time.Time {
+	switch {
+	case performed != nil:
+		return performed.Local()
+	case !date.IsZero():
+		return date.Local()
+	}
+	return time.Now()
 }
 
-// FetchLoggedCardioWorkouts retrieves all logged cardio workouts from the database.
-func FetchLoggedCardioWorkouts() ([]Workout, error) {
-	var workouts []Workout
-	err := database.DB.Select(&workouts, "SELECT * FROM workouts WHERE LOWER(type) IN ('run', 'bike', 'row', 'walk', 'crosstrainer') ORDER BY date DESC")
-	if err != nil {
-		log.Printf("Error fetching logged cardio workouts: %v", err)
-		return nil, err
+// validate checks the fields of a weights log and of the exercises it gives.
+func (l WeightsLog) validate() *ValidationError {
+	v := newValidation(ErrInvalidWorkout)
+	if strings.TrimSpace(l.WorkoutType) == "" {
+		v.Add("workout_type", "is required")
 	}
-	return workouts, nil
+	checkDates(v, l.Date, l.PerformedAt)
+	for i, exercise := range l.Exercises {
+		exercise.validateInto(v, fmt.Sprintf("exercises[%d]", i))
+	}
+	return v
 }
 
-// FetchLoggedWeightsWorkouts retrieves all logged weights workouts from the database.
-func FetchLoggedWeightsWorkouts() ([]WeightsLog, error) {
-	var weightsLogs []WeightsLog
-	err := database.DB.Select(&weightsLogs, "SELECT * FROM weights_logs ORDER BY date DESC")
-	if err != nil {
-		log.Printf("Error fetching logged weights workouts: %v", err)
-		return nil, err
+// validateStored checks a weights log written through the admin API, which
+// stores the log alone; its exercises are managed through their own table.
+func (l WeightsLog) validateStored() *ValidationError {
+	v := l.validate()
+	if len(l.Exercises) > 0 {
+		v.Add("exercises", "must be added through the exercises table")
 	}
-	return weightsLogs, nil
+	return v
 }
 
-// FetchWeightWorkouts retrieves weight workouts from the database.
-func FetchWeightWorkouts(workoutType string) ([]WeightWorkout, error) {
-	var weightWorkouts []WeightWorkout
-	err := database.DB.Select(&weightWorkouts, "SELECT * FROM weight_workouts WHERE workout_type=$1", workoutType)
+// validate checks the fields of an exercise and its sets.
+func (e Exercise) validate() *ValidationError {
+	v := newValidation(ErrInvalidSet)
+	e.validateInto(v, "")
+	return v
+}
+
+// validateInto records the invalid fields of an exercise and its sets under
+// path.
+func (e Exercise) validateInto(v *ValidationError, path string) {
+	if strings.TrimSpace(e.Name) == "" {
+		v.Add(fieldPath(path, "name"), "is required")
+	}
+	if len(e.Sets) == 0 {
+		v.Add(fieldPath(path, "sets"), "must have at least one set")
+	}
+	for i, set := range e.Sets {
+		setPath := fieldPath(path, fmt.Sprintf("sets[%d]", i))
+		if set.SetNumber < 0 {
+			v.Add(fieldPath(setPath, "set_number"), "must not be negative")
+		}
+		if set.Reps < 1 {
+			v.Add(fieldPath(setPath, "reps"), "must be at least 1")
+		}
+		if set.Weight < 0 {
+			v.Add(fieldPath(setPath, "weight"), "must not be negative")
+		}
+		if set.Unit != "" && set.Unit != UnitKg && set.Unit != UnitLb {
+			v.Add(fieldPath(setPath, "unit"), "must be %s or %s", UnitKg, UnitLb)
+		}
+		switch set.SetType {
+		case "", SetTypeWarmup, SetTypeWorking, SetTypeDrop:
+		default:
+			v.Add(fieldPath(setPath, "set_type"), "must be %s, %s or %s", SetTypeWarmup, SetTypeWorking, SetTypeDrop)
+		}
+		if set.RPE != nil && (*set.RPE < 1 || *set.RPE > 10) {
+			v.Add(fieldPath(setPath, "rpe"), "must be between 1 and 10")
+		}
+	}
+}
+
+// validate checks the fields of a WOD.
+func (w WOD) validate() *ValidationError {
+	v := newValidation(ErrInvalidWorkout)
+	if strings.TrimSpace(w.Type) == "" {
+		v.Add("type", "is required")
+	}
+	if w.Duration < 0 {
+		v.Add("duration", "must not be negative")
+	}
+	if w.Distance < 0 {
+		v.Add("distance", "must not be negative")
+	}
+	return v
+}
+
+// validate checks the fields of a weight workout.
+func (w WeightWorkout) validate() *ValidationError {
+	v := newValidation(ErrInvalidWorkout)
+	if strings.TrimSpace(w.WorkoutType) == "" {
+		v.Add("workout_type", "is required")
+	}
+	if strings.TrimSpace(w.Exercise) == "" {
+		v.Add("exercise", "is required")
+	}
+	return v
+}
+
+// prepareSets fills in defaults for omitted set fields, with unit as the
+// default unit, and converts the weights to kilograms for storage. The sets
+// must have been validated.
+func prepareSets(sets []ExerciseSet, unit string) {
+	for i := range sets {
+		set := &sets[i]
+		if set.SetNumber == 0 {
+			set.SetNumber = i + 1
+		}
+		if set.Unit == "" {
+			set.Unit = unit
+		}
+		set.Weight = toKg(set.Weight, set.Unit)
+		set.Unit = UnitKg
+		if set.SetType == "" {
+			set.SetType = SetTypeWorking
+		}
+	}
+}
+
+// FetchLoggedCardioWorkouts retrieves one page of the user's logged cardio workouts from the database.
+func FetchLoggedCardioWorkouts(ctx context.Context, q LogQuery) (Page[Workout], error) {
+	q.normalize()
+	if q.Type != "" {
+		cardioTypes, err := store.ViewCardioTypes(ctx)
+		if err != nil {
+			return Page[Workout]{}, err
+		}
+		q.CardioTypeID = classifyCardio(cardioTypes, q.Type)
+	}
+	workouts, total, err := store.FetchLoggedCardioWorkouts(ctx, q)
 	if err != nil {
-		log.Printf("Error fetching weight workouts: %v", err)
-		return nil, err
+		return Page[Workout]{}, err
 	}
-	return weightWorkouts, nil
+	return buildPage(workouts, total, q.Limit, func(w Workout) Cursor {
+		return Cursor{Date: w.Date, ID: w.ID}
+	}), nil
 }
 
-// FetchLastLoggedCardioWorkout retrieves the last logged cardio workout from the database.
-func FetchLastLoggedCardioWorkout() (*Workout, error) {
-	var workout Workout
-	err := database.DB.Get(&workout, "SELECT * FROM workouts WHERE type IN ('run', 'bike', 'row', 'walk', 'crosstrainer') ORDER BY date DESC LIMIT 1")
+// FetchLoggedWeightsWorkouts retrieves one page of the user's logged weights workouts from the database.
+func FetchLoggedWeightsWorkouts(ctx context.Context, q LogQuery) (Page[WeightsLog], error) {
+	q.normalize()
+	weightsLogs, total, err := store.FetchLoggedWeightsWorkouts(ctx, q)
 	if err != nil {
-		log.Printf("Error fetching last logged cardio workout: %v", err)
-		return nil, err
+		return Page[WeightsLog]{}, err
 	}
-	return &workout, nil
+	return buildPage(weightsLogs, total, q.Limit, func(l WeightsLog) Cursor {
+		return Cursor{Date: l.Date, ID: l.ID}
+	}), nil
+}
+
+// FetchWeightWorkouts retrieves weight workouts from the database.
+func FetchWeightWorkouts(ctx context.Context, workoutType string) ([]WeightWorkout, error) {
+	return store.FetchWeightWorkouts(ctx, workoutType)
+}
+
+// FetchWeightsLogsBetween retrieves the user's weights logs from from up to but
+// excluding to, oldest first.
+func FetchWeightsLogsBetween(ctx context.Context, userID int, from, to time.Time) ([]WeightsLog, error) {
+	return store.FetchWeightsLogsBetween(ctx, &userID, from, to)
+}
+
+// FetchLastLoggedCardioWorkout retrieves the user's last logged cardio workout from the database.
+func FetchLastLoggedCardioWorkout(ctx context.Context, userID int) (*Workout, error) {
+	return store.FetchLastLoggedCardioWorkout(ctx, userID)
 }
 
-// FetchLastLoggedWeightsWorkout retrieves the last logged weights workout for a specific type from the database.
-func FetchLastLoggedWeightsWorkout(workoutType string) (*WeightsLog, error) {
-	var weightsLog WeightsLog
-	err := database.DB.Get(&weightsLog, "SELECT * FROM weights_logs WHERE workout_type=$1 ORDER BY date DESC LIMIT 1", workoutType)
+// FetchLastLoggedWeightsWorkout retrieves the user's last logged weights workout for a specific type from the database.
+func FetchLastLoggedWeightsWorkout(ctx context.Context, userID int, workoutType string) (*WeightsLog, error) {
+	weightsLog, err := store.FetchLastLoggedWeightsWorkout(ctx, userID, workoutType)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			log.Printf("No weights workout found for type: %s", workoutType)
-			return nil, nil
-		}
-		log.Printf("Error fetching last logged weights workout: %v", err)
 		return nil, err
 	}
+	if weightsLog == nil {
+		slog.DebugContext(ctx, "No weights workout found", "user_id", userID, "type", workoutType)
+		return nil, nil
+	}
+	return weightsLog, nil
+}
 
-	log.Printf("Fetched weights log: %+v", weightsLog)
+// DateRepair is a workout stored without a date and the date it is given.
+type DateRepair struct {
+	WorkoutID int
+	Date      time.Time
+}
 
-	var exercises []Exercise
-	err = database.DB.Select(&exercises, "SELECT * FROM exercises WHERE weights_log_id=$1", weightsLog.ID)
+// RepairWorkoutDates dates the workouts stored without a date by clients that
+// relied on the server to timestamp them. IDs follow the order workouts were
+// logged in, so each gets the date of the owner's next dated workout, or of
+// their previous one when none follows, or when the owner has no dated
+// workout the time their account was created, or now for workouts without an
+// owner. The personal records they set are redated too. With dryRun the
+// repairs are only reported.
+func RepairWorkoutDates(ctx context.Context, dryRun bool) ([]DateRepair, error) {
+	workouts, err := store.ViewWorkouts(ctx)
 	if err != nil {
-		log.Printf("Error fetching exercises for weights log ID %d: %v", weightsLog.ID, err)
 		return nil, err
 	}
-	log.Printf("Fetched exercises for weights log ID %d: %+v", weightsLog.ID, exercises)
-	weightsLog.Exercises = exercises
+	sort.Slice(workouts, func(i, j int) bool { return workouts[i].ID < workouts[j].ID })
+	undated := func(w Workout) bool { return w.Date.Year() <= 1 }
+	sameOwner := func(a, b Workout) bool {
+		return (a.UserID == nil && b.UserID == nil) || (a.UserID != nil && b.UserID != nil && *a.UserID == *b.UserID)
+	}
 
-	return &weightsLog, nil
+	var repairs []DateRepair
+	for i, workout := range workouts {
+		if !undated(workout) {
+			continue
+		}
+		var date time.Time
+		found := false
+		for _, next := range workouts[i+1:] {
+			if sameOwner(next, workout) && !undated(next) {
+				date, found = next.Date, true
+				break
+			}
+		}
+		for j := i - 1; j >= 0 && !found; j-- {
+			if sameOwner(workouts[j], workout) && !undated(workouts[j]) {
+				date, found = workouts[j].Date, true
+			}
+		}
+		if !found {
+			if date, err = ownerCreatedAt(ctx, workout.UserID); err != nil {
+				return nil, err
+			}
+		}
+		repairs = append(repairs, DateRepair{WorkoutID: workout.ID, Date: date})
+	}
+	if dryRun {
+		return repairs, nil
+	}
+	for _, repair := range repairs {
+		if err := store.SetWorkoutDate(ctx, repair.WorkoutID, repair.Date); err != nil {
+			return nil, err
+		}
+	}
+	return repairs, nil
 }
 
-// Admin Section - Add, Update, Delete
+// ownerCreatedAt returns when the owner of a workout created their account, or
+// now for workouts without an existing owner.
+func ownerCreatedAt(ctx context.Context, userID *int) (time.Time, error) {
+	if userID == nil {
+		return time.Now(), nil
+	}
+	user, err := store.FetchUserByID(ctx, *userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if user == nil || user.CreatedAt.IsZero() {
+		return time.Now(), nil
+	}
+	return user.CreatedAt, nil
+}
+
+// Admin Section - Add, Update, Delete. The admin API validates records with
+// the validation registered for their table before calling these. Workouts
+// and weights logs must also name their owner, and a replacement its date.
 
-// AddWorkout adds a new workout to the database
-func AddWorkout(workout Workout) error {
-	_, err := database.DB.NamedExec(`INSERT INTO workouts (type, duration, distance, date) VALUES (:type, :duration, :distance, :date)`, &workout)
-	return err
+// AddWorkout adds a new workout to the database, dated now unless it has a date
+func AddWorkout(ctx context.Context, workout Workout) error {
+	if workout.Date.IsZero() {
+		workout.Date = time.Now()
+	}
+	if err := checkStored(ctx, workout.UserID, workout.Date); err != nil {
+		return err
+	}
+	if err := classifyWorkout(ctx, &workout); err != nil {
+		return err
+	}
+	if err := store.AddWorkout(ctx, workout); err != nil {
+		return err
+	}
+	return recomputeRecords(ctx, workout.UserID)
 }
 
 // UpdateWorkout updates an existing workout in the database
-func UpdateWorkout(workout Workout) error {
-	_, err := database.DB.NamedExec(`UPDATE workouts SET type=:type, duration=:duration, distance=:distance, date=:date WHERE id=:id`, &workout)
-	return err
+func UpdateWorkout(ctx context.Context, workout Workout) error {
+	if err := checkStored(ctx, workout.UserID, workout.Date); err != nil {
+		return err
+	}
+	if err := classifyWorkout(ctx, &workout); err != nil {
+		return err
+	}
+	previous, err := store.FetchWorkout(ctx, workout.ID)
+	if err != nil {
+		return err
+	}
+	if err := store.UpdateWorkout(ctx, workout); err != nil {
+		return err
+	}
+	return recomputeRecords(ctx, workoutOwner(previous), workout.UserID)
 }
 
 // DeleteWorkout deletes a workout from the database
-func DeleteWorkout(id int) error {
-	_, err := database.DB.Exec(`DELETE FROM workouts WHERE id=$1`, id)
-	return err
+func DeleteWorkout(ctx context.Context, id int) error {
+	previous, err := store.FetchWorkout(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := store.DeleteWorkout(ctx, id); err != nil {
+		return err
+	}
+	return recomputeRecords(ctx, workoutOwner(previous))
 }
 
-// AddWeightsLog adds a new weights log to the database
-func AddWeightsLog(weightsLog WeightsLog) error {
-	weightsLog.Date = time.Now() // Set the current time and date
-	_, err := database.DB.NamedExec(`INSERT INTO weights_logs (workout_type, date) VALUES (:workout_type, :date)`, &weightsLog)
-	return err
+// workoutOwner returns the owner of a workout that may not exist.
+func workoutOwner(workout *Workout) *int {
+	if workout == nil {
+		return nil
+	}
+	return workout.UserID
 }
 
-// UpdateWeightsLog updates an existing weights log in the database
-func UpdateWeightsLog(weightsLog WeightsLog) error {
-	_, err := database.DB.NamedExec(`UPDATE weights_logs SET workout_type=:workout_type, date=:date WHERE id=:id`, &weightsLog)
-	return err
+// AddWeightsLog adds a new weights log to the database, dated now unless it has a date
+func AddWeightsLog(ctx context.Context, weightsLog WeightsLog) error {
+	if weightsLog.Date.IsZero() {
+		weightsLog.Date = time.Now()
+	}
+	if err := checkStored(ctx, weightsLog.UserID, weightsLog.Date); err != nil {
+		return err
+	}
+	return store.AddWeightsLog(ctx, weightsLog)
 }
 
-func DeleteWeightsLog(id int) error {
-	// Delete related records in the exercises table
-	_, err := database.DB.Exec("DELETE FROM exercises WHERE weights_log_id = $1", id)
+// UpdateWeightsLog updates an existing weights log in the database
+func UpdateWeightsLog(ctx context.Context, weightsLog WeightsLog) error {
+	if err := checkStored(ctx, weightsLog.UserID, weightsLog.Date); err != nil {
+		return err
+	}
+	previous, err := weightsLogOwner(ctx, weightsLog.ID)
 	if err != nil {
-		log.Printf("Error deleting related exercises: %v", err)
 		return err
 	}
+	if err := store.UpdateWeightsLog(ctx, weightsLog); err != nil {
+		return err
+	}
+	return recomputeRecords(ctx, previous, weightsLog.UserID)
+}
 
-	// Delete the record in the weights_logs table
-	_, err = database.DB.Exec("DELETE FROM weights_logs WHERE id = $1", id)
+// DeleteWeightsLog deletes a weights log and its exercises from the database
+func DeleteWeightsLog(ctx context.Context, id int) error {
+	owner, err := weightsLogOwner(ctx, id)
 	if err != nil {
-		log.Printf("Error deleting record from weights_logs table: %v", err)
 		return err
 	}
-	return nil
+	if err := store.DeleteWeightsLog(ctx, id); err != nil {
+		return err
+	}
+	return recomputeRecords(ctx, owner)
 }
 
-// AddExercise adds a new exercise to the database
-func AddExercise(exercise Exercise) error {
-	_, err := database.DB.NamedExec(`INSERT INTO exercises (weights_log_id, name, set1, set2, set3) VALUES (:weights_log_id, :name, :set1, :set2, :set3)`, &exercise)
-	return err
+// weightsLogOwner returns the owner of a weights log, or nil if it has none or
+// does not exist.
+func weightsLogOwner(ctx context.Context, id int) (*int, error) {
+	weightsLog, err := store.FetchWeightsLog(ctx, id)
+	if err != nil || weightsLog == nil {
+		return nil, err
+	}
+	return weightsLog.UserID, nil
 }
 
-// UpdateExercise updates an existing exercise in the database
-func UpdateExercise(exercise Exercise) error {
-	_, err := database.DB.NamedExec(`UPDATE exercises SET weights_log_id=:weights_log_id, name=:name, set1=:set1, set2=:set2, set3=:set3 WHERE id=:id`, &exercise)
-	return err
+// exerciseOwner returns the owner of the weights log of an exercise, or nil if
+// there is none.
+func exerciseOwner(ctx context.Context, id int) (*int, error) {
+	exercise, err := store.FetchExercise(ctx, id)
+	if err != nil || exercise == nil {
+		return nil, err
+	}
+	return weightsLogOwner(ctx, exercise.WeightsLogID)
+}
+
+// AddExercise adds a new exercise and its sets to the database
+func AddExercise(ctx context.Context, exercise Exercise) error {
+	prepareSets(exercise.Sets, UnitKg)
+	if err := store.AddExercise(ctx, exercise); err != nil {
+		return err
+	}
+	owner, err := weightsLogOwner(ctx, exercise.WeightsLogID)
+	if err != nil {
+		return err
+	}
+	return recomputeRecords(ctx, owner)
+}
+
+// UpdateExercise updates an existing exercise in the database, replacing its sets
+func UpdateExercise(ctx context.Context, exercise Exercise) error {
+	prepareSets(exercise.Sets, UnitKg)
+	previous, err := exerciseOwner(ctx, exercise.ID)
+	if err != nil {
+		return err
+	}
+	if err := store.UpdateExercise(ctx, exercise); err != nil {
+		return err
+	}
+	owner, err := weightsLogOwner(ctx, exercise.WeightsLogID)
+	if err != nil {
+		return err
+	}
+	return recomputeRecords(ctx, previous, owner)
 }
 
 // DeleteExercise deletes an exercise from the database
-func DeleteExercise(id int) error {
-	_, err := database.DB.Exec(`DELETE FROM exercises WHERE id=$1`, id)
-	return err
+func DeleteExercise(ctx context.Context, id int) error {
+	owner, err := exerciseOwner(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := store.DeleteExercise(ctx, id); err != nil {
+		return err
+	}
+	return recomputeRecords(ctx, owner)
 }
 
 // AddWOD adds a new WOD to the database
-func AddWOD(wod WOD) error {
-	_, err := database.DB.NamedExec(`INSERT INTO wods (type, duration, distance, date) VALUES (:type, :duration, :distance, :date)`, &wod)
-	return err
+func AddWOD(ctx context.Context, wod WOD) error {
+	return store.AddWOD(ctx, wod)
 }
 
 // UpdateWOD updates an existing WOD in the database
-func UpdateWOD(wod WOD) error {
-	_, err := database.DB.NamedExec(`UPDATE wods SET type=:type, duration=:duration, distance=:distance, date=:date WHERE id=:id`, &wod)
-	return err
+func UpdateWOD(ctx context.Context, wod WOD) error {
+	return store.UpdateWOD(ctx, wod)
 }
 
 // DeleteWOD deletes a WOD from the database
-func DeleteWOD(id int) error {
-	_, err := database.DB.Exec(`DELETE FROM wods WHERE id=$1`, id)
-	return err
+func DeleteWOD(ctx context.Context, id int) error {
+	return store.DeleteWOD(ctx, id)
 }
 
 // AddWeightWorkout adds a new weight workout to the database
-func AddWeightWorkout(weightWorkout WeightWorkout) error {
-	_, err := database.DB.NamedExec(`INSERT INTO weight_workouts (workout_type, exercise) VALUES (:workout_type, :exercise)`, &weightWorkout)
-	return err
+func AddWeightWorkout(ctx context.Context, weightWorkout WeightWorkout) error {
+	return store.AddWeightWorkout(ctx, weightWorkout)
 }
 
 // UpdateWeightWorkout updates an existing weight workout in the database
-func UpdateWeightWorkout(weightWorkout WeightWorkout) error {
-	_, err := database.DB.NamedExec(`UPDATE weight_workouts SET workout_type=:workout_type, exercise=:exercise WHERE id=:id`, &weightWorkout)
-	return err
+func UpdateWeightWorkout(ctx context.Context, weightWorkout WeightWorkout) error {
+	return store.UpdateWeightWorkout(ctx, weightWorkout)
 }
 
 // DeleteWeightWorkout deletes a weight workout from the database
-func DeleteWeightWorkout(id int) error {
-	_, err := database.DB.Exec(`DELETE FROM weight_workouts WHERE id=$1`, id)
-	return err
+func DeleteWeightWorkout(ctx context.Context, id int) error {
+	return store.DeleteWeightWorkout(ctx, id)
 }
 
 // ViewWorkouts retrieves all workouts from the database
-func ViewWorkouts() ([]Workout, error) {
-	var workouts []Workout
-	err := database.DB.Select(&workouts, "SELECT * FROM workouts")
-	if err != nil {
-		log.Printf("Error viewing workouts: %v", err)
-		return nil, err
-	}
-	return workouts, nil
+func ViewWorkouts(ctx context.Context) ([]Workout, error) {
+	return store.ViewWorkouts(ctx)
+}
+
+// FetchWorkout retrieves a workout by ID from the database, or nil if there is none
+func FetchWorkout(ctx context.Context, id int) (*Workout, error) {
+	return store.FetchWorkout(ctx, id)
 }
 
 // ViewWeightsLogs retrieves all weights logs from the database
-func ViewWeightsLogs() ([]WeightsLog, error) {
-	var weightsLogs []WeightsLog
-	err := database.DB.Select(&weightsLogs, "SELECT * FROM weights_logs")
-	if err != nil {
-		log.Printf("Error viewing weights logs: %v", err)
-		return nil, err
-	}
-	return weightsLogs, nil
+func ViewWeightsLogs(ctx context.Context) ([]WeightsLog, error) {
+	return store.ViewWeightsLogs(ctx)
+}
+
+// FetchWeightsLog retrieves a weights log, without its exercises, by ID from the database, or nil if there is none
+func FetchWeightsLog(ctx context.Context, id int) (*WeightsLog, error) {
+	return store.FetchWeightsLog(ctx, id)
 }
 
 // ViewExercises retrieves all exercises from the database
-func ViewExercises() ([]Exercise, error) {
-	var exercises []Exercise
-	err := database.DB.Select(&exercises, "SELECT * FROM exercises")
-	if err != nil {
-		log.Printf("Error viewing exercises: %v", err)
-		return nil, err
-	}
-	return exercises, nil
+func ViewExercises(ctx context.Context) ([]Exercise, error) {
+	return store.ViewExercises(ctx)
+}
+
+// FetchExercise retrieves a exercise and its sets by ID from the database, or nil if there is none
+func FetchExercise(ctx context.Context, id int) (*Exercise, error) {
+	return store.FetchExercise(ctx, id)
 }
 
 // ViewWODs retrieves all WODs from the database
-func ViewWODs() ([]WOD, error) {
-	var wods []WOD
-	err := database.DB.Select(&wods, "SELECT * FROM wods")
-	if err != nil {
-		log.Printf("Error viewing WODs: %v", err)
-		return nil, err
-	}
-	return wods, nil
+func ViewWODs(ctx context.Context) ([]WOD, error) {
+	return store.ViewWODs(ctx)
+}
+
+// FetchWOD retrieves a WOD by ID from the database, or nil if there is none
+func FetchWOD(ctx context.Context, id int) (*WOD, error) {
+	return store.FetchWOD(ctx, id)
 }
 
 // ViewWeightWorkouts retrieves all weight workouts from the database
-func ViewWeightWorkouts() ([]WeightWorkout, error) {
-	var weightWorkouts []WeightWorkout
-	err := database.DB.Select(&weightWorkouts, "SELECT * FROM weight_workouts")
-	if err != nil {
-		log.Printf("Error viewing weight workouts: %v", err)
-		return nil, err
-	}
-	return weightWorkouts, nil
+func ViewWeightWorkouts(ctx context.Context) ([]WeightWorkout, error) {
+	return store.ViewWeightWorkouts(ctx)
 }
 
-func EmptyWorkouts() error {
-	_, err := database.DB.Exec("DELETE FROM workouts")
-	if err != nil {
-		log.Printf("Error emptying workouts table: %v", err)
-		return err
-	}
-	return nil
+// FetchWeightWorkout retrieves a weight workout by ID from the database, or nil if there is none
+func FetchWeightWorkout(ctx context.Context, id int) (*WeightWorkout, error) {
+	return store.FetchWeightWorkout(ctx, id)
 }
 
-func EmptyWeightsLogs() error {
-	// Delete related records in the exercises table
-	_, err := database.DB.Exec("DELETE FROM exercises WHERE weights_log_id IN (SELECT id FROM weights_logs)")
-	if err != nil {
-		log.Printf("Error deleting related exercises: %v", err)
-		return err
-	}
+func EmptyWorkouts(ctx context.Context) error {
+	return store.EmptyWorkouts(ctx)
+}
 
-	// Delete records in the weights_logs table
-	_, err = database.DB.Exec("DELETE FROM weights_logs")
-	if err != nil {
-		log.Printf("Error emptying weights_logs table: %v", err)
-		return err
-	}
-	return nil
+func EmptyWeightsLogs(ctx context.Context) error {
+	return store.EmptyWeightsLogs(ctx)
 }
 
-func EmptyExercises() error {
-	_, err := database.DB.Exec("DELETE FROM exercises")
-	if err != nil {
-		log.Printf("Error emptying exercises table: %v", err)
-		return err
-	}
-	return nil
+func EmptyExercises(ctx context.Context) error {
+	return store.EmptyExercises(ctx)
 }
 
-func EmptyWODs() error {
-	_, err := database.DB.Exec("DELETE FROM wods")
-	if err != nil {
-		log.Printf("Error emptying wods table: %v", err)
-		return err
-	}
-	return nil
+func EmptyWODs(ctx context.Context) error {
+	return store.EmptyWODs(ctx)
 }
 
-func EmptyWeightWorkouts() error {
-	_, err := database.DB.Exec("DELETE FROM weight_workouts")
-	if err != nil {
-		log.Printf("Error emptying weight_workouts table: %v", err)
-		return err
-	}
-	return nil
+func EmptyWeightWorkouts(ctx context.Context) error {
+	return store.EmptyWeightWorkouts(ctx)
 }
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestCheckDates(t *testing.T) {
+	now := time.Now()
+	at := func(t time.Time) *time.Time { return &t }
+	tests := []struct {
+		name      string
+		date      time.Time
+		performed *time.Time
+		want      []string // Invalid fields
+	}{
+		{name: "no dates"},
+		{name: "past date", date: now.AddDate(0, 0, -1)},
+		{name: "clock slightly fast", date: now.Add(time.Minute), performed: at(now.Add(time.Minute))},
+		{name: "future date", date: now.Add(time.Hour), want: []string{"date"}},
+		{name: "date before 1970", date: time.Date(1969, 12, 31, 0, 0, 0, 0, time.UTC), want: []string{"date"}},
+		{name: "future performed_at", performed: at(now.Add(time.Hour)), want: []string{"performed_at"}},
+		{name: "performed_at before 1970", performed: at(time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC)), want: []string{"performed_at"}},
+		{name: "both invalid", date: time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC), performed: at(now.AddDate(1, 0, 0)), want: []string{"date", "performed_at"}},
+	}
+	for _, tt := range tests {
+		v := newValidation(ErrInvalidWorkout)
+		checkDates(v, tt.date, tt.performed)
+		var got []string
+		for _, field := range v.Fields {
+			got = append(got, field.Field)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: invalid fields %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -1,33 +1,73 @@
 package routes
 
 import (
+	"momentum/internal/auth"
 	"momentum/internal/handlers"
+	"momentum/internal/metrics"
 	"net/http"
 
 	"github.com/gorilla/mux"
 )
 
-func InitializeRoutes() *mux.Router {
+// InitializeRoutes returns the router of the API, serving the web app from
+// staticDir.
+func InitializeRoutes(staticDir string) *mux.Router {
 	router := mux.NewRouter()
+	router.Use(metrics.Middleware)
 
-	router.HandleFunc("/workout/today", handlers.GetWorkoutOfTheDay).Methods("GET")
-	router.HandleFunc("/workout/log/cardio", handlers.LogCardioWorkout).Methods("POST")
-	router.HandleFunc("/workout/log/weights", handlers.LogWeightsWorkout).Methods("POST")
-	router.HandleFunc("/workout/logs/cardio", handlers.GetLoggedCardioWorkouts).Methods("GET")
-	router.HandleFunc("/workout/logs/weights", handlers.GetLoggedWeightsWorkouts).Methods("GET")
-	router.HandleFunc("/workout/weight-workouts", handlers.GetWeightWorkouts).Methods("GET")
-	router.HandleFunc("/workout/last/cardio", handlers.GetLastLoggedCardioWorkout).Methods("GET")
-	router.HandleFunc("/workout/last/weights", handlers.GetLastLoggedWeightsWorkout).Methods("GET")
-
-	// Admin routes
-	router.HandleFunc("/admin/add/{table}", handlers.AddRecord).Methods("POST")
-	router.HandleFunc("/admin/update/{table}", handlers.UpdateRecord).Methods("POST")
-	router.HandleFunc("/admin/delete/{table}", handlers.DeleteRecord).Methods("POST")
-	router.HandleFunc("/admin/view/{table}", handlers.ViewRecords).Methods("GET")
-	router.HandleFunc("/admin/empty/{table}", handlers.EmptyTable).Methods("POST")
-
-	// Serve static files from the "web" directory
-	router.PathPrefix("/").Handler(http.FileServer(http.Dir("./web")))
+	// Health checks and metrics for load balancers, orchestrators and Prometheus
+	router.HandleFunc("/healthz", handlers.GetHealth).Methods("GET")
+	router.HandleFunc("/readyz", handlers.GetReadiness).Methods("GET")
+	router.Handle("/metrics", metrics.Handler()).Methods("GET")
+
+	// Account routes
+	router.HandleFunc("/auth/register", handlers.Register).Methods("POST")
+	router.HandleFunc("/auth/login", handlers.Login).Methods("POST")
+	router.HandleFunc("/auth/logout", handlers.Logout).Methods("POST")
+	router.Handle("/auth/me", auth.RequireUser(http.HandlerFunc(handlers.GetCurrentUser))).Methods("GET")
+	router.Handle("/auth/me", auth.RequireUser(http.HandlerFunc(handlers.UpdateCurrentUser))).Methods("PATCH")
+
+	// Workout routes only return the logged-in user's own data
+	workout := router.PathPrefix("/workout").Subrouter()
+	workout.Use(auth.RequireUser)
+	workout.HandleFunc("/today", handlers.GetWorkoutOfTheDay).Methods("GET")
+	workout.HandleFunc("/wod/history", handlers.GetWODHistory).Methods("GET")
+	workout.HandleFunc("/programs", handlers.GetPrograms).Methods("GET")
+	workout.HandleFunc("/programs/{id:[0-9]+}", handlers.GetProgram).Methods("GET")
+	workout.HandleFunc("/programs/{id:[0-9]+}/enrol", handlers.EnrolInProgram).Methods("POST")
+	workout.HandleFunc("/enrolment", handlers.GetEnrolment).Methods("GET")
+	workout.HandleFunc("/enrolment", handlers.EndEnrolment).Methods("DELETE")
+	workout.HandleFunc("/log/cardio", handlers.LogCardioWorkout).Methods("POST")
+	workout.HandleFunc("/log/weights", handlers.LogWeightsWorkout).Methods("POST")
+	workout.HandleFunc("/logs/cardio", handlers.GetLoggedCardioWorkouts).Methods("GET")
+	workout.HandleFunc("/logs/weights", handlers.GetLoggedWeightsWorkouts).Methods("GET")
+	workout.HandleFunc("/weight-workouts", handlers.GetWeightWorkouts).Methods("GET")
+	workout.HandleFunc("/last/cardio", handlers.GetLastLoggedCardioWorkout).Methods("GET")
+	workout.HandleFunc("/last/weights", handlers.GetLastLoggedWeightsWorkout).Methods("GET")
+	workout.HandleFunc("/suggest/weights", handlers.GetWeightsSuggestion).Methods("GET")
+	workout.HandleFunc("/records", handlers.GetPersonalRecords).Methods("GET")
+
+	// Analytics routes summarise the logged-in user's own training
+	analytics := router.PathPrefix("/analytics").Subrouter()
+	analytics.Use(auth.RequireUser)
+	analytics.HandleFunc("/strength", handlers.GetStrengthAnalytics).Methods("GET")
+	analytics.HandleFunc("/cardio", handlers.GetCardioAnalytics).Methods("GET")
+	analytics.HandleFunc("/days", handlers.GetDailyAnalytics).Methods("GET")
+
+	// Admin routes require a logged-in user with the admin role
+	admin := router.PathPrefix("/admin").Subrouter()
+	admin.Use(auth.RequireUser, auth.RequireAdmin)
+	admin.HandleFunc("", handlers.GetAdminTables).Methods("GET")
+	admin.HandleFunc("/{table}", handlers.ViewRecords).Methods("GET")
+	admin.HandleFunc("/{table}", handlers.AddRecord).Methods("POST")
+	admin.HandleFunc("/{table}", handlers.EmptyTable).Methods("DELETE")
+	admin.HandleFunc("/{table}/{id}", handlers.GetRecord).Methods("GET")
+	admin.HandleFunc("/{table}/{id}", handlers.ReplaceRecord).Methods("PUT")
+	admin.HandleFunc("/{table}/{id}", handlers.PatchRecord).Methods("PATCH")
+	admin.HandleFunc("/{table}/{id}", handlers.DeleteRecord).Methods("DELETE")
+
+	// Serve the web app from the static directory
+	router.PathPrefix("/").Handler(http.FileServer(http.Dir(staticDir)))
 
 	return router
 }
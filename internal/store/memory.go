@@ -0,0 +1,1413 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"momentum/internal/models"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryStore implements models.Store in process memory. Data is lost when the
+// process exits, which makes it suitable for local runs and tests.
+type MemoryStore struct {
+	mu             sync.RWMutex
+	nextID         map[string]int
+	workouts       []models.Workout
+	weightsLogs    []models.WeightsLog
+	exercises      []models.Exercise
+	wods           []models.WOD
+	cardioTypes    []models.CardioType
+	wodAssignments []models.WODAssignment
+	programs       []models.Program
+	enrolments     []models.Enrolment
+	records        []models.PersonalRecord
+	weightWorkouts []models.WeightWorkout
+	users          []models.User
+	sessions       map[string]models.Session
+}
+
+// NewMemory returns an in-memory store seeded with the same WODs, cardio types,
+// programs and weight workouts as a freshly migrated database.
+func NewMemory() *MemoryStore {
+	s := &MemoryStore{nextID: make(map[string]int), sessions: make(map[string]models.Session)}
+	now := time.Now()
+	for _, wod := range []models.WOD{
+		{Type: "Walk", Duration: 60, Distance: 5.0},
+		{Type: "Run - Intervals", Duration: 30, Distance: 5.0},
+		{Type: "Run", Duration: 30, Distance: 5.0},
+		{Type: "Crosstrainer", Duration: 30, Distance: 5.0},
+		{Type: "Row - 2 mins on 1 min off", Duration: 30, Distance: 5.0},
+		{Type: "Row - 10 x 500m", Duration: 30, Distance: 5.0},
+		{Type: "Row", Duration: 30, Distance: 5.0},
+		{Type: "Bike", Duration: 60, Distance: 20.0},
+	} {
+		wod.ID = s.newID("wods")
+		wod.Date = now
+		s.wods = append(s.wods, wod)
+	}
+	for _, cardioType := range []models.CardioType{
+		{Name: "run", Aliases: models.StringList{"running", "jog", "jogging", "treadmill"}, Category: "running"},
+		{Name: "bike", Aliases: models.StringList{"cycle", "cycling", "ride", "spin"}, Category: "cycling"},
+		{Name: "row", Aliases: models.StringList{"rowing", "rower", "erg"}, Category: "rowing"},
+		{Name: "walk", Aliases: models.StringList{"walking", "hike"}, Category: "walking"},
+		{Name: "crosstrainer", Aliases: models.StringList{"cross trainer", "elliptical"}, Category: "elliptical"},
+		{Name: "swim", Aliases: models.StringList{"swimming"}, Category: "swimming"},
+	} {
+		cardioType.ID = s.newID("cardio_types")
+		s.cardioTypes = append(s.cardioTypes, cardioType)
+	}
+	for _, weightWorkout := range []models.WeightWorkout{
+		{WorkoutType: "push", Exercise: "Flat Dumbbells", MuscleGroup: "chest"},
+		{WorkoutType: "push", Exercise: "Flat Flys", MuscleGroup: "chest"},
+		{WorkoutType: "push", Exercise: "Seated Dumbbell front raises", MuscleGroup: "shoulders"},
+		{WorkoutType: "push", Exercise: "Seated Dumbbell side raises", MuscleGroup: "shoulders"},
+		{WorkoutType: "push", Exercise: "Seated Dumbbell shoulder press", MuscleGroup: "shoulders"},
+		{WorkoutType: "push", Exercise: "Tricep Pushdowns", MuscleGroup: "triceps"},
+		{WorkoutType: "push", Exercise: "Incline Smith", MuscleGroup: "chest"},
+		{WorkoutType: "push", Exercise: "Close Grip Incline Smith", MuscleGroup: "triceps"},
+		{WorkoutType: "push", Exercise: "Overhead Rope (Cables)", MuscleGroup: "triceps"},
+		{WorkoutType: "push", Exercise: "Assisted Dips/Dip machine", MuscleGroup: "triceps"},
+		{WorkoutType: "pull", Exercise: "Deadlifts", MuscleGroup: "back"},
+		{WorkoutType: "pull", Exercise: "Bent Over Rows (Underhand)", MuscleGroup: "back"},
+		{WorkoutType: "pull", Exercise: "Shrugs (Barbell or dumbbell)", MuscleGroup: "back"},
+		{WorkoutType: "pull", Exercise: "Lat Pulldown", MuscleGroup: "back"},
+		{WorkoutType: "pull", Exercise: "Upright Rows (Barbell or Rope)", MuscleGroup: "shoulders"},
+		{WorkoutType: "pull", Exercise: "Rear Delt Raises (Dumbbell)", MuscleGroup: "shoulders"},
+		{WorkoutType: "pull", Exercise: "Single Preacher Dumbbell Curls", MuscleGroup: "biceps"},
+		{WorkoutType: "pull", Exercise: "EZ Bar Standing Curls", MuscleGroup: "biceps"},
+		{WorkoutType: "pull", Exercise: "Double Dumbbell Hammer Curls", MuscleGroup: "biceps"},
+		{WorkoutType: "legs", Exercise: "Barbell Squat", MuscleGroup: "quads"},
+		{WorkoutType: "legs", Exercise: "Straight leg deadlifts", MuscleGroup: "hamstrings"},
+		{WorkoutType: "legs", Exercise: "Front squat (added)", MuscleGroup: "quads"},
+		{WorkoutType: "legs", Exercise: "Leg Press", MuscleGroup: "quads"},
+		{WorkoutType: "legs", Exercise: "Calf Raises on Leg Press", MuscleGroup: "calves"},
+		{WorkoutType: "legs", Exercise: "Leg Extensions", MuscleGroup: "quads"},
+		{WorkoutType: "legs", Exercise: "Hamstring curls (Machine)", MuscleGroup: "hamstrings"},
+		{WorkoutType: "legs", Exercise: "Dumbbell lunges", MuscleGroup: "quads"},
+		{WorkoutType: "legs", Exercise: "Ab/Crunch Machine", MuscleGroup: "core"},
+		{WorkoutType: "legs", Exercise: "Captains Chair Leg or Knee Raises", MuscleGroup: "core"},
+	} {
+		weightWorkout.ID = s.newID("weight_workouts")
+		s.weightWorkouts = append(s.weightWorkouts, weightWorkout)
+	}
+	s.insertProgram(seedProgram(now))
+	return s
+}
+
+// seedProgram returns the 12-week push/pull/legs program seeded by the
+// create_programs migration.
+func seedProgram(now time.Time) models.Program {
+	program := models.Program{
+		Name:        "12-Week Push Pull Legs",
+		Description: "Three 4-week waves of rising intensity on push, pull and legs days, each ending with a deload week.",
+		DaysPerWeek: 3,
+		CreatedAt:   now,
+	}
+	// Sets, reps and percentage of the one-rep max of the main lifts in each week
+	schemes := []struct {
+		focus      string
+		sets, reps int
+		percent    float64
+	}{
+		{"Volume", 4, 10, 65}, {"Volume", 4, 10, 67.5}, {"Volume", 4, 8, 70}, {"Deload", 3, 8, 55},
+		{"Strength", 4, 8, 72.5}, {"Strength", 4, 6, 75}, {"Strength", 5, 5, 77.5}, {"Deload", 3, 5, 60},
+		{"Intensity", 4, 5, 80}, {"Intensity", 5, 3, 82.5}, {"Intensity", 5, 3, 85}, {"Deload", 3, 3, 65},
+	}
+	days := []struct {
+		number      int
+		workoutType string
+		main        []string
+		accessories []string
+	}{
+		{1, "push", []string{"Incline Smith", "Seated Dumbbell shoulder press"}, []string{"Tricep Pushdowns", "Seated Dumbbell side raises"}},
+		{3, "pull", []string{"Deadlifts", "Bent Over Rows (Underhand)"}, []string{"Lat Pulldown", "EZ Bar Standing Curls"}},
+		{5, "legs", []string{"Barbell Squat", "Leg Press"}, []string{"Hamstring curls (Machine)", "Calf Raises on Leg Press"}},
+	}
+	for i, scheme := range schemes {
+		week := models.ProgramWeek{WeekNumber: i + 1, Focus: scheme.focus}
+		for _, day := range days {
+			programDay := models.ProgramDay{DayNumber: day.number, WorkoutType: day.workoutType}
+			for _, name := range day.main {
+				percent := scheme.percent
+				programDay.Exercises = append(programDay.Exercises, models.ProgramExercise{Position: len(programDay.Exercises) + 1, Name: name, Sets: scheme.sets, Reps: scheme.reps, Percent1RM: &percent})
+			}
+			for _, name := range day.accessories {
+				programDay.Exercises = append(programDay.Exercises, models.ProgramExercise{Position: len(programDay.Exercises) + 1, Name: name, Sets: 3, Reps: 12})
+			}
+			week.Days = append(week.Days, programDay)
+		}
+		program.Weeks = append(program.Weeks, week)
+	}
+	return program
+}
+
+// Ping always succeeds, as the data is in memory.
+func (s *MemoryStore) Ping(ctx context.Context) error {
+	return nil
+}
+
+// newID returns the next identifier for a table. Callers must hold the lock.
+func (s *MemoryStore) newID(table string) int {
+	s.nextID[table]++
+	return s.nextID[table]
+}
+
+// SaveWorkout saves a new workout and returns its ID.
+func (s *MemoryStore) SaveWorkout(ctx context.Context, workout models.Workout) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	workout.ID = s.newID("workouts")
+	s.workouts = append(s.workouts, workout)
+	return workout.ID, nil
+}
+
+// SaveWeightsLog saves a weights log together with its exercises and returns
+// its ID.
+func (s *MemoryStore) SaveWeightsLog(ctx context.Context, weightsLog models.WeightsLog) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	weightsLog.ID = s.newID("weights_logs")
+	for _, exercise := range weightsLog.Exercises {
+		exercise.WeightsLogID = weightsLog.ID
+		s.insertExercise(exercise)
+	}
+	weightsLog.Exercises = nil
+	s.weightsLogs = append(s.weightsLogs, weightsLog)
+	return weightsLog.ID, nil
+}
+
+// FetchLoggedCardioWorkouts returns a page of the user's cardio workouts, newest first.
+func (s *MemoryStore) FetchLoggedCardioWorkouts(ctx context.Context, q models.LogQuery) ([]models.Workout, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	// The type filter matches the logged type or the cardio type it resolves to
+	untyped := q
+	untyped.Type = ""
+	var workouts []models.Workout
+	for _, workout := range s.workouts {
+		if workout.CardioTypeID != nil && matchesLog(untyped, workout.UserID, workout.Type, workout.Date) && hasCardioType(workout, q) {
+			workouts = append(workouts, workout)
+		}
+	}
+	key := func(w models.Workout) (time.Time, int) { return w.Date, w.ID }
+	sortNewestFirst(workouts, key)
+	total := len(workouts)
+	return paginate(workouts, q, key), total, nil
+}
+
+// FetchLoggedWeightsWorkouts returns a page of the user's weights logs, newest first.
+func (s *MemoryStore) FetchLoggedWeightsWorkouts(ctx context.Context, q models.LogQuery) ([]models.WeightsLog, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var weightsLogs []models.WeightsLog
+	for _, weightsLog := range s.weightsLogs {
+		if matchesLog(q, weightsLog.UserID, weightsLog.WorkoutType, weightsLog.Date) {
+			weightsLogs = append(weightsLogs, weightsLog)
+		}
+	}
+	key := func(l models.WeightsLog) (time.Time, int) { return l.Date, l.ID }
+	sortNewestFirst(weightsLogs, key)
+	total := len(weightsLogs)
+	weightsLogs = paginate(weightsLogs, q, key)
+	for i := range weightsLogs {
+		weightsLogs[i] = s.withExercises(weightsLogs[i])
+	}
+	return weightsLogs, total, nil
+}
+
+// FetchWeightWorkouts returns the weight workouts of the given type.
+func (s *MemoryStore) FetchWeightWorkouts(ctx context.Context, workoutType string) ([]models.WeightWorkout, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var weightWorkouts []models.WeightWorkout
+	for _, weightWorkout := range s.weightWorkouts {
+		if weightWorkout.WorkoutType == workoutType {
+			weightWorkouts = append(weightWorkouts, weightWorkout)
+		}
+	}
+	return weightWorkouts, nil
+}
+
+// FetchLastLoggedCardioWorkout returns the user's most recent cardio workout.
+func (s *MemoryStore) FetchLastLoggedCardioWorkout(ctx context.Context, userID int) (*models.Workout, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var last *models.Workout
+	for i, workout := range s.workouts {
+		if ownedBy(workout.UserID, userID) && workout.CardioTypeID != nil && (last == nil || !workout.Date.Before(last.Date)) {
+			last = &s.workouts[i]
+		}
+	}
+	if last == nil {
+		return nil, nil
+	}
+	workout := *last
+	return &workout, nil
+}
+
+// hasCardioType reports whether a workout's logged type is the requested one,
+// or its cardio type the one the request resolves to. An empty request matches
+// every workout.
+func hasCardioType(workout models.Workout, q models.LogQuery) bool {
+	if q.Type == "" || strings.EqualFold(workout.Type, q.Type) {
+		return true
+	}
+	return q.CardioTypeID != nil && workout.CardioTypeID != nil && *q.CardioTypeID == *workout.CardioTypeID
+}
+
+// FetchWorkoutTypes returns the distinct types of all logged workouts.
+func (s *MemoryStore) FetchWorkoutTypes(ctx context.Context) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	seen := make(map[string]bool)
+	var workoutTypes []string
+	for _, workout := range s.workouts {
+		if !seen[workout.Type] {
+			seen[workout.Type] = true
+			workoutTypes = append(workoutTypes, workout.Type)
+		}
+	}
+	return workoutTypes, nil
+}
+
+// SetWorkoutCardioType sets the cardio type of every workout of a type.
+func (s *MemoryStore) SetWorkoutCardioType(ctx context.Context, workoutType string, cardioTypeID *int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.workouts {
+		if s.workouts[i].Type == workoutType {
+			s.workouts[i].CardioTypeID = cloneID(cardioTypeID)
+		}
+	}
+	return nil
+}
+
+// SetWorkoutDate sets the date of a workout and of the personal records it set.
+func (s *MemoryStore) SetWorkoutDate(ctx context.Context, id int, date time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	found := false
+	for i := range s.workouts {
+		if s.workouts[i].ID == id {
+			s.workouts[i].Date = date
+			found = true
+		}
+	}
+	if !found {
+		return models.ErrNotFound
+	}
+	for i := range s.records {
+		if s.records[i].WorkoutID != nil && *s.records[i].WorkoutID == id {
+			s.records[i].AchievedAt = date
+		}
+	}
+	return nil
+}
+
+// FetchWorkoutsBetween returns the user's workouts logged within a time range.
+func (s *MemoryStore) FetchWorkoutsBetween(ctx context.Context, userID *int, from, to time.Time) ([]models.Workout, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var workouts []models.Workout
+	for _, workout := range s.workouts {
+		if (userID == nil || ownedBy(workout.UserID, *userID)) && !workout.Date.Before(from) && workout.Date.Before(to) {
+			workouts = append(workouts, workout)
+		}
+	}
+	sort.SliceStable(workouts, func(i, j int) bool { return workouts[i].Date.Before(workouts[j].Date) })
+	return workouts, nil
+}
+
+// FetchCardioTotals sums the user's cardio workouts within a time range by
+// period of a time zone and cardio type.
+func (s *MemoryStore) FetchCardioTotals(ctx context.Context, userID int, period string, loc *time.Location, from, to time.Time) ([]models.CardioTotal, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	totals := []models.CardioTotal{}
+	index := map[string]int{}
+	for _, workout := range s.workouts {
+		cardioType := s.cardioTypeOf(workout)
+		if cardioType == "" || !ownedBy(workout.UserID, userID) || workout.Date.Before(from) || !workout.Date.Before(to) {
+			continue
+		}
+		start := periodStart(period, workout.Date.In(loc))
+		key := start + "/" + cardioType
+		i, ok := index[key]
+		if !ok {
+			i = len(totals)
+			index[key] = i
+			totals = append(totals, models.CardioTotal{Period: start, CardioType: cardioType})
+		}
+		totals[i].Workouts++
+		totals[i].Distance += workout.Distance
+		totals[i].Duration += workout.Duration
+		if workout.Distance > 0 {
+			totals[i].PacedDuration += workout.Duration
+		}
+	}
+	sort.Slice(totals, func(i, j int) bool {
+		if totals[i].Period != totals[j].Period {
+			return totals[i].Period < totals[j].Period
+		}
+		return totals[i].CardioType < totals[j].CardioType
+	})
+	return totals, nil
+}
+
+// FetchCardioBestSplits returns the user's fastest time of each cardio type
+// over each distance within a time range.
+func (s *MemoryStore) FetchCardioBestSplits(ctx context.Context, userID int, distances []float64, from, to time.Time) ([]models.CardioSplit, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	splits := []models.CardioSplit{}
+	index := map[string]int{}
+	for _, workout := range s.workouts {
+		cardioType := s.cardioTypeOf(workout)
+		if cardioType == "" || !ownedBy(workout.UserID, userID) || workout.Date.Before(from) || !workout.Date.Before(to) || workout.Duration <= 0 {
+			continue
+		}
+		for _, distance := range distances {
+			if workout.Distance < distance {
+				continue
+			}
+			seconds := workout.Duration * distance / workout.Distance
+			key := fmt.Sprintf("%s/%g", cardioType, distance)
+			i, ok := index[key]
+			switch {
+			case !ok:
+				index[key] = len(splits)
+				splits = append(splits, models.CardioSplit{CardioType: cardioType, Distance: distance, Seconds: seconds})
+			case seconds < splits[i].Seconds:
+				splits[i].Seconds = seconds
+			}
+		}
+	}
+	sort.Slice(splits, func(i, j int) bool {
+		if splits[i].CardioType != splits[j].CardioType {
+			return splits[i].CardioType < splits[j].CardioType
+		}
+		return splits[i].Distance < splits[j].Distance
+	})
+	return splits, nil
+}
+
+// cardioTypeOf returns the name of a workout's cardio type, or "" if it is not
+// a cardio workout. Callers must hold the lock.
+func (s *MemoryStore) cardioTypeOf(workout models.Workout) string {
+	for _, cardioType := range s.cardioTypes {
+		if workout.CardioTypeID != nil && cardioType.ID == *workout.CardioTypeID {
+			return cardioType.Name
+		}
+	}
+	return ""
+}
+
+// periodStart returns the first day, as YYYY-MM-DD, of the day, week or month
+// of the wall-clock time of a timestamp.
+func periodStart(period string, t time.Time) string {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch period {
+	case models.PeriodMonth:
+		day = day.AddDate(0, 0, 1-day.Day())
+	case models.PeriodWeek:
+		day = day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	}
+	return day.Format("2006-01-02")
+}
+
+// FetchDailyActivity counts the user's logs within a time range by day of a
+// time zone.
+func (s *MemoryStore) FetchDailyActivity(ctx context.Context, userID int, loc *time.Location, from, to time.Time) ([]models.DayActivity, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	days := []models.DayActivity{}
+	index := map[string]int{}
+	activityOn := func(t time.Time) *models.DayActivity {
+		day := periodStart(models.PeriodDay, t.In(loc))
+		i, ok := index[day]
+		if !ok {
+			i = len(days)
+			index[day] = i
+			days = append(days, models.DayActivity{Day: day})
+		}
+		return &days[i]
+	}
+	for _, workout := range s.workouts {
+		if ownedBy(workout.UserID, userID) && !workout.Date.Before(from) && workout.Date.Before(to) {
+			day := activityOn(workout.Date)
+			day.CardioWorkouts++
+			day.Duration += workout.Duration
+			day.Distance += workout.Distance
+		}
+	}
+	for _, weightsLog := range s.weightsLogs {
+		if ownedBy(weightsLog.UserID, userID) && !weightsLog.Date.Before(from) && weightsLog.Date.Before(to) {
+			activityOn(weightsLog.Date).WeightsSessions++
+		}
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Day < days[j].Day })
+	return days, nil
+}
+
+// FetchWeightsLogsBetween returns the user's weights logs within a time range with their exercises.
+func (s *MemoryStore) FetchWeightsLogsBetween(ctx context.Context, userID *int, from, to time.Time) ([]models.WeightsLog, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var weightsLogs []models.WeightsLog
+	for _, weightsLog := range s.weightsLogs {
+		if (userID == nil || ownedBy(weightsLog.UserID, *userID)) && !weightsLog.Date.Before(from) && weightsLog.Date.Before(to) {
+			weightsLogs = append(weightsLogs, s.withExercises(weightsLog))
+		}
+	}
+	sort.SliceStable(weightsLogs, func(i, j int) bool { return weightsLogs[i].Date.Before(weightsLogs[j].Date) })
+	return weightsLogs, nil
+}
+
+// FetchWODAssignment returns the workout of the day assigned for a day.
+func (s *MemoryStore) FetchWODAssignment(ctx context.Context, userID *int, day time.Time) (*models.WODAssignment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.findWODAssignment(userID, day), nil
+}
+
+// CreateWODAssignment stores the workout of the day for a day unless one was
+// already assigned, and returns the stored assignment.
+func (s *MemoryStore) CreateWODAssignment(ctx context.Context, assignment models.WODAssignment) (*models.WODAssignment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing := s.findWODAssignment(assignment.UserID, assignment.Day); existing != nil {
+		return existing, nil
+	}
+	assignment.ID = s.newID("wod_assignments")
+	assignment.UserID = cloneID(assignment.UserID)
+	assignment.WODID = cloneID(assignment.WODID)
+	assignment.Reasons = append(models.JSONList(nil), assignment.Reasons...)
+	s.wodAssignments = append(s.wodAssignments, assignment)
+	return &assignment, nil
+}
+
+// FetchWODAssignments returns the workouts of the day assigned within a range of days, newest first.
+func (s *MemoryStore) FetchWODAssignments(ctx context.Context, userID *int, from, to time.Time) ([]models.WODAssignment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var assignments []models.WODAssignment
+	for _, assignment := range s.wodAssignments {
+		if sameOwner(assignment.UserID, userID) && !assignment.Day.Before(from) && assignment.Day.Before(to) {
+			assignments = append(assignments, assignment)
+		}
+	}
+	sort.SliceStable(assignments, func(i, j int) bool { return assignments[i].Day.After(assignments[j].Day) })
+	return assignments, nil
+}
+
+// findWODAssignment returns a copy of the assignment for a day, or nil.
+// Callers must hold the lock.
+func (s *MemoryStore) findWODAssignment(userID *int, day time.Time) *models.WODAssignment {
+	for _, assignment := range s.wodAssignments {
+		if sameOwner(assignment.UserID, userID) && assignment.Day.Equal(day) {
+			return &assignment
+		}
+	}
+	return nil
+}
+
+// CreateEnrolment ends the user's active enrolment and stores the new one.
+func (s *MemoryStore) CreateEnrolment(ctx context.Context, enrolment models.Enrolment) (*models.Enrolment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.endEnrolment(enrolment.UserID, enrolment.CreatedAt)
+	enrolment.ID = s.newID("program_enrolments")
+	s.enrolments = append(s.enrolments, enrolment)
+	return &enrolment, nil
+}
+
+// FetchActiveEnrolment returns the program enrolment the user has not ended.
+func (s *MemoryStore) FetchActiveEnrolment(ctx context.Context, userID int) (*models.Enrolment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for i := len(s.enrolments) - 1; i >= 0; i-- {
+		if enrolment := s.enrolments[i]; enrolment.UserID == userID && enrolment.EndedAt == nil {
+			return &enrolment, nil
+		}
+	}
+	return nil, nil
+}
+
+// EndEnrolment ends the user's active program enrolment.
+func (s *MemoryStore) EndEnrolment(ctx context.Context, userID int, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.endEnrolment(userID, at)
+	return nil
+}
+
+// endEnrolment ends the user's active enrolments. Callers must hold the lock.
+func (s *MemoryStore) endEnrolment(userID int, at time.Time) {
+	for i := range s.enrolments {
+		if s.enrolments[i].UserID == userID && s.enrolments[i].EndedAt == nil {
+			ended := at
+			s.enrolments[i].EndedAt = &ended
+		}
+	}
+}
+
+// FetchLastLoggedWeightsWorkout returns the user's most recent weights log of
+// a type with its exercises, or nil if there is none.
+func (s *MemoryStore) FetchLastLoggedWeightsWorkout(ctx context.Context, userID int, workoutType string) (*models.WeightsLog, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var last *models.WeightsLog
+	for i, weightsLog := range s.weightsLogs {
+		if ownedBy(weightsLog.UserID, userID) && strings.EqualFold(weightsLog.WorkoutType, workoutType) && (last == nil || !weightsLog.Date.Before(last.Date)) {
+			last = &s.weightsLogs[i]
+		}
+	}
+	if last == nil {
+		return nil, nil
+	}
+	weightsLog := s.withExercises(*last)
+	return &weightsLog, nil
+}
+
+// FetchPersonalRecords returns every record the user set, oldest first.
+func (s *MemoryStore) FetchPersonalRecords(ctx context.Context, userID int) ([]models.PersonalRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var records []models.PersonalRecord
+	for _, record := range s.records {
+		if record.UserID == userID {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+// AddPersonalRecords saves the new personal records beat returns from the
+// user's history and sets their IDs.
+func (s *MemoryStore) AddPersonalRecords(ctx context.Context, userID int, beat func(history []models.PersonalRecord) []models.PersonalRecord) ([]models.PersonalRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var history []models.PersonalRecord
+	for _, record := range s.records {
+		if record.UserID == userID {
+			history = append(history, record)
+		}
+	}
+	records := beat(history)
+	s.addPersonalRecords(records)
+	return records, nil
+}
+
+// ReplacePersonalRecords deletes every record of the user and saves the given
+// ones, setting their IDs.
+func (s *MemoryStore) ReplacePersonalRecords(ctx context.Context, userID int, records []models.PersonalRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = filter(s.records, func(r models.PersonalRecord) bool { return r.UserID != userID })
+	s.addPersonalRecords(records)
+	return nil
+}
+
+// addPersonalRecords stores records and sets their IDs. Callers must hold the
+// lock.
+func (s *MemoryStore) addPersonalRecords(records []models.PersonalRecord) {
+	for i, record := range records {
+		record.ID = s.newID("personal_records")
+		records[i].ID = record.ID
+		record.WeightsLogID = cloneID(record.WeightsLogID)
+		record.WorkoutID = cloneID(record.WorkoutID)
+		record.Previous = nil
+		s.records = append(s.records, record)
+	}
+}
+
+// CreateUser adds a new user and returns it with its assigned ID.
+func (s *MemoryStore) CreateUser(ctx context.Context, user models.User) (*models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, existing := range s.users {
+		if existing.Username == user.Username {
+			return nil, models.ErrUserExists
+		}
+	}
+	user.ID = s.newID("users")
+	s.users = append(s.users, user)
+	return &user, nil
+}
+
+// FetchUserByUsername returns the user with the given username.
+func (s *MemoryStore) FetchUserByUsername(ctx context.Context, username string) (*models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, user := range s.users {
+		if user.Username == username {
+			return &user, nil
+		}
+	}
+	return nil, nil
+}
+
+// FetchUserByID returns the user with the given ID.
+func (s *MemoryStore) FetchUserByID(ctx context.Context, id int) (*models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, user := range s.users {
+		if user.ID == id {
+			return &user, nil
+		}
+	}
+	return nil, nil
+}
+
+// SetUserRole changes the role of a user.
+func (s *MemoryStore) SetUserRole(ctx context.Context, id int, role string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.users {
+		if s.users[i].ID == id {
+			s.users[i].Role = role
+		}
+	}
+	return nil
+}
+
+// SetUserPassword changes the password hash of a user and deletes their
+// sessions.
+func (s *MemoryStore) SetUserPassword(ctx context.Context, id int, passwordHash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.users {
+		if s.users[i].ID == id {
+			s.users[i].PasswordHash = passwordHash
+		}
+	}
+	for tokenHash, session := range s.sessions {
+		if session.UserID == id {
+			delete(s.sessions, tokenHash)
+		}
+	}
+	return nil
+}
+
+// SetUserUnits changes the unit system of a user.
+func (s *MemoryStore) SetUserUnits(ctx context.Context, id int, units string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.users {
+		if s.users[i].ID == id {
+			s.users[i].Units = units
+		}
+	}
+	return nil
+}
+
+// SetUserTimeZone changes the time zone of a user.
+func (s *MemoryStore) SetUserTimeZone(ctx context.Context, id int, timeZone string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.users {
+		if s.users[i].ID == id {
+			s.users[i].TimeZone = timeZone
+		}
+	}
+	return nil
+}
+
+// CreateSession stores a new session.
+func (s *MemoryStore) CreateSession(ctx context.Context, session models.Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[session.TokenHash] = session
+	return nil
+}
+
+// FetchSession returns the session with the given token hash.
+func (s *MemoryStore) FetchSession(ctx context.Context, tokenHash string) (*models.Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	session, ok := s.sessions[tokenHash]
+	if !ok {
+		return nil, nil
+	}
+	return &session, nil
+}
+
+// DeleteSession deletes the session with the given token hash.
+func (s *MemoryStore) DeleteSession(ctx context.Context, tokenHash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, tokenHash)
+	return nil
+}
+
+// DeleteExpiredSessions deletes the sessions that expired before now.
+func (s *MemoryStore) DeleteExpiredSessions(ctx context.Context, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for tokenHash, session := range s.sessions {
+		if session.ExpiresAt.Before(now) {
+			delete(s.sessions, tokenHash)
+		}
+	}
+	return nil
+}
+
+// AddWorkout adds a new workout
+func (s *MemoryStore) AddWorkout(ctx context.Context, workout models.Workout) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	workout.ID = s.newID("workouts")
+	s.workouts = append(s.workouts, workout)
+	return nil
+}
+
+// UpdateWorkout updates an existing workout
+func (s *MemoryStore) UpdateWorkout(ctx context.Context, workout models.Workout) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.workouts {
+		if s.workouts[i].ID == workout.ID {
+			s.workouts[i] = workout
+			return nil
+		}
+	}
+	return models.ErrNotFound
+}
+
+// DeleteWorkout deletes a workout and the records it set
+func (s *MemoryStore) DeleteWorkout(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := len(s.workouts)
+	s.workouts = filter(s.workouts, func(w models.Workout) bool { return w.ID != id })
+	if len(s.workouts) == n {
+		return models.ErrNotFound
+	}
+	s.records = filter(s.records, func(r models.PersonalRecord) bool { return r.WorkoutID == nil || *r.WorkoutID != id })
+	return nil
+}
+
+// ViewWorkouts returns all workouts
+func (s *MemoryStore) ViewWorkouts(ctx context.Context) ([]models.Workout, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]models.Workout(nil), s.workouts...), nil
+}
+
+// FetchWorkout returns the workout with the given ID, or nil
+func (s *MemoryStore) FetchWorkout(ctx context.Context, id int) (*models.Workout, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, record := range s.workouts {
+		if record.ID == id {
+			return &record, nil
+		}
+	}
+	return nil, nil
+}
+
+// EmptyWorkouts deletes every workout and the records they set
+func (s *MemoryStore) EmptyWorkouts(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.workouts = nil
+	s.records = filter(s.records, func(r models.PersonalRecord) bool { return r.WorkoutID == nil })
+	return nil
+}
+
+// AddWeightsLog adds a new weights log without exercises
+func (s *MemoryStore) AddWeightsLog(ctx context.Context, weightsLog models.WeightsLog) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	weightsLog.ID = s.newID("weights_logs")
+	weightsLog.Exercises = nil
+	s.weightsLogs = append(s.weightsLogs, weightsLog)
+	return nil
+}
+
+// UpdateWeightsLog updates an existing weights log
+func (s *MemoryStore) UpdateWeightsLog(ctx context.Context, weightsLog models.WeightsLog) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.weightsLogs {
+		if s.weightsLogs[i].ID == weightsLog.ID {
+			s.weightsLogs[i].UserID = weightsLog.UserID
+			s.weightsLogs[i].WorkoutType = weightsLog.WorkoutType
+			s.weightsLogs[i].Date = weightsLog.Date
+			return nil
+		}
+	}
+	return models.ErrNotFound
+}
+
+// DeleteWeightsLog deletes a weights log, its exercises and the records it set
+func (s *MemoryStore) DeleteWeightsLog(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := len(s.weightsLogs)
+	s.weightsLogs = filter(s.weightsLogs, func(l models.WeightsLog) bool { return l.ID != id })
+	if len(s.weightsLogs) == n {
+		return models.ErrNotFound
+	}
+	s.exercises = filter(s.exercises, func(e models.Exercise) bool { return e.WeightsLogID != id })
+	s.records = filter(s.records, func(r models.PersonalRecord) bool { return r.WeightsLogID == nil || *r.WeightsLogID != id })
+	return nil
+}
+
+// ViewWeightsLogs returns all weights logs
+func (s *MemoryStore) ViewWeightsLogs(ctx context.Context) ([]models.WeightsLog, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]models.WeightsLog(nil), s.weightsLogs...), nil
+}
+
+// FetchWeightsLog returns the weights log with the given ID, or nil
+func (s *MemoryStore) FetchWeightsLog(ctx context.Context, id int) (*models.WeightsLog, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, record := range s.weightsLogs {
+		if record.ID == id {
+			return &record, nil
+		}
+	}
+	return nil, nil
+}
+
+// EmptyWeightsLogs deletes every weights log, their exercises and the records they set
+func (s *MemoryStore) EmptyWeightsLogs(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	logIDs := make(map[int]bool, len(s.weightsLogs))
+	for _, weightsLog := range s.weightsLogs {
+		logIDs[weightsLog.ID] = true
+	}
+	s.exercises = filter(s.exercises, func(e models.Exercise) bool { return !logIDs[e.WeightsLogID] })
+	s.weightsLogs = nil
+	s.records = filter(s.records, func(r models.PersonalRecord) bool { return r.WeightsLogID == nil })
+	return nil
+}
+
+// insertExercise stores an exercise and its sets with fresh IDs. Callers must
+// hold the lock.
+func (s *MemoryStore) insertExercise(exercise models.Exercise) {
+	exercise.ID = s.newID("exercises")
+	exercise.Sets = s.newSets(exercise.ID, exercise.Sets)
+	s.exercises = append(s.exercises, exercise)
+}
+
+// newSets copies sets for an exercise and assigns them IDs. Callers must hold
+// the lock.
+func (s *MemoryStore) newSets(exerciseID int, sets []models.ExerciseSet) []models.ExerciseSet {
+	stored := make([]models.ExerciseSet, len(sets))
+	for i, set := range sets {
+		set.ID = s.newID("exercise_sets")
+		set.ExerciseID = exerciseID
+		stored[i] = set
+	}
+	return stored
+}
+
+// AddExercise adds a new exercise and its sets
+func (s *MemoryStore) AddExercise(ctx context.Context, exercise models.Exercise) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.insertExercise(exercise)
+	return nil
+}
+
+// UpdateExercise updates an existing exercise and replaces its sets
+func (s *MemoryStore) UpdateExercise(ctx context.Context, exercise models.Exercise) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.exercises {
+		if s.exercises[i].ID == exercise.ID {
+			exercise.Sets = s.newSets(exercise.ID, exercise.Sets)
+			s.exercises[i] = exercise
+			return nil
+		}
+	}
+	return models.ErrNotFound
+}
+
+// DeleteExercise deletes an exercise
+func (s *MemoryStore) DeleteExercise(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := len(s.exercises)
+	s.exercises = filter(s.exercises, func(e models.Exercise) bool { return e.ID != id })
+	if len(s.exercises) == n {
+		return models.ErrNotFound
+	}
+	return nil
+}
+
+// ViewExercises returns all exercises with their sets
+func (s *MemoryStore) ViewExercises(ctx context.Context) ([]models.Exercise, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	exercises := make([]models.Exercise, len(s.exercises))
+	for i, exercise := range s.exercises {
+		exercises[i] = cloneExercise(exercise)
+	}
+	return exercises, nil
+}
+
+// FetchExercise returns the exercise with its sets with the given ID, or nil
+func (s *MemoryStore) FetchExercise(ctx context.Context, id int) (*models.Exercise, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, record := range s.exercises {
+		if record.ID == id {
+			record = cloneExercise(record)
+			return &record, nil
+		}
+	}
+	return nil, nil
+}
+
+// EmptyExercises deletes every exercise
+func (s *MemoryStore) EmptyExercises(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.exercises = nil
+	s.records = filter(s.records, func(r models.PersonalRecord) bool { return r.WeightsLogID == nil })
+	return nil
+}
+
+// AddWOD adds a new WOD
+func (s *MemoryStore) AddWOD(ctx context.Context, wod models.WOD) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	wod.ID = s.newID("wods")
+	s.wods = append(s.wods, wod)
+	return nil
+}
+
+// UpdateWOD updates an existing WOD
+func (s *MemoryStore) UpdateWOD(ctx context.Context, wod models.WOD) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.wods {
+		if s.wods[i].ID == wod.ID {
+			s.wods[i] = wod
+			return nil
+		}
+	}
+	return models.ErrNotFound
+}
+
+// DeleteWOD deletes a WOD and unlinks its assignments
+func (s *MemoryStore) DeleteWOD(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := len(s.wods)
+	s.wods = filter(s.wods, func(w models.WOD) bool { return w.ID != id })
+	if len(s.wods) == n {
+		return models.ErrNotFound
+	}
+	for i := range s.wodAssignments {
+		if s.wodAssignments[i].WODID != nil && *s.wodAssignments[i].WODID == id {
+			s.wodAssignments[i].WODID = nil
+		}
+	}
+	return nil
+}
+
+// ViewWODs returns all WODs
+func (s *MemoryStore) ViewWODs(ctx context.Context) ([]models.WOD, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]models.WOD(nil), s.wods...), nil
+}
+
+// FetchWOD returns the WOD with the given ID, or nil
+func (s *MemoryStore) FetchWOD(ctx context.Context, id int) (*models.WOD, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, record := range s.wods {
+		if record.ID == id {
+			return &record, nil
+		}
+	}
+	return nil, nil
+}
+
+// EmptyWODs deletes every WOD and unlinks all assignments
+func (s *MemoryStore) EmptyWODs(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.wods = nil
+	for i := range s.wodAssignments {
+		s.wodAssignments[i].WODID = nil
+	}
+	return nil
+}
+
+// AddCardioType adds a new cardio type
+func (s *MemoryStore) AddCardioType(ctx context.Context, cardioType models.CardioType) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, existing := range s.cardioTypes {
+		if existing.Name == cardioType.Name {
+			return fmt.Errorf("%w: cardio type %q", models.ErrConflict, cardioType.Name)
+		}
+	}
+	cardioType.ID = s.newID("cardio_types")
+	s.cardioTypes = append(s.cardioTypes, cardioType)
+	return nil
+}
+
+// UpdateCardioType updates an existing cardio type
+func (s *MemoryStore) UpdateCardioType(ctx context.Context, cardioType models.CardioType) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, existing := range s.cardioTypes {
+		if existing.Name == cardioType.Name && existing.ID != cardioType.ID {
+			return fmt.Errorf("%w: cardio type %q", models.ErrConflict, cardioType.Name)
+		}
+	}
+	for i := range s.cardioTypes {
+		if s.cardioTypes[i].ID == cardioType.ID {
+			s.cardioTypes[i] = cardioType
+			return nil
+		}
+	}
+	return models.ErrNotFound
+}
+
+// DeleteCardioType deletes a cardio type and unlinks its workouts
+func (s *MemoryStore) DeleteCardioType(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := len(s.cardioTypes)
+	s.cardioTypes = filter(s.cardioTypes, func(c models.CardioType) bool { return c.ID != id })
+	if len(s.cardioTypes) == n {
+		return models.ErrNotFound
+	}
+	for i := range s.workouts {
+		if s.workouts[i].CardioTypeID != nil && *s.workouts[i].CardioTypeID == id {
+			s.workouts[i].CardioTypeID = nil
+		}
+	}
+	return nil
+}
+
+// ViewCardioTypes returns all cardio types
+func (s *MemoryStore) ViewCardioTypes(ctx context.Context) ([]models.CardioType, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]models.CardioType(nil), s.cardioTypes...), nil
+}
+
+// FetchCardioType returns the cardio type with the given ID, or nil
+func (s *MemoryStore) FetchCardioType(ctx context.Context, id int) (*models.CardioType, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, record := range s.cardioTypes {
+		if record.ID == id {
+			return &record, nil
+		}
+	}
+	return nil, nil
+}
+
+// EmptyCardioTypes deletes every cardio type and unlinks all workouts
+func (s *MemoryStore) EmptyCardioTypes(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cardioTypes = nil
+	for i := range s.workouts {
+		s.workouts[i].CardioTypeID = nil
+	}
+	return nil
+}
+
+// AddProgram adds a new program with its weeks, days and exercises
+func (s *MemoryStore) AddProgram(ctx context.Context, program models.Program) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, existing := range s.programs {
+		if existing.Name == program.Name {
+			return fmt.Errorf("%w: program %q", models.ErrConflict, program.Name)
+		}
+	}
+	s.insertProgram(program)
+	return nil
+}
+
+// insertProgram assigns identifiers to a program and everything it contains
+// and stores it. Callers must hold the lock.
+func (s *MemoryStore) insertProgram(program models.Program) {
+	program.ID = s.newID("programs")
+	s.programs = append(s.programs, s.withProgramIDs(program))
+}
+
+// withProgramIDs returns a copy of a program whose weeks, days and exercises
+// have fresh identifiers. Callers must hold the lock.
+func (s *MemoryStore) withProgramIDs(program models.Program) models.Program {
+	program = cloneProgram(program)
+	for i := range program.Weeks {
+		week := &program.Weeks[i]
+		week.ID = s.newID("program_weeks")
+		week.ProgramID = program.ID
+		for j := range week.Days {
+			day := &week.Days[j]
+			day.ID = s.newID("program_days")
+			day.ProgramWeekID = week.ID
+			for k := range day.Exercises {
+				day.Exercises[k].ID = s.newID("program_exercises")
+				day.Exercises[k].ProgramDayID = day.ID
+			}
+		}
+	}
+	return program
+}
+
+// UpdateProgram updates an existing program and replaces its weeks
+func (s *MemoryStore) UpdateProgram(ctx context.Context, program models.Program) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, existing := range s.programs {
+		if existing.Name == program.Name && existing.ID != program.ID {
+			return fmt.Errorf("%w: program %q", models.ErrConflict, program.Name)
+		}
+	}
+	for i := range s.programs {
+		if s.programs[i].ID == program.ID {
+			program.CreatedAt = s.programs[i].CreatedAt
+			s.programs[i] = s.withProgramIDs(program)
+			return nil
+		}
+	}
+	return models.ErrNotFound
+}
+
+// DeleteProgram deletes a program and its enrolments
+func (s *MemoryStore) DeleteProgram(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := len(s.programs)
+	s.programs = filter(s.programs, func(p models.Program) bool { return p.ID != id })
+	if len(s.programs) == n {
+		return models.ErrNotFound
+	}
+	s.enrolments = filter(s.enrolments, func(e models.Enrolment) bool { return e.ProgramID != id })
+	return nil
+}
+
+// ViewPrograms returns all programs without their weeks
+func (s *MemoryStore) ViewPrograms(ctx context.Context) ([]models.Program, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	programs := make([]models.Program, len(s.programs))
+	for i, program := range s.programs {
+		program.Weeks = nil
+		programs[i] = program
+	}
+	return programs, nil
+}
+
+// FetchProgram returns a program with its weeks, days and exercises, or nil
+func (s *MemoryStore) FetchProgram(ctx context.Context, id int) (*models.Program, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, program := range s.programs {
+		if program.ID == id {
+			program = cloneProgram(program)
+			return &program, nil
+		}
+	}
+	return nil, nil
+}
+
+// EmptyPrograms deletes every program and enrolment
+func (s *MemoryStore) EmptyPrograms(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.programs = nil
+	s.enrolments = nil
+	return nil
+}
+
+// AddWeightWorkout adds a new weight workout
+func (s *MemoryStore) AddWeightWorkout(ctx context.Context, weightWorkout models.WeightWorkout) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	weightWorkout.ID = s.newID("weight_workouts")
+	s.weightWorkouts = append(s.weightWorkouts, weightWorkout)
+	return nil
+}
+
+// UpdateWeightWorkout updates an existing weight workout
+func (s *MemoryStore) UpdateWeightWorkout(ctx context.Context, weightWorkout models.WeightWorkout) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.weightWorkouts {
+		if s.weightWorkouts[i].ID == weightWorkout.ID {
+			s.weightWorkouts[i] = weightWorkout
+			return nil
+		}
+	}
+	return models.ErrNotFound
+}
+
+// DeleteWeightWorkout deletes a weight workout
+func (s *MemoryStore) DeleteWeightWorkout(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := len(s.weightWorkouts)
+	s.weightWorkouts = filter(s.weightWorkouts, func(w models.WeightWorkout) bool { return w.ID != id })
+	if len(s.weightWorkouts) == n {
+		return models.ErrNotFound
+	}
+	return nil
+}
+
+// ViewWeightWorkouts returns all weight workouts
+func (s *MemoryStore) ViewWeightWorkouts(ctx context.Context) ([]models.WeightWorkout, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]models.WeightWorkout(nil), s.weightWorkouts...), nil
+}
+
+// FetchWeightWorkout returns the weight workout with the given ID, or nil
+func (s *MemoryStore) FetchWeightWorkout(ctx context.Context, id int) (*models.WeightWorkout, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, record := range s.weightWorkouts {
+		if record.ID == id {
+			return &record, nil
+		}
+	}
+	return nil, nil
+}
+
+// EmptyWeightWorkouts deletes every weight workout
+func (s *MemoryStore) EmptyWeightWorkouts(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.weightWorkouts = nil
+	return nil
+}
+
+// withExercises returns a copy of a weights log with its exercises attached.
+// Callers must hold the lock.
+func (s *MemoryStore) withExercises(weightsLog models.WeightsLog) models.WeightsLog {
+	weightsLog.Exercises = nil
+	for _, exercise := range s.exercises {
+		if exercise.WeightsLogID == weightsLog.ID {
+			weightsLog.Exercises = append(weightsLog.Exercises, cloneExercise(exercise))
+		}
+	}
+	return weightsLog
+}
+
+// cloneExercise copies an exercise so callers cannot modify the stored sets.
+func cloneExercise(exercise models.Exercise) models.Exercise {
+	exercise.Sets = append([]models.ExerciseSet(nil), exercise.Sets...)
+	return exercise
+}
+
+// cloneProgram copies a program so callers cannot modify the stored weeks.
+func cloneProgram(program models.Program) models.Program {
+	program.Weeks = append([]models.ProgramWeek(nil), program.Weeks...)
+	for i := range program.Weeks {
+		week := &program.Weeks[i]
+		week.Days = append([]models.ProgramDay(nil), week.Days...)
+		for j := range week.Days {
+			week.Days[j].Exercises = append([]models.ProgramExercise(nil), week.Days[j].Exercises...)
+		}
+	}
+	return program
+}
+
+// matchesLog reports whether a logged record passes the owner, date range and
+// type filters of a log query.
+func matchesLog(q models.LogQuery, owner *int, workoutType string, date time.Time) bool {
+	if !ownedBy(owner, q.UserID) {
+		return false
+	}
+	if q.From != nil && date.Before(*q.From) {
+		return false
+	}
+	if q.To != nil && !date.Before(*q.To) {
+		return false
+	}
+	return q.Type == "" || strings.EqualFold(workoutType, q.Type)
+}
+
+// sortNewestFirst orders records by date and then ID, both descending.
+func sortNewestFirst[T any](items []T, key func(T) (time.Time, int)) {
+	sort.SliceStable(items, func(i, j int) bool {
+		di, ii := key(items[i])
+		dj, ij := key(items[j])
+		if !di.Equal(dj) {
+			return di.After(dj)
+		}
+		return ii > ij
+	})
+}
+
+// paginate applies the cursor or offset of a log query to sorted records and
+// keeps one extra record to signal a following page.
+func paginate[T any](items []T, q models.LogQuery, key func(T) (time.Time, int)) []T {
+	start := 0
+	if q.Cursor != nil {
+		start = len(items)
+		for i, item := range items {
+			date, id := key(item)
+			if date.Before(q.Cursor.Date) || (date.Equal(q.Cursor.Date) && id < q.Cursor.ID) {
+				start = i
+				break
+			}
+		}
+	} else if q.Offset < len(items) {
+		start = q.Offset
+	} else {
+		start = len(items)
+	}
+	end := start + q.Limit + 1
+	if end > len(items) {
+		end = len(items)
+	}
+	return items[start:end]
+}
+
+// cloneID copies an optional ID so stored records do not share pointers.
+func cloneID(id *int) *int {
+	if id == nil {
+		return nil
+	}
+	v := *id
+	return &v
+}
+
+// sameOwner reports whether two optional owners are the same user, or both
+// absent.
+func sameOwner(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// ownedBy reports whether a record's owner is the given user.
+func ownedBy(owner *int, userID int) bool {
+	return owner != nil && *owner == userID
+}
+
+// filter returns the items for which keep reports true.
+func filter[T any](items []T, keep func(T) bool) []T {
+	kept := items[:0]
+	for _, item := range items {
+		if keep(item) {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
+
+var _ models.Store = (*MemoryStore)(nil)
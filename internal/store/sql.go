@@ -0,0 +1,1468 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"momentum/internal/models"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+)
+
+// SQLStore implements models.Store on top of Postgres or SQLite. Queries are
+// written with Postgres placeholders and rebound for the connected driver.
+type SQLStore struct {
+	db           *sqlx.DB
+	queryTimeout time.Duration
+}
+
+// NewSQL returns a store backed by the given database connection. Each call
+// to the store is cancelled after queryTimeout, or only with its context when
+// queryTimeout is zero.
+func NewSQL(db *sqlx.DB, queryTimeout time.Duration) *SQLStore {
+	return &SQLStore{db: db, queryTimeout: queryTimeout}
+}
+
+// withDeadline bounds a call to the store by the query timeout.
+func (s *SQLStore) withDeadline(ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.queryTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, s.queryTimeout)
+}
+
+// Ping checks the database connection.
+func (s *SQLStore) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// affectedOne returns models.ErrNotFound when a statement that updates or
+// deletes a record by ID matched no row.
+func affectedOne(result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return models.ErrNotFound
+	}
+	return nil
+}
+
+// uniqueViolation returns models.ErrConflict for an error raised by a unique
+// constraint, and any other error unchanged.
+func uniqueViolation(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return models.ErrConflict
+	}
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+		return models.ErrConflict
+	}
+	return err
+}
+
+// dbTime returns a timestamp in UTC, which every timestamp is written in.
+// SQLite stores timestamps as text with their UTC offset and compares them as
+// text, so stored values and the bounds of a range must all be in UTC to
+// compare correctly. Reads convert to the user's time zone where days matter.
+func dbTime(t time.Time) time.Time {
+	return t.UTC()
+}
+
+// fetchByID loads the row of a table with an ID into dest, reporting whether
+// there is one.
+func (s *SQLStore) fetchByID(ctx context.Context, dest interface{}, table string, id int) (bool, error) {
+	err := s.db.GetContext(ctx, dest, s.db.Rebind("SELECT * FROM "+table+" WHERE id=$1"), id)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// SaveWorkout saves a new workout to the database and returns its ID.
+func (s *SQLStore) SaveWorkout(ctx context.Context, workout models.Workout) (int, error) {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	var id int
+	err := s.db.QueryRowxContext(ctx, s.db.Rebind(`INSERT INTO workouts (user_id, type, cardio_type_id, duration, distance, date) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`),
+		workout.UserID, workout.Type, workout.CardioTypeID, workout.Duration, workout.Distance, dbTime(workout.Date)).Scan(&id)
+	return id, err
+}
+
+// SaveWeightsLog saves a weights log and its exercises in one transaction and
+// returns its ID.
+func (s *SQLStore) SaveWeightsLog(ctx context.Context, weightsLog models.WeightsLog) (int, error) {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	var weightsLogID int
+	err = tx.QueryRowxContext(ctx, s.db.Rebind(`INSERT INTO weights_logs (user_id, workout_type, date) VALUES ($1, $2, $3) RETURNING id`), weightsLog.UserID, weightsLog.WorkoutType, dbTime(weightsLog.Date)).Scan(&weightsLogID)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	for _, exercise := range weightsLog.Exercises {
+		exercise.WeightsLogID = weightsLogID
+		if err := s.insertExercise(ctx, tx, exercise); err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+	}
+	return weightsLogID, tx.Commit()
+}
+
+// insertExercise inserts an exercise and its sets within a transaction.
+func (s *SQLStore) insertExercise(ctx context.Context, tx *sqlx.Tx, exercise models.Exercise) error {
+	var exerciseID int
+	err := tx.QueryRowxContext(ctx, s.db.Rebind(`INSERT INTO exercises (weights_log_id, name) VALUES ($1, $2) RETURNING id`), exercise.WeightsLogID, exercise.Name).Scan(&exerciseID)
+	if err != nil {
+		return err
+	}
+	return insertSets(ctx, tx, exerciseID, exercise.Sets)
+}
+
+// insertSets inserts the sets of an exercise within a transaction.
+func insertSets(ctx context.Context, tx *sqlx.Tx, exerciseID int, sets []models.ExerciseSet) error {
+	for _, set := range sets {
+		set.ExerciseID = exerciseID
+		_, err := tx.NamedExecContext(ctx, `INSERT INTO exercise_sets (exercise_id, set_number, reps, weight, unit, rpe, set_type) VALUES (:exercise_id, :set_number, :reps, :weight, :unit, :rpe, :set_type)`, &set)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// attachExercises loads the exercises and sets of each weights log with one
+// query per table rather than one per log.
+func (s *SQLStore) attachExercises(ctx context.Context, weightsLogs []models.WeightsLog) error {
+	if len(weightsLogs) == 0 {
+		return nil
+	}
+	ids := make([]int, len(weightsLogs))
+	for i, weightsLog := range weightsLogs {
+		ids[i] = weightsLog.ID
+	}
+	query, args, err := sqlx.In("SELECT * FROM exercises WHERE weights_log_id IN (?) ORDER BY weights_log_id, id", ids)
+	if err != nil {
+		return err
+	}
+	var exercises []models.Exercise
+	if err := s.db.SelectContext(ctx, &exercises, s.db.Rebind(query), args...); err != nil {
+		return err
+	}
+	if err := s.attachSets(ctx, exercises); err != nil {
+		return err
+	}
+	byLog := make(map[int][]models.Exercise, len(weightsLogs))
+	for _, exercise := range exercises {
+		byLog[exercise.WeightsLogID] = append(byLog[exercise.WeightsLogID], exercise)
+	}
+	for i := range weightsLogs {
+		weightsLogs[i].Exercises = byLog[weightsLogs[i].ID]
+	}
+	return nil
+}
+
+// attachSets loads the sets of each exercise with a single query.
+func (s *SQLStore) attachSets(ctx context.Context, exercises []models.Exercise) error {
+	if len(exercises) == 0 {
+		return nil
+	}
+	ids := make([]int, len(exercises))
+	for i, exercise := range exercises {
+		ids[i] = exercise.ID
+	}
+	query, args, err := sqlx.In("SELECT * FROM exercise_sets WHERE exercise_id IN (?) ORDER BY exercise_id, set_number", ids)
+	if err != nil {
+		return err
+	}
+	var sets []models.ExerciseSet
+	if err := s.db.SelectContext(ctx, &sets, s.db.Rebind(query), args...); err != nil {
+		return err
+	}
+	byExercise := make(map[int][]models.ExerciseSet, len(exercises))
+	for _, set := range sets {
+		byExercise[set.ExerciseID] = append(byExercise[set.ExerciseID], set)
+	}
+	for i := range exercises {
+		exercises[i].Sets = byExercise[exercises[i].ID]
+	}
+	return nil
+}
+
+// FetchLoggedCardioWorkouts retrieves a page of the user's logged cardio workouts from the database.
+func (s *SQLStore) FetchLoggedCardioWorkouts(ctx context.Context, q models.LogQuery) ([]models.Workout, int, error) {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	// The type filter matches the logged type or the cardio type it resolves to
+	where, args := logFilter(q, "LOWER(type) = LOWER(?)", q.Type)
+	if q.CardioTypeID != nil {
+		where, args = logFilter(q, "(LOWER(type) = LOWER(?) OR cardio_type_id = ?)", q.Type, *q.CardioTypeID)
+	}
+	where += " AND cardio_type_id IS NOT NULL"
+	var total int
+	if err := s.db.GetContext(ctx, &total, s.db.Rebind("SELECT COUNT(*) FROM workouts WHERE "+where), args...); err != nil {
+		return nil, 0, err
+	}
+	query, args := pageQuery("SELECT * FROM workouts WHERE "+where, args, q)
+	var workouts []models.Workout
+	if err := s.db.SelectContext(ctx, &workouts, s.db.Rebind(query), args...); err != nil {
+		return nil, 0, err
+	}
+	return workouts, total, nil
+}
+
+// FetchLoggedWeightsWorkouts retrieves a page of the user's logged weights workouts from the database.
+func (s *SQLStore) FetchLoggedWeightsWorkouts(ctx context.Context, q models.LogQuery) ([]models.WeightsLog, int, error) {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	where, args := logFilter(q, "LOWER(workout_type) = LOWER(?)", q.Type)
+	var total int
+	if err := s.db.GetContext(ctx, &total, s.db.Rebind("SELECT COUNT(*) FROM weights_logs WHERE "+where), args...); err != nil {
+		return nil, 0, err
+	}
+	query, args := pageQuery("SELECT * FROM weights_logs WHERE "+where, args, q)
+	var weightsLogs []models.WeightsLog
+	if err := s.db.SelectContext(ctx, &weightsLogs, s.db.Rebind(query), args...); err != nil {
+		return nil, 0, err
+	}
+	if err := s.attachExercises(ctx, weightsLogs); err != nil {
+		return nil, 0, err
+	}
+	return weightsLogs, total, nil
+}
+
+// logFilter builds the WHERE clause, with ? placeholders, for the owner, date
+// range and type filters of a log query. The placeholders of typeCondition are
+// bound to typeArgs.
+func logFilter(q models.LogQuery, typeCondition string, typeArgs ...interface{}) (string, []interface{}) {
+	conditions := []string{"user_id = ?"}
+	args := []interface{}{q.UserID}
+	if q.From != nil {
+		conditions = append(conditions, "date >= ?")
+		args = append(args, dbTime(*q.From))
+	}
+	if q.To != nil {
+		conditions = append(conditions, "date < ?")
+		args = append(args, dbTime(*q.To))
+	}
+	if q.Type != "" {
+		conditions = append(conditions, typeCondition)
+		args = append(args, typeArgs...)
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
+// pageQuery appends the cursor condition, newest-first ordering and limit to
+// a filtered query. One extra row is requested to detect a following page.
+func pageQuery(query string, args []interface{}, q models.LogQuery) (string, []interface{}) {
+	if q.Cursor != nil {
+		query += " AND (date < ? OR (date = ? AND id < ?))"
+		args = append(args, dbTime(q.Cursor.Date), dbTime(q.Cursor.Date), q.Cursor.ID)
+	}
+	query += " ORDER BY date DESC, id DESC LIMIT ?"
+	args = append(args, q.Limit+1)
+	if q.Cursor == nil && q.Offset > 0 {
+		query += " OFFSET ?"
+		args = append(args, q.Offset)
+	}
+	return query, args
+}
+
+// FetchWeightWorkouts retrieves weight workouts of the given type from the database.
+func (s *SQLStore) FetchWeightWorkouts(ctx context.Context, workoutType string) ([]models.WeightWorkout, error) {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	var weightWorkouts []models.WeightWorkout
+	err := s.db.SelectContext(ctx, &weightWorkouts, s.db.Rebind("SELECT * FROM weight_workouts WHERE workout_type=$1"), workoutType)
+	return weightWorkouts, err
+}
+
+// FetchLastLoggedCardioWorkout retrieves the user's last logged cardio workout from the database.
+func (s *SQLStore) FetchLastLoggedCardioWorkout(ctx context.Context, userID int) (*models.Workout, error) {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	var workout models.Workout
+	err := s.db.GetContext(ctx, &workout, s.db.Rebind("SELECT * FROM workouts WHERE user_id=$1 AND cardio_type_id IS NOT NULL ORDER BY date DESC, id DESC LIMIT 1"), userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &workout, nil
+}
+
+// FetchLastLoggedWeightsWorkout retrieves the user's last logged weights workout of a type with its exercises.
+func (s *SQLStore) FetchLastLoggedWeightsWorkout(ctx context.Context, userID int, workoutType string) (*models.WeightsLog, error) {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	var weightsLog models.WeightsLog
+	err := s.db.GetContext(ctx, &weightsLog, s.db.Rebind("SELECT * FROM weights_logs WHERE user_id=$1 AND LOWER(workout_type) = LOWER($2) ORDER BY date DESC, id DESC LIMIT 1"), userID, workoutType)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	weightsLogs := []models.WeightsLog{weightsLog}
+	if err := s.attachExercises(ctx, weightsLogs); err != nil {
+		return nil, err
+	}
+	return &weightsLogs[0], nil
+}
+
+// FetchWorkoutTypes retrieves the distinct types of all logged workouts.
+func (s *SQLStore) FetchWorkoutTypes(ctx context.Context) ([]string, error) {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	var workoutTypes []string
+	err := s.db.SelectContext(ctx, &workoutTypes, "SELECT DISTINCT type FROM workouts")
+	return workoutTypes, err
+}
+
+// SetWorkoutCardioType sets the cardio type of every workout of a type.
+func (s *SQLStore) SetWorkoutCardioType(ctx context.Context, workoutType string, cardioTypeID *int) error {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	_, err := s.db.ExecContext(ctx, s.db.Rebind("UPDATE workouts SET cardio_type_id=$1 WHERE type=$2"), cardioTypeID, workoutType)
+	return err
+}
+
+// SetWorkoutDate sets the date of a workout and of the personal records it set.
+func (s *SQLStore) SetWorkoutDate(ctx context.Context, id int, date time.Time) error {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := affectedOne(tx.ExecContext(ctx, s.db.Rebind(`UPDATE workouts SET date=$1 WHERE id=$2`), dbTime(date), id)); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, s.db.Rebind(`UPDATE personal_records SET achieved_at=$1 WHERE workout_id=$2`), dbTime(date), id); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// FetchWorkoutsBetween retrieves the user's workouts logged within a time range.
+func (s *SQLStore) FetchWorkoutsBetween(ctx context.Context, userID *int, from, to time.Time) ([]models.Workout, error) {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	var workouts []models.Workout
+	query, args := betweenQuery("workouts", userID, from, to)
+	err := s.db.SelectContext(ctx, &workouts, s.db.Rebind(query), args...)
+	return workouts, err
+}
+
+// betweenQuery selects the logs of a table from from up to but excluding to,
+// oldest first, of one user or of every user when userID is nil.
+func betweenQuery(table string, userID *int, from, to time.Time) (string, []interface{}) {
+	query, args := "SELECT * FROM "+table+" WHERE date >= $1 AND date < $2", []interface{}{dbTime(from), dbTime(to)}
+	if userID != nil {
+		query += " AND user_id=$3"
+		args = append(args, *userID)
+	}
+	return query + " ORDER BY date, id", args
+}
+
+// FetchWeightsLogsBetween retrieves the user's weights logs within a time range with their exercises.
+func (s *SQLStore) FetchWeightsLogsBetween(ctx context.Context, userID *int, from, to time.Time) ([]models.WeightsLog, error) {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	var weightsLogs []models.WeightsLog
+	query, args := betweenQuery("weights_logs", userID, from, to)
+	err := s.db.SelectContext(ctx, &weightsLogs, s.db.Rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.attachExercises(ctx, weightsLogs); err != nil {
+		return nil, err
+	}
+	return weightsLogs, nil
+}
+
+// FetchCardioTotals sums the user's cardio workouts within a time range by
+// period of a time zone and cardio type.
+func (s *SQLStore) FetchCardioTotals(ctx context.Context, userID int, period string, loc *time.Location, from, to time.Time) ([]models.CardioTotal, error) {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	local, zone := s.localTime("w.date", loc, to)
+	query := `SELECT ` + s.periodStart(period, local) + ` AS period, ct.name AS cardio_type, COUNT(*) AS workouts,
+		COALESCE(SUM(w.distance), 0) AS distance, COALESCE(SUM(w.duration), 0) AS duration,
+		COALESCE(SUM(CASE WHEN w.distance > 0 THEN w.duration ELSE 0 END), 0) AS paced_duration
+		FROM workouts w JOIN cardio_types ct ON ct.id = w.cardio_type_id
+		WHERE w.user_id=? AND w.date >= ? AND w.date < ?
+		GROUP BY 1, 2 ORDER BY 1, 2`
+	totals := []models.CardioTotal{}
+	err := s.db.SelectContext(ctx, &totals, s.db.Rebind(query), zone, userID, dbTime(from), dbTime(to))
+	return totals, err
+}
+
+// FetchDailyActivity counts the user's logs within a time range by day of a
+// time zone.
+func (s *SQLStore) FetchDailyActivity(ctx context.Context, userID int, loc *time.Location, from, to time.Time) ([]models.DayActivity, error) {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	local, zone := s.localTime("date", loc, to)
+	day := s.periodStart(models.PeriodDay, local)
+	query := `SELECT day, SUM(cardio_workouts) AS cardio_workouts, SUM(duration) AS duration,
+		SUM(distance) AS distance, SUM(weights_sessions) AS weights_sessions FROM (
+			SELECT ` + day + ` AS day, 1 AS cardio_workouts,
+				COALESCE(duration, 0) AS duration, COALESCE(distance, 0) AS distance, 0 AS weights_sessions
+			FROM workouts WHERE user_id=? AND date >= ? AND date < ?
+			UNION ALL
+			SELECT ` + day + ` AS day, 0, 0, 0, 1
+			FROM weights_logs WHERE user_id=? AND date >= ? AND date < ?
+		) AS activity GROUP BY day ORDER BY day`
+	days := []models.DayActivity{}
+	err := s.db.SelectContext(ctx, &days, s.db.Rebind(query), zone, userID, dbTime(from), dbTime(to), zone, userID, dbTime(from), dbTime(to))
+	return days, err
+}
+
+// FetchCardioBestSplits returns the user's fastest time of each cardio type
+// over each distance within a time range.
+func (s *SQLStore) FetchCardioBestSplits(ctx context.Context, userID int, distances []float64, from, to time.Time) ([]models.CardioSplit, error) {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	splits := []models.CardioSplit{}
+	if len(distances) == 0 {
+		return splits, nil
+	}
+	var args []interface{}
+	values := make([]string, len(distances))
+	for i, distance := range distances {
+		values[i] = "(CAST(? AS FLOAT))"
+		args = append(args, distance)
+	}
+	args = append(args, userID, dbTime(from), dbTime(to))
+	query := `SELECT ct.name AS cardio_type, split.column1 AS distance, MIN(w.duration * split.column1 / w.distance) AS seconds
+		FROM workouts w JOIN cardio_types ct ON ct.id = w.cardio_type_id
+		JOIN (VALUES ` + strings.Join(values, ", ") + `) AS split ON w.distance >= split.column1
+		WHERE w.user_id=? AND w.date >= ? AND w.date < ? AND w.duration > 0
+		GROUP BY ct.name, split.column1 ORDER BY ct.name, split.column1`
+	err := s.db.SelectContext(ctx, &splits, s.db.Rebind(query), args...)
+	return splits, err
+}
+
+// localTime returns the SQL expression, with one ? placeholder, for the
+// wall-clock time of a timestamp column in a time zone, and the argument bound
+// to the placeholder. Postgres applies the rules of the time zone to each
+// timestamp. SQLite has no time zone database, so it shifts every timestamp by
+// the offset the time zone has at the time given, which puts logs across a
+// daylight saving time change an hour off.
+func (s *SQLStore) localTime(column string, loc *time.Location, at time.Time) (string, interface{}) {
+	_, offset := at.In(loc).Zone()
+	shift := fmt.Sprintf("%+d seconds", offset)
+	switch {
+	case s.db.DriverName() == "sqlite3":
+		return "DATETIME(" + column + ", ?)", shift
+	case loc == time.Local:
+		// The server's time zone has no name Postgres knows
+		return "(" + column + " AT TIME ZONE CAST(? AS INTERVAL))", shift
+	}
+	return "(" + column + " AT TIME ZONE ?)", loc.String()
+}
+
+// periodStart returns the SQL expression for the first day, as YYYY-MM-DD, of
+// the day, week or month of a wall-clock time returned by localTime.
+func (s *SQLStore) periodStart(period, local string) string {
+	if s.db.DriverName() == "sqlite3" {
+		switch period {
+		case models.PeriodMonth:
+			return "STRFTIME('%Y-%m-01', " + local + ")"
+		case models.PeriodWeek:
+			return "DATE(" + local + ", '-6 days', 'weekday 1')"
+		}
+		return "DATE(" + local + ")"
+	}
+	return "TO_CHAR(DATE_TRUNC('" + period + "', " + local + "), 'YYYY-MM-DD')"
+}
+
+// FetchWODAssignment retrieves the workout of the day assigned for a day.
+func (s *SQLStore) FetchWODAssignment(ctx context.Context, userID *int, day time.Time) (*models.WODAssignment, error) {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	var assignment models.WODAssignment
+	err := s.db.GetContext(ctx, &assignment, s.db.Rebind("SELECT * FROM wod_assignments WHERE COALESCE(user_id, 0)=$1 AND day=$2"), ownerKey(userID), day)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &assignment, nil
+}
+
+// CreateWODAssignment stores the workout of the day for a day unless one was
+// already assigned, and returns the stored assignment.
+func (s *SQLStore) CreateWODAssignment(ctx context.Context, assignment models.WODAssignment) (*models.WODAssignment, error) {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	assignment.CreatedAt = dbTime(assignment.CreatedAt)
+	_, err := s.db.NamedExecContext(ctx, `INSERT INTO wod_assignments (user_id, day, kind, wod_id, type, duration, distance, reasons, created_at)
+		VALUES (:user_id, :day, :kind, :wod_id, :type, :duration, :distance, :reasons, :created_at) ON CONFLICT DO NOTHING`, &assignment)
+	if err != nil {
+		return nil, err
+	}
+	return s.FetchWODAssignment(ctx, assignment.UserID, assignment.Day)
+}
+
+// FetchWODAssignments retrieves the workouts of the day assigned within a range of days, newest first.
+func (s *SQLStore) FetchWODAssignments(ctx context.Context, userID *int, from, to time.Time) ([]models.WODAssignment, error) {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	var assignments []models.WODAssignment
+	err := s.db.SelectContext(ctx, &assignments, s.db.Rebind("SELECT * FROM wod_assignments WHERE COALESCE(user_id, 0)=$1 AND day >= $2 AND day < $3 ORDER BY day DESC"), ownerKey(userID), from, to)
+	return assignments, err
+}
+
+// ownerKey maps the owner of a WOD assignment to the key used by the unique
+// index on wod_assignments, where shared assignments belong to user 0.
+func ownerKey(userID *int) int {
+	if userID == nil {
+		return 0
+	}
+	return *userID
+}
+
+// CreateEnrolment ends the user's active enrolment and stores the new one in
+// one transaction.
+func (s *SQLStore) CreateEnrolment(ctx context.Context, enrolment models.Enrolment) (*models.Enrolment, error) {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, s.db.Rebind("UPDATE program_enrolments SET ended_at=$1 WHERE user_id=$2 AND ended_at IS NULL"), dbTime(enrolment.CreatedAt), enrolment.UserID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	err = tx.QueryRowxContext(ctx, s.db.Rebind(`INSERT INTO program_enrolments (user_id, program_id, started_on, created_at) VALUES ($1, $2, $3, $4) RETURNING id`),
+		enrolment.UserID, enrolment.ProgramID, enrolment.StartedOn, dbTime(enrolment.CreatedAt)).Scan(&enrolment.ID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &enrolment, nil
+}
+
+// FetchActiveEnrolment retrieves the program enrolment the user has not ended.
+func (s *SQLStore) FetchActiveEnrolment(ctx context.Context, userID int) (*models.Enrolment, error) {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	var enrolment models.Enrolment
+	err := s.db.GetContext(ctx, &enrolment, s.db.Rebind("SELECT * FROM program_enrolments WHERE user_id=$1 AND ended_at IS NULL ORDER BY id DESC LIMIT 1"), userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &enrolment, nil
+}
+
+// EndEnrolment ends the user's active program enrolment.
+func (s *SQLStore) EndEnrolment(ctx context.Context, userID int, at time.Time) error {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	_, err := s.db.ExecContext(ctx, s.db.Rebind("UPDATE program_enrolments SET ended_at=$1 WHERE user_id=$2 AND ended_at IS NULL"), dbTime(at), userID)
+	return err
+}
+
+// FetchPersonalRecords retrieves every record the user set, oldest first.
+func (s *SQLStore) FetchPersonalRecords(ctx context.Context, userID int) ([]models.PersonalRecord, error) {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	var records []models.PersonalRecord
+	err := s.db.SelectContext(ctx, &records, s.db.Rebind("SELECT * FROM personal_records WHERE user_id=$1 ORDER BY id"), userID)
+	return records, err
+}
+
+// AddPersonalRecords saves the new personal records beat returns from the
+// user's history in one transaction and sets their IDs.
+func (s *SQLStore) AddPersonalRecords(ctx context.Context, userID int, beat func(history []models.PersonalRecord) []models.PersonalRecord) ([]models.PersonalRecord, error) {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.lockPersonalRecords(ctx, tx, userID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	var history []models.PersonalRecord
+	if err := tx.SelectContext(ctx, &history, s.db.Rebind("SELECT * FROM personal_records WHERE user_id=$1 ORDER BY id"), userID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	records := beat(history)
+	if err := s.insertPersonalRecords(ctx, tx, records); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	return records, tx.Commit()
+}
+
+// ReplacePersonalRecords deletes every record of the user and saves the given
+// ones in one transaction, setting their IDs.
+func (s *SQLStore) ReplacePersonalRecords(ctx context.Context, userID int, records []models.PersonalRecord) error {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := s.lockPersonalRecords(ctx, tx, userID); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, s.db.Rebind("DELETE FROM personal_records WHERE user_id=$1"), userID); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := s.insertPersonalRecords(ctx, tx, records); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// lockPersonalRecords makes the transactions that read and change the records
+// of a user run one at a time by locking the user's row until the transaction
+// ends. SQLite has no row locks but lets a single transaction write at a
+// time, so a write that changes nothing takes that lock before the records
+// are read.
+func (s *SQLStore) lockPersonalRecords(ctx context.Context, tx *sqlx.Tx, userID int) error {
+	query := "SELECT id FROM users WHERE id=$1 FOR UPDATE"
+	if s.db.DriverName() == "sqlite3" {
+		query = "UPDATE users SET id = id WHERE id=$1"
+	}
+	_, err := tx.ExecContext(ctx, s.db.Rebind(query), userID)
+	return err
+}
+
+// insertPersonalRecords inserts records within a transaction and sets their IDs.
+func (s *SQLStore) insertPersonalRecords(ctx context.Context, tx *sqlx.Tx, records []models.PersonalRecord) error {
+	for i, r := range records {
+		err := tx.QueryRowxContext(ctx, s.db.Rebind(`INSERT INTO personal_records (user_id, kind, exercise, value, weight, reps, weights_log_id, workout_id, achieved_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id`), r.UserID, r.Kind, r.Exercise, r.Value, r.Weight, r.Reps, r.WeightsLogID, r.WorkoutID, dbTime(r.AchievedAt)).Scan(&records[i].ID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateUser inserts a new user and returns it with its assigned ID.
+func (s *SQLStore) CreateUser(ctx context.Context, user models.User) (*models.User, error) {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	err := s.db.QueryRowxContext(ctx, s.db.Rebind(`INSERT INTO users (username, password_hash, role, units, time_zone, created_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`),
+		user.Username, user.PasswordHash, user.Role, user.Units, user.TimeZone, dbTime(user.CreatedAt)).Scan(&user.ID)
+	if err != nil {
+		if uniqueViolation(err) == models.ErrConflict {
+			return nil, models.ErrUserExists
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// FetchUserByUsername retrieves a user by username.
+func (s *SQLStore) FetchUserByUsername(ctx context.Context, username string) (*models.User, error) {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	return s.fetchUser(ctx, "SELECT * FROM users WHERE username=$1", username)
+}
+
+// FetchUserByID retrieves a user by ID.
+func (s *SQLStore) FetchUserByID(ctx context.Context, id int) (*models.User, error) {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	return s.fetchUser(ctx, "SELECT * FROM users WHERE id=$1", id)
+}
+
+// SetUserRole changes the role of a user.
+func (s *SQLStore) SetUserRole(ctx context.Context, id int, role string) error {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	_, err := s.db.ExecContext(ctx, s.db.Rebind("UPDATE users SET role=$1 WHERE id=$2"), role, id)
+	return err
+}
+
+// SetUserPassword changes the password hash of a user and deletes their
+// sessions in one transaction.
+func (s *SQLStore) SetUserPassword(ctx context.Context, id int, passwordHash string) error {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, s.db.Rebind("UPDATE users SET password_hash=$1 WHERE id=$2"), passwordHash, id); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, s.db.Rebind("DELETE FROM sessions WHERE user_id=$1"), id); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// SetUserUnits changes the unit system of a user.
+func (s *SQLStore) SetUserUnits(ctx context.Context, id int, units string) error {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	_, err := s.db.ExecContext(ctx, s.db.Rebind("UPDATE users SET units=$1 WHERE id=$2"), units, id)
+	return err
+}
+
+// SetUserTimeZone changes the time zone of a user.
+func (s *SQLStore) SetUserTimeZone(ctx context.Context, id int, timeZone string) error {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	_, err := s.db.ExecContext(ctx, s.db.Rebind("UPDATE users SET time_zone=$1 WHERE id=$2"), timeZone, id)
+	return err
+}
+
+func (s *SQLStore) fetchUser(ctx context.Context, query string, arg interface{}) (*models.User, error) {
+	var user models.User
+	if err := s.db.GetContext(ctx, &user, s.db.Rebind(query), arg); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// CreateSession stores a new session.
+func (s *SQLStore) CreateSession(ctx context.Context, session models.Session) error {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	session.ExpiresAt = dbTime(session.ExpiresAt)
+	_, err := s.db.NamedExecContext(ctx, `INSERT INTO sessions (token_hash, user_id, expires_at) VALUES (:token_hash, :user_id, :expires_at)`, &session)
+	return err
+}
+
+// FetchSession retrieves a session by token hash.
+func (s *SQLStore) FetchSession(ctx context.Context, tokenHash string) (*models.Session, error) {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	var session models.Session
+	if err := s.db.GetContext(ctx, &session, s.db.Rebind("SELECT * FROM sessions WHERE token_hash=$1"), tokenHash); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+// DeleteSession deletes a session by token hash.
+func (s *SQLStore) DeleteSession(ctx context.Context, tokenHash string) error {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	_, err := s.db.ExecContext(ctx, s.db.Rebind("DELETE FROM sessions WHERE token_hash=$1"), tokenHash)
+	return err
+}
+
+// DeleteExpiredSessions deletes the sessions that expired before now.
+func (s *SQLStore) DeleteExpiredSessions(ctx context.Context, now time.Time) error {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	_, err := s.db.ExecContext(ctx, s.db.Rebind("DELETE FROM sessions WHERE expires_at < $1"), dbTime(now))
+	return err
+}
+
+// AddWorkout adds a new workout to the database
+func (s *SQLStore) AddWorkout(ctx context.Context, workout models.Workout) error {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	workout.Date = dbTime(workout.Date)
+	_, err := s.db.NamedExecContext(ctx, `INSERT INTO workouts (user_id, type, cardio_type_id, duration, distance, date) VALUES (:user_id, :type, :cardio_type_id, :duration, :distance, :date)`, &workout)
+	return err
+}
+
+// UpdateWorkout updates an existing workout in the database
+func (s *SQLStore) UpdateWorkout(ctx context.Context, workout models.Workout) error {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	workout.Date = dbTime(workout.Date)
+	return affectedOne(s.db.NamedExecContext(ctx, `UPDATE workouts SET user_id=:user_id, type=:type, cardio_type_id=:cardio_type_id, duration=:duration, distance=:distance, date=:date WHERE id=:id`, &workout))
+}
+
+// DeleteWorkout deletes a workout and the records it set from the database
+func (s *SQLStore) DeleteWorkout(ctx context.Context, id int) error {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, s.db.Rebind(`DELETE FROM personal_records WHERE workout_id=$1`), id); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := affectedOne(tx.ExecContext(ctx, s.db.Rebind(`DELETE FROM workouts WHERE id=$1`), id)); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// ViewWorkouts retrieves all workouts from the database
+func (s *SQLStore) ViewWorkouts(ctx context.Context) ([]models.Workout, error) {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	var workouts []models.Workout
+	err := s.db.SelectContext(ctx, &workouts, "SELECT * FROM workouts")
+	return workouts, err
+}
+
+// FetchWorkout retrieves a workout by ID, or nil if there is none
+func (s *SQLStore) FetchWorkout(ctx context.Context, id int) (*models.Workout, error) {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	var record models.Workout
+	found, err := s.fetchByID(ctx, &record, "workouts", id)
+	if !found {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// EmptyWorkouts deletes every workout and the records they set from the database
+func (s *SQLStore) EmptyWorkouts(ctx context.Context) error {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM personal_records WHERE workout_id IS NOT NULL"); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM workouts"); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// AddWeightsLog adds a new weights log to the database
+func (s *SQLStore) AddWeightsLog(ctx context.Context, weightsLog models.WeightsLog) error {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	weightsLog.Date = dbTime(weightsLog.Date)
+	_, err := s.db.NamedExecContext(ctx, `INSERT INTO weights_logs (user_id, workout_type, date) VALUES (:user_id, :workout_type, :date)`, &weightsLog)
+	return err
+}
+
+// UpdateWeightsLog updates an existing weights log in the database
+func (s *SQLStore) UpdateWeightsLog(ctx context.Context, weightsLog models.WeightsLog) error {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	weightsLog.Date = dbTime(weightsLog.Date)
+	return affectedOne(s.db.NamedExecContext(ctx, `UPDATE weights_logs SET user_id=:user_id, workout_type=:workout_type, date=:date WHERE id=:id`, &weightsLog))
+}
+
+// DeleteWeightsLog deletes a weights log, its exercises and the records it set from the database
+func (s *SQLStore) DeleteWeightsLog(ctx context.Context, id int) error {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, s.db.Rebind("DELETE FROM personal_records WHERE weights_log_id = $1"), id); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, s.db.Rebind("DELETE FROM exercise_sets WHERE exercise_id IN (SELECT id FROM exercises WHERE weights_log_id = $1)"), id); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, s.db.Rebind("DELETE FROM exercises WHERE weights_log_id = $1"), id); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := affectedOne(tx.ExecContext(ctx, s.db.Rebind("DELETE FROM weights_logs WHERE id = $1"), id)); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// ViewWeightsLogs retrieves all weights logs from the database
+func (s *SQLStore) ViewWeightsLogs(ctx context.Context) ([]models.WeightsLog, error) {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	var weightsLogs []models.WeightsLog
+	err := s.db.SelectContext(ctx, &weightsLogs, "SELECT * FROM weights_logs")
+	return weightsLogs, err
+}
+
+// FetchWeightsLog retrieves a weights log without its exercises by ID, or nil if there is none
+func (s *SQLStore) FetchWeightsLog(ctx context.Context, id int) (*models.WeightsLog, error) {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	var record models.WeightsLog
+	found, err := s.fetchByID(ctx, &record, "weights_logs", id)
+	if !found {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// EmptyWeightsLogs deletes every weights log, their exercises and the records they set from the database
+func (s *SQLStore) EmptyWeightsLogs(ctx context.Context) error {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM personal_records WHERE weights_log_id IS NOT NULL"); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM exercise_sets WHERE exercise_id IN (SELECT id FROM exercises WHERE weights_log_id IN (SELECT id FROM weights_logs))"); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM exercises WHERE weights_log_id IN (SELECT id FROM weights_logs)"); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM weights_logs"); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// AddExercise adds a new exercise and its sets to the database
+func (s *SQLStore) AddExercise(ctx context.Context, exercise models.Exercise) error {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := s.insertExercise(ctx, tx, exercise); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// UpdateExercise updates an existing exercise in the database and replaces its sets
+func (s *SQLStore) UpdateExercise(ctx context.Context, exercise models.Exercise) error {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := affectedOne(tx.NamedExecContext(ctx, `UPDATE exercises SET weights_log_id=:weights_log_id, name=:name WHERE id=:id`, &exercise)); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, s.db.Rebind(`DELETE FROM exercise_sets WHERE exercise_id=$1`), exercise.ID); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := insertSets(ctx, tx, exercise.ID, exercise.Sets); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteExercise deletes an exercise and its sets from the database
+func (s *SQLStore) DeleteExercise(ctx context.Context, id int) error {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, s.db.Rebind(`DELETE FROM exercise_sets WHERE exercise_id=$1`), id); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := affectedOne(tx.ExecContext(ctx, s.db.Rebind(`DELETE FROM exercises WHERE id=$1`), id)); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// ViewExercises retrieves all exercises and their sets from the database
+func (s *SQLStore) ViewExercises(ctx context.Context) ([]models.Exercise, error) {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	var exercises []models.Exercise
+	if err := s.db.SelectContext(ctx, &exercises, "SELECT * FROM exercises"); err != nil {
+		return nil, err
+	}
+	if err := s.attachSets(ctx, exercises); err != nil {
+		return nil, err
+	}
+	return exercises, nil
+}
+
+// FetchExercise retrieves an exercise and its sets by ID, or nil if there is none
+func (s *SQLStore) FetchExercise(ctx context.Context, id int) (*models.Exercise, error) {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	exercises := make([]models.Exercise, 1)
+	found, err := s.fetchByID(ctx, &exercises[0], "exercises", id)
+	if !found {
+		return nil, err
+	}
+	if err := s.attachSets(ctx, exercises); err != nil {
+		return nil, err
+	}
+	return &exercises[0], nil
+}
+
+// EmptyExercises deletes every exercise and set from the database
+func (s *SQLStore) EmptyExercises(ctx context.Context) error {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM personal_records WHERE weights_log_id IS NOT NULL"); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM exercise_sets"); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM exercises"); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// AddWOD adds a new WOD to the database
+func (s *SQLStore) AddWOD(ctx context.Context, wod models.WOD) error {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	wod.Date = dbTime(wod.Date)
+	_, err := s.db.NamedExecContext(ctx, `INSERT INTO wods (type, duration, distance, date) VALUES (:type, :duration, :distance, :date)`, &wod)
+	return err
+}
+
+// UpdateWOD updates an existing WOD in the database
+func (s *SQLStore) UpdateWOD(ctx context.Context, wod models.WOD) error {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	wod.Date = dbTime(wod.Date)
+	return affectedOne(s.db.NamedExecContext(ctx, `UPDATE wods SET type=:type, duration=:duration, distance=:distance, date=:date WHERE id=:id`, &wod))
+}
+
+// DeleteWOD deletes a WOD from the database and unlinks its assignments
+func (s *SQLStore) DeleteWOD(ctx context.Context, id int) error {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, s.db.Rebind(`UPDATE wod_assignments SET wod_id=NULL WHERE wod_id=$1`), id); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := affectedOne(tx.ExecContext(ctx, s.db.Rebind(`DELETE FROM wods WHERE id=$1`), id)); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// ViewWODs retrieves all WODs from the database
+func (s *SQLStore) ViewWODs(ctx context.Context) ([]models.WOD, error) {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	var wods []models.WOD
+	err := s.db.SelectContext(ctx, &wods, "SELECT * FROM wods")
+	return wods, err
+}
+
+// FetchWOD retrieves a WOD by ID, or nil if there is none
+func (s *SQLStore) FetchWOD(ctx context.Context, id int) (*models.WOD, error) {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	var record models.WOD
+	found, err := s.fetchByID(ctx, &record, "wods", id)
+	if !found {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// EmptyWODs deletes every WOD from the database and unlinks all assignments
+func (s *SQLStore) EmptyWODs(ctx context.Context) error {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE wod_assignments SET wod_id=NULL`); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM wods`); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// AddCardioType adds a new cardio type to the database
+func (s *SQLStore) AddCardioType(ctx context.Context, cardioType models.CardioType) error {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	_, err := s.db.NamedExecContext(ctx, `INSERT INTO cardio_types (name, aliases, category) VALUES (:name, :aliases, :category)`, &cardioType)
+	return uniqueViolation(err)
+}
+
+// UpdateCardioType updates an existing cardio type in the database
+func (s *SQLStore) UpdateCardioType(ctx context.Context, cardioType models.CardioType) error {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	return uniqueViolation(affectedOne(s.db.NamedExecContext(ctx, `UPDATE cardio_types SET name=:name, aliases=:aliases, category=:category WHERE id=:id`, &cardioType)))
+}
+
+// DeleteCardioType deletes a cardio type from the database and unlinks its workouts
+func (s *SQLStore) DeleteCardioType(ctx context.Context, id int) error {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, s.db.Rebind(`UPDATE workouts SET cardio_type_id=NULL WHERE cardio_type_id=$1`), id); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := affectedOne(tx.ExecContext(ctx, s.db.Rebind(`DELETE FROM cardio_types WHERE id=$1`), id)); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// ViewCardioTypes retrieves all cardio types from the database
+func (s *SQLStore) ViewCardioTypes(ctx context.Context) ([]models.CardioType, error) {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	var cardioTypes []models.CardioType
+	err := s.db.SelectContext(ctx, &cardioTypes, "SELECT * FROM cardio_types ORDER BY id")
+	return cardioTypes, err
+}
+
+// FetchCardioType retrieves a cardio type by ID, or nil if there is none
+func (s *SQLStore) FetchCardioType(ctx context.Context, id int) (*models.CardioType, error) {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	var record models.CardioType
+	found, err := s.fetchByID(ctx, &record, "cardio_types", id)
+	if !found {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// EmptyCardioTypes deletes every cardio type from the database and unlinks all workouts
+func (s *SQLStore) EmptyCardioTypes(ctx context.Context) error {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE workouts SET cardio_type_id=NULL`); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM cardio_types`); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// AddProgram adds a new program with its weeks, days and exercises to the database
+func (s *SQLStore) AddProgram(ctx context.Context, program models.Program) error {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	var programID int
+	err = tx.QueryRowxContext(ctx, s.db.Rebind(`INSERT INTO programs (name, description, days_per_week, created_at) VALUES ($1, $2, $3, $4) RETURNING id`),
+		program.Name, program.Description, program.DaysPerWeek, dbTime(program.CreatedAt)).Scan(&programID)
+	if err != nil {
+		tx.Rollback()
+		return uniqueViolation(err)
+	}
+	if err := s.insertProgramWeeks(ctx, tx, programID, program.Weeks); err != nil {
+		tx.Rollback()
+		return uniqueViolation(err)
+	}
+	return uniqueViolation(tx.Commit())
+}
+
+// UpdateProgram updates an existing program in the database and replaces its weeks
+func (s *SQLStore) UpdateProgram(ctx context.Context, program models.Program) error {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := affectedOne(tx.NamedExecContext(ctx, `UPDATE programs SET name=:name, description=:description, days_per_week=:days_per_week WHERE id=:id`, &program)); err != nil {
+		tx.Rollback()
+		return uniqueViolation(err)
+	}
+	if err := deleteProgramWeeks(ctx, tx, "program_id = $1", program.ID); err != nil {
+		tx.Rollback()
+		return uniqueViolation(err)
+	}
+	if err := s.insertProgramWeeks(ctx, tx, program.ID, program.Weeks); err != nil {
+		tx.Rollback()
+		return uniqueViolation(err)
+	}
+	return uniqueViolation(tx.Commit())
+}
+
+// insertProgramWeeks inserts the weeks of a program with their days and
+// exercises within a transaction.
+func (s *SQLStore) insertProgramWeeks(ctx context.Context, tx *sqlx.Tx, programID int, weeks []models.ProgramWeek) error {
+	for _, week := range weeks {
+		var weekID int
+		err := tx.QueryRowxContext(ctx, s.db.Rebind(`INSERT INTO program_weeks (program_id, week_number, focus) VALUES ($1, $2, $3) RETURNING id`), programID, week.WeekNumber, week.Focus).Scan(&weekID)
+		if err != nil {
+			return err
+		}
+		for _, day := range week.Days {
+			var dayID int
+			err := tx.QueryRowxContext(ctx, s.db.Rebind(`INSERT INTO program_days (program_week_id, day_number, workout_type) VALUES ($1, $2, $3) RETURNING id`), weekID, day.DayNumber, day.WorkoutType).Scan(&dayID)
+			if err != nil {
+				return err
+			}
+			for _, exercise := range day.Exercises {
+				exercise.ProgramDayID = dayID
+				_, err := tx.NamedExecContext(ctx, `INSERT INTO program_exercises (program_day_id, position, name, sets, reps, percent_1rm) VALUES (:program_day_id, :position, :name, :sets, :reps, :percent_1rm)`, &exercise)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// deleteProgramWeeks deletes the weeks, days and exercises of the programs
+// matching a condition on program_weeks within a transaction. Rows are
+// deleted explicitly since SQLite does not enforce foreign keys by default.
+func deleteProgramWeeks(ctx context.Context, tx *sqlx.Tx, condition string, args ...interface{}) error {
+	weeks := "SELECT id FROM program_weeks WHERE " + condition
+	days := "SELECT id FROM program_days WHERE program_week_id IN (" + weeks + ")"
+	for _, query := range []string{
+		"DELETE FROM program_exercises WHERE program_day_id IN (" + days + ")",
+		"DELETE FROM program_days WHERE program_week_id IN (" + weeks + ")",
+		"DELETE FROM program_weeks WHERE " + condition,
+	} {
+		if _, err := tx.ExecContext(ctx, tx.Rebind(query), args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteProgram deletes a program, its weeks and its enrolments from the database
+func (s *SQLStore) DeleteProgram(ctx context.Context, id int) error {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := deleteProgramWeeks(ctx, tx, "program_id = $1", id); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, s.db.Rebind(`DELETE FROM program_enrolments WHERE program_id=$1`), id); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := affectedOne(tx.ExecContext(ctx, s.db.Rebind(`DELETE FROM programs WHERE id=$1`), id)); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// ViewPrograms retrieves all programs from the database without their weeks
+func (s *SQLStore) ViewPrograms(ctx context.Context) ([]models.Program, error) {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	var programs []models.Program
+	err := s.db.SelectContext(ctx, &programs, "SELECT * FROM programs ORDER BY id")
+	return programs, err
+}
+
+// FetchProgram retrieves a program with its weeks, days and exercises, loading
+// each level with a single query.
+func (s *SQLStore) FetchProgram(ctx context.Context, id int) (*models.Program, error) {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	var program models.Program
+	if err := s.db.GetContext(ctx, &program, s.db.Rebind("SELECT * FROM programs WHERE id=$1"), id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if err := s.db.SelectContext(ctx, &program.Weeks, s.db.Rebind("SELECT * FROM program_weeks WHERE program_id=$1 ORDER BY week_number"), id); err != nil {
+		return nil, err
+	}
+	var days []models.ProgramDay
+	err := s.db.SelectContext(ctx, &days, s.db.Rebind(`SELECT d.* FROM program_days d JOIN program_weeks w ON w.id = d.program_week_id
+		WHERE w.program_id=$1 ORDER BY d.program_week_id, d.day_number`), id)
+	if err != nil {
+		return nil, err
+	}
+	var exercises []models.ProgramExercise
+	err = s.db.SelectContext(ctx, &exercises, s.db.Rebind(`SELECT e.* FROM program_exercises e JOIN program_days d ON d.id = e.program_day_id
+		JOIN program_weeks w ON w.id = d.program_week_id WHERE w.program_id=$1 ORDER BY e.program_day_id, e.position`), id)
+	if err != nil {
+		return nil, err
+	}
+	byDay := make(map[int][]models.ProgramExercise, len(days))
+	for _, exercise := range exercises {
+		byDay[exercise.ProgramDayID] = append(byDay[exercise.ProgramDayID], exercise)
+	}
+	byWeek := make(map[int][]models.ProgramDay, len(program.Weeks))
+	for _, day := range days {
+		day.Exercises = byDay[day.ID]
+		byWeek[day.ProgramWeekID] = append(byWeek[day.ProgramWeekID], day)
+	}
+	for i := range program.Weeks {
+		program.Weeks[i].Days = byWeek[program.Weeks[i].ID]
+	}
+	return &program, nil
+}
+
+// EmptyPrograms deletes every program and enrolment from the database
+func (s *SQLStore) EmptyPrograms(ctx context.Context) error {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := deleteProgramWeeks(ctx, tx, "1 = 1"); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM program_enrolments`); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM programs`); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// AddWeightWorkout adds a new weight workout to the database
+func (s *SQLStore) AddWeightWorkout(ctx context.Context, weightWorkout models.WeightWorkout) error {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	_, err := s.db.NamedExecContext(ctx, `INSERT INTO weight_workouts (workout_type, exercise, muscle_group) VALUES (:workout_type, :exercise, :muscle_group)`, &weightWorkout)
+	return err
+}
+
+// UpdateWeightWorkout updates an existing weight workout in the database
+func (s *SQLStore) UpdateWeightWorkout(ctx context.Context, weightWorkout models.WeightWorkout) error {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	return affectedOne(s.db.NamedExecContext(ctx, `UPDATE weight_workouts SET workout_type=:workout_type, exercise=:exercise, muscle_group=:muscle_group WHERE id=:id`, &weightWorkout))
+}
+
+// DeleteWeightWorkout deletes a weight workout from the database
+func (s *SQLStore) DeleteWeightWorkout(ctx context.Context, id int) error {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	return affectedOne(s.db.ExecContext(ctx, s.db.Rebind(`DELETE FROM weight_workouts WHERE id=$1`), id))
+}
+
+// ViewWeightWorkouts retrieves all weight workouts from the database
+func (s *SQLStore) ViewWeightWorkouts(ctx context.Context) ([]models.WeightWorkout, error) {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	var weightWorkouts []models.WeightWorkout
+	err := s.db.SelectContext(ctx, &weightWorkouts, "SELECT * FROM weight_workouts")
+	return weightWorkouts, err
+}
+
+// FetchWeightWorkout retrieves a weight workout by ID, or nil if there is none
+func (s *SQLStore) FetchWeightWorkout(ctx context.Context, id int) (*models.WeightWorkout, error) {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	var record models.WeightWorkout
+	found, err := s.fetchByID(ctx, &record, "weight_workouts", id)
+	if !found {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// EmptyWeightWorkouts deletes every weight workout from the database
+func (s *SQLStore) EmptyWeightWorkouts(ctx context.Context) error {
+	ctx, cancel := s.withDeadline(ctx)
+	defer cancel()
+	_, err := s.db.ExecContext(ctx, "DELETE FROM weight_workouts")
+	return err
+}
+
+var _ models.Store = (*SQLStore)(nil)
@@ -0,0 +1,23 @@
+package store
+
+import (
+	"momentum/internal/config"
+	"momentum/internal/database"
+	"momentum/internal/models"
+)
+
+// Memory selects the in-memory store. The other kinds are the database kinds
+// understood by the database package.
+const Memory = config.StoreMemory
+
+// Open returns the store for the given kind, connecting to and migrating the
+// database first for the SQL-backed kinds.
+func Open(kind string, cfg config.Database) (models.Store, error) {
+	if kind == Memory {
+		return NewMemory(), nil
+	}
+	if err := database.InitDB(kind, cfg); err != nil {
+		return nil, err
+	}
+	return NewSQL(database.DB, cfg.QueryTimeout), nil
+}
@@ -0,0 +1,386 @@
+package store_test
+
+import (
+	"context"
+	"momentum/internal/migrations"
+	"momentum/internal/models"
+	"momentum/internal/store"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// backends opens the memory store and a migrated in-memory SQLite database.
+var backends = []struct {
+	name string
+	open func(t *testing.T) models.Store
+}{
+	{name: "memory", open: func(t *testing.T) models.Store { return store.NewMemory() }},
+	{name: "sqlite", open: func(t *testing.T) models.Store {
+		db, err := sqlx.Connect("sqlite3", "file::memory:?_foreign_keys=1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		db.SetMaxOpenConns(1)
+		t.Cleanup(func() { db.Close() })
+		if err := migrations.Up(db); err != nil {
+			t.Fatal(err)
+		}
+		return store.NewSQL(db, 0)
+	}},
+}
+
+// useStore sets a store as the store of the models package for the rest of
+// the test.
+func useStore(t *testing.T, s models.Store) {
+	models.SetStore(s)
+	t.Cleanup(func() { models.SetStore(nil) })
+}
+
+// eachStore runs a test against each backend with a new user.
+func eachStore(t *testing.T, test func(t *testing.T, userID int)) {
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			useStore(t, backend.open(t))
+			user, err := models.CreateUser(context.Background(), "ada", "hash", models.RoleUser)
+			if err != nil {
+				t.Fatal(err)
+			}
+			test(t, user.ID)
+		})
+	}
+}
+
+// anHourAgo is the time of day of the logs, so logs of the same day share a
+// date.
+var anHourAgo = time.Now().Add(-time.Hour).Truncate(time.Second)
+
+// daysAgo returns the time the given number of days before anHourAgo.
+func daysAgo(days int) *time.Time {
+	t := anHourAgo.AddDate(0, 0, -days)
+	return &t
+}
+
+// bench returns a weights log of one bench press exercise with a set for each
+// rep count.
+func bench(performed *time.Time, weight float64, reps ...int) models.WeightsLog {
+	exercise := models.Exercise{Name: "Bench"}
+	for _, r := range reps {
+		exercise.Sets = append(exercise.Sets, models.ExerciseSet{Reps: r, Weight: weight, Unit: models.UnitKg})
+	}
+	return models.WeightsLog{WorkoutType: "push", PerformedAt: performed, Exercises: []models.Exercise{exercise}}
+}
+
+func TestCursorPagination(t *testing.T) {
+	eachStore(t, func(t *testing.T, userID int) {
+		ctx := context.Background()
+		// Two pairs of logs share a date, so pages must break ties by ID.
+		// Logs come out newest first, the last saved first on the same date.
+		var want []int
+		for _, days := range []int{9, 7, 7, 5, 3, 3, 1} {
+			result, err := models.SaveWeightsLog(ctx, userID, models.UnitsMetric, bench(daysAgo(days), 60, 8))
+			if err != nil {
+				t.Fatal(err)
+			}
+			want = append([]int{result.ID}, want...)
+		}
+
+		for _, limit := range []int{1, 2, 3, 7, 10} {
+			var got []int
+			q := models.LogQuery{UserID: userID, Limit: limit}
+			for pages := 0; ; pages++ {
+				if pages > len(want) {
+					t.Fatalf("limit %d: pagination does not end", limit)
+				}
+				page, err := models.FetchLoggedWeightsWorkouts(ctx, q)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if page.Total != len(want) {
+					t.Errorf("limit %d: total %d, want %d", limit, page.Total, len(want))
+				}
+				for _, weightsLog := range page.Items {
+					got = append(got, weightsLog.ID)
+				}
+				if page.NextCursor == "" {
+					break
+				}
+				if q.Cursor, err = models.DecodeCursor(page.NextCursor); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if !slices.Equal(got, want) {
+				t.Errorf("limit %d: got IDs %v, want %v", limit, got, want)
+			}
+		}
+	})
+}
+
+func TestCardioTypeFilter(t *testing.T) {
+	eachStore(t, func(t *testing.T, userID int) {
+		ctx := context.Background()
+		for i, workoutType := range []string{"Run", "jog", "Bike", "Treadmill"} {
+			workout := models.Workout{Type: workoutType, Duration: 1800, Distance: 5, PerformedAt: daysAgo(i + 1)}
+			if _, err := models.SaveWorkout(ctx, userID, models.UnitsMetric, workout); err != nil {
+				t.Fatal(err)
+			}
+		}
+		tests := []struct {
+			filter string
+			want   int
+		}{
+			{filter: "run", want: 3},
+			{filter: "running", want: 3},
+			{filter: "JOG", want: 3},
+			{filter: "cycling", want: 1},
+			{filter: "swim", want: 0},
+			{filter: "", want: 4},
+		}
+		for _, tt := range tests {
+			page, err := models.FetchLoggedCardioWorkouts(ctx, models.LogQuery{UserID: userID, Type: tt.filter})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if page.Total != tt.want || len(page.Items) != tt.want {
+				t.Errorf("type %q: %d workouts of %d, want %d", tt.filter, len(page.Items), page.Total, tt.want)
+			}
+		}
+	})
+}
+
+func TestPersonalRecords(t *testing.T) {
+	logs := []struct {
+		name    string
+		weights *models.WeightsLog
+		cardio  *models.Workout
+		want    []string
+	}{
+		{
+			name:    "first log sets every weights record",
+			weights: ptr(bench(daysAgo(10), 100, 5, 5)),
+			want:    []string{models.RecordHeaviestSet, models.RecordBestE1RM, models.RecordMostReps},
+		},
+		{
+			name:    "repeating a session sets none",
+			weights: ptr(bench(daysAgo(9), 100, 5)),
+		},
+		{
+			name:    "an extra rep beats the estimate and the reps at that weight",
+			weights: ptr(bench(daysAgo(8), 100, 6)),
+			want:    []string{models.RecordBestE1RM, models.RecordMostReps},
+		},
+		{
+			name:    "a heavier single sets the heaviest set and reps at the new weight",
+			weights: ptr(bench(daysAgo(7), 105, 1)),
+			want:    []string{models.RecordHeaviestSet, models.RecordMostReps},
+		},
+		{
+			name:    "225 lb is lighter than 105 kg",
+			weights: ptr(models.WeightsLog{WorkoutType: "push", PerformedAt: daysAgo(6), Exercises: []models.Exercise{{Name: "bench", Sets: []models.ExerciseSet{{Reps: 1, Weight: 225, Unit: models.UnitLb}}}}}),
+			want:    []string{models.RecordMostReps},
+		},
+		{
+			name:    "warm-up sets do not count",
+			weights: ptr(models.WeightsLog{WorkoutType: "push", PerformedAt: daysAgo(5), Exercises: []models.Exercise{{Name: "Bench", Sets: []models.ExerciseSet{{Reps: 1, Weight: 200, Unit: models.UnitKg, SetType: models.SetTypeWarmup}}}}}),
+		},
+		{
+			name:   "a run of 5 km sets the fastest 5 km",
+			cardio: &models.Workout{Type: "Run", Duration: 1500, Distance: 5, PerformedAt: daysAgo(4)},
+			want:   []string{models.RecordFastest5K},
+		},
+		{
+			name:   "a faster pace over 10 km beats it",
+			cardio: &models.Workout{Type: "jogging", Duration: 2800, Distance: 10, PerformedAt: daysAgo(3)},
+			want:   []string{models.RecordFastest5K},
+		},
+		{
+			name:   "runs shorter than 5 km do not count",
+			cardio: &models.Workout{Type: "Run", Duration: 600, Distance: 4, PerformedAt: daysAgo(2)},
+		},
+		{
+			name:   "rides set the longest ride",
+			cardio: &models.Workout{Type: "cycling", Duration: 3600, Distance: 25, PerformedAt: daysAgo(1)},
+			want:   []string{models.RecordLongestRide},
+		},
+	}
+	eachStore(t, func(t *testing.T, userID int) {
+		ctx := context.Background()
+		var ids []int
+		for _, l := range logs {
+			var result *models.LogResult
+			var err error
+			if l.weights != nil {
+				result, err = models.SaveWeightsLog(ctx, userID, models.UnitsMetric, *l.weights)
+			} else {
+				result, err = models.SaveWorkout(ctx, userID, models.UnitsMetric, *l.cardio)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			ids = append(ids, result.ID)
+			var got []string
+			for _, r := range result.Records {
+				got = append(got, r.Kind)
+			}
+			if !slices.Equal(got, l.want) || result.PersonalRecord != (len(l.want) > 0) {
+				t.Errorf("%s: got records %v, want %v", l.name, got, l.want)
+			}
+		}
+
+		// Deleting the log of the best estimate through the admin API
+		// restores the one it beat
+		if err := models.DeleteWeightsLog(ctx, ids[2]); err != nil {
+			t.Fatal(err)
+		}
+		records, err := models.FetchPersonalRecords(ctx, userID, models.RecordBestE1RM, "bench", false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(records) != 1 || records[0].Value != 116.7 || *records[0].WeightsLogID != ids[0] {
+			t.Errorf("best estimate after the delete: %+v, want 116.7 kg from log %d", records, ids[0])
+		}
+	})
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestRepairWorkoutDates(t *testing.T) {
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := backend.open(t)
+			useStore(t, s)
+			var users []*models.User
+			for _, name := range []string{"ada", "grace", "linus"} {
+				user, err := models.CreateUser(ctx, name, "hash", models.RoleUser)
+				if err != nil {
+					t.Fatal(err)
+				}
+				users = append(users, user)
+			}
+			// Each user's undated workouts take the dates of their own
+			// workouts, never of another user's logged in between
+			workouts := []struct {
+				user *models.User
+				date *time.Time
+			}{
+				{users[0], daysAgo(5)},
+				{users[1], nil},
+				{users[1], daysAgo(2)},
+				{users[0], nil},
+				{users[2], nil},
+			}
+			for _, w := range workouts {
+				workout := models.Workout{UserID: &w.user.ID, Type: "Run", Duration: 1800, Distance: 5}
+				if w.date != nil {
+					workout.Date = *w.date
+				}
+				if err := s.AddWorkout(ctx, workout); err != nil {
+					t.Fatal(err)
+				}
+			}
+			all, err := s.ViewWorkouts(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(all) != len(workouts) {
+				t.Fatalf("%d workouts stored, want %d", len(all), len(workouts))
+			}
+			// Workouts are numbered from 1 in the order they were added
+			want := map[int]time.Time{2: *daysAgo(2), 4: *daysAgo(5), 5: users[2].CreatedAt}
+
+			for _, dryRun := range []bool{true, false} {
+				repairs, err := models.RepairWorkoutDates(ctx, dryRun)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(repairs) != len(want) {
+					t.Fatalf("dry run %t: %d repairs, want %d", dryRun, len(repairs), len(want))
+				}
+				for _, repair := range repairs {
+					if !repair.Date.Equal(want[repair.WorkoutID]) {
+						t.Errorf("dry run %t: workout %d dated %s, want %s", dryRun, repair.WorkoutID, repair.Date, want[repair.WorkoutID])
+					}
+				}
+			}
+			for id, date := range want {
+				workout, err := models.FetchWorkout(ctx, id)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !workout.Date.Equal(date) {
+					t.Errorf("workout %d stored with date %s, want %s", id, workout.Date, date)
+				}
+			}
+		})
+	}
+}
+
+func TestConcurrentPersonalRecords(t *testing.T) {
+	eachStore(t, func(t *testing.T, userID int) {
+		ctx := context.Background()
+		const logs = 8
+		var wg sync.WaitGroup
+		errs := make(chan error, logs)
+		for range logs {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := models.SaveWeightsLog(ctx, userID, models.UnitsMetric, bench(daysAgo(1), 100, 5))
+				errs <- err
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		// Only the first of the identical logs sets records
+		records, err := models.FetchPersonalRecords(ctx, userID, "", "", true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(records) != 3 {
+			t.Errorf("%d records set by %d identical logs, want 3", len(records), logs)
+		}
+	})
+}
+
+func TestLastLoggedWeightsWorkout(t *testing.T) {
+	eachStore(t, func(t *testing.T, userID int) {
+		ctx := context.Background()
+		// The last two logs share a date, so the last saved of them wins
+		var want int
+		for _, workoutType := range []string{"push", "Push", "PUSH"} {
+			weightsLog := bench(daysAgo(1), 60, 8)
+			weightsLog.WorkoutType = workoutType
+			result, err := models.SaveWeightsLog(ctx, userID, models.UnitsMetric, weightsLog)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want = result.ID
+		}
+		if _, err := models.SaveWeightsLog(ctx, userID, models.UnitsMetric, bench(daysAgo(2), 60, 8)); err != nil {
+			t.Fatal(err)
+		}
+
+		last, err := models.FetchLastLoggedWeightsWorkout(ctx, userID, "push")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if last == nil || last.ID != want {
+			t.Fatalf("got %+v, want the log with ID %d", last, want)
+		}
+		if last, err = models.FetchLastLoggedWeightsWorkout(ctx, userID, "pull"); err != nil || last != nil {
+			t.Errorf("got %+v, %v for a type never logged, want nil", last, err)
+		}
+	})
+}
@@ -19,12 +19,63 @@ document.addEventListener('DOMContentLoaded', function() {
             const tableName = formData.get('table-name');
             const operation = formData.get('operation');
             const data = Object.fromEntries(formData.entries());
+            delete data['table-name'];
+            delete data.operation;
+
+            // Updates are sent as a patch, so fields left empty keep their current values
+            if (operation === 'update') {
+                Object.keys(data).forEach(key => {
+                    if (data[key] === '') {
+                        delete data[key];
+                    }
+                });
+            }
 
             // Ensure the id field is sent as an integer for delete and update operations
             if (operation === 'delete' || operation === 'update') {
                 data.id = parseInt(data.id, 10);
             }
 
+            // Ensure the user_id field is sent as an integer
+            if (data.user_id) {
+                data.user_id = parseInt(data.user_id, 10);
+            }
+
+            // Send exercise sets as an array rather than raw JSON text
+            if (tableName === 'exercises' && (operation === 'add' || operation === 'update')) {
+                try {
+                    if (data.sets || operation === 'add') {
+                        data.sets = data.sets ? JSON.parse(data.sets) : [];
+                    }
+                } catch (error) {
+                    alert(`Sets must be valid JSON: ${error.message}`);
+                    return;
+                }
+                if (data.weights_log_id) {
+                    data.weights_log_id = parseInt(data.weights_log_id, 10);
+                }
+            }
+
+            // Send cardio type aliases as an array of names
+            if (tableName === 'cardio_types' && (operation === 'add' || data.aliases !== undefined)) {
+                data.aliases = (data.aliases || '').split(',').map(alias => alias.trim()).filter(alias => alias);
+            }
+
+            // Send program weeks as nested objects; leaving them empty on update keeps the current weeks
+            if (tableName === 'programs' && (operation === 'add' || operation === 'update')) {
+                try {
+                    if (data.weeks || operation === 'add') {
+                        data.weeks = data.weeks ? JSON.parse(data.weeks) : [];
+                    }
+                } catch (error) {
+                    alert(`Weeks must be valid JSON: ${error.message}`);
+                    return;
+                }
+                if (data.days_per_week) {
+                    data.days_per_week = parseInt(data.days_per_week, 10);
+                }
+            }
+
             // Ensure the duration field is sent as an integer
             if (data.duration) {
                 data.duration = parseInt(data.duration, 10);
@@ -41,12 +92,19 @@ document.addEventListener('DOMContentLoaded', function() {
                 data.date = date.toISOString();
             }
 
-            fetch(`/admin/${operation}/${tableName}`, {
-                method: 'POST',
+            // Records are added to the table and patched or deleted by ID
+            const request = {
+                add: { url: `/admin/${tableName}`, method: 'POST' },
+                update: { url: `/admin/${tableName}/${data.id}`, method: 'PATCH' },
+                delete: { url: `/admin/${tableName}/${data.id}`, method: 'DELETE' }
+            }[operation];
+
+            fetch(request.url, {
+                method: request.method,
                 headers: {
                     'Content-Type': 'application/json'
                 },
-                body: JSON.stringify(data)
+                body: operation === 'delete' ? undefined : JSON.stringify(data)
             }).then(response => {
                 if (response.ok) {
                     console.log(`${operation} operation on ${tableName} table was successful!`);
@@ -58,8 +116,10 @@ document.addEventListener('DOMContentLoaded', function() {
                     fetchLoggedCardioWorkouts();
                     fetchLoggedWeightsWorkouts();
                 } else {
-                    console.error(`Failed to ${operation} ${tableName}.`);
-                    alert(`Failed to ${operation} ${tableName}.`);
+                    errorMessage(response).then(message => {
+                        console.error(`Failed to ${operation} ${tableName}: ${message}`);
+                        alert(`Failed to ${operation} ${tableName}: ${message}`);
+                    });
                 }
             }).catch(error => {
                 console.error(`Error performing ${operation} on ${tableName}:`, error);
@@ -70,8 +130,8 @@ document.addEventListener('DOMContentLoaded', function() {
         emptyTableButton.addEventListener('click', function() {
             const tableName = tableNameSelect.value;
             if (confirm(`Are you sure you want to empty the ${tableName} table?`)) {
-                fetch(`/admin/empty/${tableName}`, {
-                    method: 'POST',
+                fetch(`/admin/${tableName}`, {
+                    method: 'DELETE',
                     headers: {
                         'Content-Type': 'application/json'
                     }
@@ -103,6 +163,8 @@ document.addEventListener('DOMContentLoaded', function() {
                 if (tableName === 'workouts') {
                     fieldsContainer.innerHTML = `
                         ${operation === 'update' ? '<label for="id">ID:</label><input type="number" id="id" name="id" required>' : ''}
+                        <label for="user_id">User ID:</label>
+                        <input type="number" id="user_id" name="user_id" required>
                         <label for="type">Type:</label>
                         <input type="text" id="type" name="type" required>
                         <label for="duration">Duration (minutes):</label>
@@ -115,6 +177,8 @@ document.addEventListener('DOMContentLoaded', function() {
                 } else if (tableName === 'weights_logs') {
                     fieldsContainer.innerHTML = `
                         ${operation === 'update' ? '<label for="id">ID:</label><input type="number" id="id" name="id" required>' : ''}
+                        <label for="user_id">User ID:</label>
+                        <input type="number" id="user_id" name="user_id" required>
                         <label for="workout_type">Workout Type:</label>
                         <input type="text" id="workout_type" name="workout_type" required>
                         <label for="date">Date:</label>
@@ -127,12 +191,8 @@ document.addEventListener('DOMContentLoaded', function() {
                         <input type="number" id="weights_log_id" name="weights_log_id" required>
                         <label for="name">Name:</label>
                         <input type="text" id="name" name="name" required>
-                        <label for="set1">Set 1:</label>
-                        <input type="number" id="set1" name="set1" required>
-                        <label for="set2">Set 2:</label>
-                        <input type="number" id="set2" name="set2" required>
-                        <label for="set3">Set 3:</label>
-                        <input type="number" id="set3" name="set3" required>
+                        <label for="sets">Sets (JSON):</label>
+                        <textarea id="sets" name="sets" rows="4" placeholder='[{"reps": 10, "weight": 40, "unit": "kg", "set_type": "working"}]'></textarea>
                     `;
                 } else if (tableName === 'wods') {
                     fieldsContainer.innerHTML = `
@@ -146,6 +206,28 @@ document.addEventListener('DOMContentLoaded', function() {
                         <label for="date">Date:</label>
                         <input type="datetime-local" id="date" name="date" required>
                     `;
+                } else if (tableName === 'cardio_types') {
+                    fieldsContainer.innerHTML = `
+                        ${operation === 'update' ? '<label for="id">ID:</label><input type="number" id="id" name="id" required>' : ''}
+                        <label for="name">Name:</label>
+                        <input type="text" id="name" name="name" required>
+                        <label for="aliases">Aliases (comma separated):</label>
+                        <input type="text" id="aliases" name="aliases" placeholder="jog, jogging">
+                        <label for="category">Category:</label>
+                        <input type="text" id="category" name="category" required>
+                    `;
+                } else if (tableName === 'programs') {
+                    fieldsContainer.innerHTML = `
+                        ${operation === 'update' ? '<label for="id">ID:</label><input type="number" id="id" name="id" required>' : ''}
+                        <label for="name">Name:</label>
+                        <input type="text" id="name" name="name" required>
+                        <label for="description">Description:</label>
+                        <input type="text" id="description" name="description">
+                        <label for="days_per_week">Days per Week:</label>
+                        <input type="number" id="days_per_week" name="days_per_week" min="1" max="7" required>
+                        <label for="weeks">Weeks (JSON${operation === 'update' ? ', leave empty to keep' : ''}):</label>
+                        <textarea id="weeks" name="weeks" rows="6" placeholder='[{"focus": "Volume", "days": [{"day_number": 1, "workout_type": "push", "exercises": [{"name": "Incline Smith", "sets": 4, "reps": 10, "percent_1rm": 65}]}]}]'></textarea>
+                    `;
                 } else if (tableName === 'weight_workouts') {
                     fieldsContainer.innerHTML = `
                         ${operation === 'update' ? '<label for="id">ID:</label><input type="number" id="id" name="id" required>' : ''}
@@ -153,8 +235,18 @@ document.addEventListener('DOMContentLoaded', function() {
                         <input type="text" id="workout_type" name="workout_type" required>
                         <label for="exercise">Exercise:</label>
                         <input type="text" id="exercise" name="exercise" required>
+                        <label for="muscle_group">Muscle Group:</label>
+                        <input type="text" id="muscle_group" name="muscle_group" placeholder="e.g. chest">
                     `;
                 }
+                // Fields left empty on update keep their current values, so only the ID is required
+                if (operation === 'update') {
+                    fieldsContainer.querySelectorAll('[required]').forEach(field => {
+                        if (field.name !== 'id') {
+                            field.required = false;
+                        }
+                    });
+                }
             } else if (operation === 'delete') {
                 fieldsContainer.innerHTML = `
                     <label for="id">ID:</label>
@@ -165,14 +257,20 @@ document.addEventListener('DOMContentLoaded', function() {
 
         function fetchTableData() {
             const tableName = tableNameSelect.value;
-            fetch(`/admin/view/${tableName}`)
-                .then(response => response.json())
+            fetch(`/admin/${tableName}`)
+                .then(response => {
+                    if (response.status === 403) {
+                        throw new Error('Admin access required');
+                    }
+                    return response.json();
+                })
                 .then(data => {
                     console.log(`Fetched data for ${tableName}:`, data);
                     viewResults.innerHTML = generateTableHTML(data);
                 })
                 .catch(error => {
                     console.error(`Error fetching data for ${tableName}:`, error);
+                    viewResults.innerHTML = `<p>${error.message}</p>`;
                 });
         }
 
@@ -204,6 +302,8 @@ document.addEventListener('DOMContentLoaded', function() {
                         const durationMinutes = Math.floor(item[header] / 60);
                         const durationSeconds = item[header] % 60;
                         td.textContent = `${durationMinutes}m ${durationSeconds}s`;
+                    } else if (typeof item[header] === 'object' && item[header] !== null) {
+                        td.textContent = JSON.stringify(item[header]);
                     } else {
                         td.textContent = item[header];
                     }
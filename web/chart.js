@@ -1,39 +1,40 @@
 document.addEventListener('DOMContentLoaded', function() {
     fetchLoggedCardioWorkouts();
+    fetchDailyActivity();
 });
 
-function fetchLoggedCardioWorkouts() {
-    console.log('Fetching logged cardio workouts...');
-    fetch('/workout/logs/cardio')
+// fetchDailyActivity charts the distance covered on each day. The server
+// groups the workouts into the days of the user's time zone.
+function fetchDailyActivity() {
+    fetch('/analytics/days')
         .then(response => {
             if (!response.ok) {
                 throw new Error('Network response was not ok');
             }
             return response.json();
         })
-        .then(data => {
-            console.log('Fetched logged cardio workouts:', data);
-            visualizeCardioWorkouts(data);
+        .then(report => {
+            visualizeDailyDistance(report);
         })
         .catch(error => {
-            console.error('Error fetching logged cardio workouts:', error);
+            console.error('Error fetching daily activity:', error);
         });
 }
 
-function visualizeCardioWorkouts(data) {
-    const dates = data.map(workout => new Date(workout.date).toLocaleDateString());
-    const distances = data.map(workout => workout.distance);
-    const types = data.map(workout => workout.type);
-
-    // Cardio Trend Chart
+function visualizeDailyDistance(report) {
+    const streak = document.getElementById('streak');
+    if (streak) {
+        streak.textContent = `Current streak: ${report.current_streak} days (longest in the last ${report.days.length} days: ${report.longest_streak})`;
+    }
+    const unit = report.distance_unit;
     const ctxTrend = document.getElementById('cardioTrendChart').getContext('2d');
     new Chart(ctxTrend, {
         type: 'line',
         data: {
-            labels: dates,
+            labels: report.days.map(day => day.day),
             datasets: [{
-                label: 'Distance (kms)',
-                data: distances,
+                label: `Distance (${unit})`,
+                data: report.days.map(day => day.distance),
                 borderColor: 'rgba(75, 192, 192, 1)',
                 backgroundColor: 'rgba(75, 192, 192, 0.2)',
                 fill: true,
@@ -50,12 +51,36 @@ function visualizeCardioWorkouts(data) {
                 y: {
                     title: {
                         display: true,
-                        text: 'Distance (kms)'
+                        text: `Distance (${unit})`
                     }
                 }
             }
         }
     });
+}
+
+function fetchLoggedCardioWorkouts() {
+    console.log('Fetching logged cardio workouts...');
+    fetch('/workout/logs/cardio?limit=500')
+        .then(response => {
+            if (!response.ok) {
+                throw new Error('Network response was not ok');
+            }
+            return response.json();
+        })
+        .then(data => {
+            console.log('Fetched logged cardio workouts:', data);
+            visualizeCardioWorkouts(data.items);
+        })
+        .catch(error => {
+            console.error('Error fetching logged cardio workouts:', error);
+        });
+}
+
+function visualizeCardioWorkouts(data) {
+    const distances = data.map(workout => workout.distance);
+    const types = data.map(workout => workout.type);
+    const unit = data.length > 0 ? data[0].distance_unit : 'km';
 
     // Total Distance Chart
     const totalDistanceByType = types.reduce((acc, type, index) => {
@@ -69,7 +94,7 @@ function visualizeCardioWorkouts(data) {
         data: {
             labels: Object.keys(totalDistanceByType),
             datasets: [{
-                label: 'Total Distance (kms)',
+                label: `Total Distance (${unit})`,
                 data: Object.values(totalDistanceByType),
                 backgroundColor: 'rgba(153, 102, 255, 0.2)',
                 borderColor: 'rgba(153, 102, 255, 1)',
@@ -87,7 +112,7 @@ function visualizeCardioWorkouts(data) {
                 y: {
                     title: {
                         display: true,
-                        text: 'Total Distance (kms)'
+                        text: `Total Distance (${unit})`
                     }
                 }
             }
@@ -101,7 +126,7 @@ function visualizeCardioWorkouts(data) {
         data: {
             labels: Object.keys(totalDistanceByType),
             datasets: [{
-                label: 'Distance Covered by Type (kms)',
+                label: `Distance Covered by Type (${unit})`,
                 data: Object.values(totalDistanceByType),
                 backgroundColor: [
                     'rgba(255, 99, 132, 0.2)',
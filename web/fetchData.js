@@ -11,14 +11,14 @@ function fetchLoggedCardioWorkouts() {
             console.log('Fetched logged cardio workouts:', data);
             const tableBody = document.getElementById('cardio-workouts-table').querySelector('tbody');
             tableBody.innerHTML = '';
-            data.forEach(workout => {
+            data.items.forEach(workout => {
                 const durationMinutes = Math.floor(workout.duration / 60);
                 const durationSeconds = workout.duration % 60;
                 const row = document.createElement('tr');
                 row.innerHTML = `
                     <td>${workout.type}</td>
                     <td>${durationMinutes}m ${durationSeconds}s</td>
-                    <td>${workout.distance}</td>
+                    <td>${workout.distance} ${workout.distance_unit}</td>
                     <td>${new Date(workout.date).toLocaleString()}</td>
                 `;
                 tableBody.appendChild(row);
@@ -42,10 +42,14 @@ function fetchLoggedWeightsWorkouts() {
             console.log('Fetched logged weights workouts:', data);
             const tableBody = document.getElementById('weights-workouts-table').querySelector('tbody');
             tableBody.innerHTML = '';
-            data.forEach(workout => {
+            data.items.forEach(workout => {
                 const row = document.createElement('tr');
+                const exercises = (workout.exercises || [])
+                    .map(exercise => `${exercise.name}: ${formatSets(exercise.sets)}`)
+                    .join('<br>');
                 row.innerHTML = `
                     <td>${workout.workout_type}</td>
+                    <td>${exercises || '-'}</td>
                     <td>${new Date(workout.date).toLocaleString()}</td>
                 `;
                 tableBody.appendChild(row);
@@ -56,6 +60,36 @@ function fetchLoggedWeightsWorkouts() {
         });
 }
 
+function fetchWODHistory() {
+    console.log('Fetching workout of the day history...');
+    fetch('/workout/wod/history')
+        .then(response => {
+            if (!response.ok) {
+                throw new Error('Network response was not ok');
+            }
+            return response.json();
+        })
+        .then(data => {
+            console.log('Fetched workout of the day history:', data);
+            const tableBody = document.getElementById('wod-history-table').querySelector('tbody');
+            tableBody.innerHTML = '';
+            data.forEach(assignment => {
+                const row = document.createElement('tr');
+                row.innerHTML = `
+                    <td>${assignment.day.slice(0, 10)}</td>
+                    <td>${assignment.type}</td>
+                    <td>${assignment.duration}</td>
+                    <td>${assignment.distance} ${assignment.distance_unit}</td>
+                    <td>${assignment.completed ? 'Yes' : 'No'}</td>
+                `;
+                tableBody.appendChild(row);
+            });
+        })
+        .catch(error => {
+            console.error('Error fetching workout of the day history:', error);
+        });
+}
+
 function fetchLastLoggedWorkouts() {
     fetchLastLoggedWorkout('push', 'push-workout-table');
     fetchLastLoggedWorkout('pull', 'pull-workout-table');
@@ -81,22 +115,48 @@ function fetchLastLoggedWorkout(workoutType, tableId) {
                         const row = document.createElement('tr');
                         row.innerHTML = `
                             <td>${exercise.name}</td>
-                            <td>${exercise.set1}</td>
-                            <td>${exercise.set2}</td>
-                            <td>${exercise.set3}</td>
+                            <td>${formatSets(exercise.sets)}</td>
                             <td>${new Date(weightsLog.date).toLocaleString()}</td>
                         `;
                         tableBody.appendChild(row);
                     });
                 } else {
                     console.log(`No exercises found for ${workoutType} workout.`);
-                    tableBody.innerHTML = '<tr><td colspan="5">No exercises found</td></tr>';
+                    tableBody.innerHTML = '<tr><td colspan="3">No exercises found</td></tr>';
                 }
             } else {
-                tableBody.innerHTML = '<tr><td colspan="5">No workout found</td></tr>';
+                tableBody.innerHTML = '<tr><td colspan="3">No workout found</td></tr>';
             }
         })
         .catch(error => {
             console.error(`Error fetching last logged ${workoutType} workout:`, error);
         });
-}
\ No newline at end of file
+}
+
+// formatSets renders an exercise's sets as "reps x weight unit", marking
+// warm-up and drop sets.
+function formatSets(sets) {
+    if (!sets || sets.length === 0) {
+        return '-';
+    }
+    return sets.map(set => {
+        const label = `${set.reps} x ${set.weight}${set.unit}`;
+        return set.set_type === 'working' ? label : `${label} (${set.set_type})`;
+    }).join(', ');
+}
+
+// announceRecords tells the user about the personal records set by a log.
+function announceRecords(result) {
+    if (!result || !result.personal_record) {
+        return;
+    }
+    const labels = {
+        heaviest_set: record => `heaviest ${record.exercise}: ${record.value} ${record.unit}`,
+        best_e1rm: record => `best estimated 1RM on ${record.exercise}: ${record.value} ${record.unit}`,
+        most_reps: record => `most reps on ${record.exercise} at ${record.weight}: ${record.value}`,
+        fastest_5k: record => `fastest 5 km: ${Math.floor(record.value / 60)}m ${record.value % 60}s`,
+        longest_ride: record => `longest ride: ${record.value} ${record.unit}`
+    };
+    const lines = result.records.map(record => (labels[record.kind] || (r => `${r.kind}: ${r.value}`))(record));
+    alert(`New personal record!\n${lines.join('\n')}`);
+}
@@ -2,6 +2,43 @@ document.addEventListener('DOMContentLoaded', function() {
     const getWorkoutButton = document.getElementById('get-workout');
     const workoutDisplay = document.getElementById('workout-display');
 
+    const unitsSelect = document.getElementById('units');
+    withUnits(units => {
+        unitsSelect.value = units;
+    });
+    unitsSelect.addEventListener('change', function() {
+        setUnits(unitsSelect.value).then(response => {
+            if (!response.ok) {
+                console.error('Failed to save unit preference.');
+                return;
+            }
+            location.reload();
+        });
+    });
+
+    // The browser's own time zone is offered first, then every zone it knows
+    const timeZoneSelect = document.getElementById('time-zone');
+    const deviceTimeZone = Intl.DateTimeFormat().resolvedOptions().timeZone;
+    const timeZones = Intl.supportedValuesOf ? Intl.supportedValuesOf('timeZone') : [];
+    [deviceTimeZone, ...timeZones.filter(timeZone => timeZone !== deviceTimeZone)].forEach(timeZone => {
+        timeZoneSelect.add(new Option(timeZone, timeZone));
+    });
+    withTimeZone(timeZone => {
+        if (timeZone && !Array.from(timeZoneSelect.options).some(option => option.value === timeZone)) {
+            timeZoneSelect.add(new Option(timeZone, timeZone));
+        }
+        timeZoneSelect.value = timeZone;
+    });
+    timeZoneSelect.addEventListener('change', function() {
+        setTimeZone(timeZoneSelect.value).then(response => {
+            if (!response.ok) {
+                errorMessage(response).then(message => alert(`Failed to save time zone: ${message}`));
+                return;
+            }
+            location.reload();
+        });
+    });
+
     getWorkoutButton.addEventListener('click', function() {
         fetch('/workout/today')
             .then(response => {
@@ -11,10 +48,20 @@ document.addEventListener('DOMContentLoaded', function() {
                 return response.json();
             })
             .then(workout => {
+                if (workout.kind === 'program') {
+                    workoutDisplay.innerHTML = programSessionHTML(workout);
+                    return;
+                }
+                const details = workout.kind === 'weights'
+                    ? `<p>Exercises: ${(workout.exercises || []).join(', ')}</p>`
+                    : `<p>Duration: ${workout.duration} minutes</p>
+                       <p>Distance: ${workout.distance} ${workout.distance_unit}</p>`;
+                const reasons = (workout.reasons || []).map(reason => `<li>${reason}</li>`).join('');
                 workoutDisplay.innerHTML = `
                     <p>Type: ${workout.type}</p>
-                    <p>Duration: ${workout.duration} minutes</p>
-                    <p>Distance: ${workout.distance} kms</p>
+                    ${details}
+                    <p>${workout.completed ? 'Completed today' : 'Not completed yet'}</p>
+                    ${reasons ? `<p>Why this workout:</p><ul>${reasons}</ul>` : ''}
                 `;
             })
             .catch(error => {
@@ -23,6 +70,24 @@ document.addEventListener('DOMContentLoaded', function() {
             });
     });
 
+    // programSessionHTML describes the session prescribed by the user's program
+    function programSessionHTML(session) {
+        const heading = `<p>${session.program_name}: week ${session.week}, day ${session.day_number} (${session.focus})</p>`;
+        if (session.rest) {
+            return `${heading}<p>Rest day</p>`;
+        }
+        const exercises = (session.exercises || []).map(exercise => {
+            let load = '';
+            if (exercise.target_weight) {
+                load = ` @ ${exercise.target_weight} ${exercise.unit} (${exercise.percent_1rm}% 1RM)`;
+            } else if (exercise.percent_1rm) {
+                load = ` @ ${exercise.percent_1rm}% 1RM`;
+            }
+            return `<li>${exercise.name}: ${exercise.sets} x ${exercise.reps}${load}</li>`;
+        }).join('');
+        return `${heading}<p>Type: ${session.workout_type}</p><ul>${exercises}</ul>`;
+    }
+
      // Fetch logged cardio workouts and visualize them on page load
      fetchLoggedCardioWorkouts();
 });
\ No newline at end of file
@@ -1,5 +1,8 @@
 document.addEventListener('DOMContentLoaded', function() {
     if (document.getElementById('cardio-workout-log-form')) {
+        withUnits(units => {
+            document.getElementById('distance-unit').value = units === 'imperial' ? 'mi' : 'km';
+        });
         document.getElementById('cardio-workout-log-form').addEventListener('submit', function(event) {
             event.preventDefault();
             const formData = new FormData(event.target);
@@ -9,7 +12,9 @@ document.addEventListener('DOMContentLoaded', function() {
             const workout = {
                 type: formData.get('exercise-type'),
                 duration: duration,
-                distance: parseFloat(formData.get('distance'))
+                distance: parseFloat(formData.get('distance')),
+                distance_unit: formData.get('distance-unit'),
+                performed_at: performedAt(formData.get('performed-at'))
             };
             fetch('/workout/log/cardio', {
                 method: 'POST',
@@ -20,10 +25,14 @@ document.addEventListener('DOMContentLoaded', function() {
             }).then(response => {
                 if (response.ok) {
                     console.log('Cardio workout logged successfully!');
+                    response.json().then(announceRecords);
                     fetchLoggedCardioWorkouts();
                     resetForm('cardio-workout-log-form');
                 } else {
-                    console.error('Failed to log cardio workout.');
+                    errorMessage(response).then(message => {
+                        console.error(`Failed to log cardio workout: ${message}`);
+                        alert(`Failed to log cardio workout: ${message}`);
+                    });
                 }
             }).catch(error => {
                 console.error('Error logging cardio workout:', error);
@@ -1,5 +1,8 @@
 document.addEventListener('DOMContentLoaded', function() {
     if (document.getElementById('log-weights-workout')) {
+        withUnits(units => {
+            document.getElementById('unit').value = units === 'imperial' ? 'lb' : 'kg';
+        });
         // Fetch and display exercises for the default selected workout type
         const defaultWorkoutType = document.getElementById('workout-type').value;
         fetchExercises(defaultWorkoutType);
@@ -11,9 +14,16 @@ document.addEventListener('DOMContentLoaded', function() {
 
         function fetchExercises(workoutType) {
             console.log(`Fetching exercises for workout type: ${workoutType}`);
-            fetch(`/workout/weight-workouts?type=${workoutType}`)
-                .then(response => response.json())
-                .then(exercises => {
+            Promise.all([
+                fetch(`/workout/weight-workouts?type=${workoutType}`).then(response => response.json()),
+                fetch(`/workout/suggest/weights?type=${workoutType}`)
+                    .then(response => response.ok ? response.json() : { exercises: [] })
+                    .catch(() => ({ exercises: [] }))
+            ])
+                .then(([exercises, suggestion]) => {
+                    // Suggested weight and reps for the next session, by exercise name
+                    const suggestions = {};
+                    (suggestion.exercises || []).forEach(s => { suggestions[s.name.toLowerCase()] = s; });
                     console.log('Fetched exercises:', exercises);
                     const exercisesContainer = document.getElementById('exercises');
                     exercisesContainer.innerHTML = '';
@@ -25,9 +35,8 @@ document.addEventListener('DOMContentLoaded', function() {
                             <thead>
                                 <tr>
                                     <th>Exercise</th>
-                                    <th>Set 1 Weight</th>
-                                    <th>Set 2 Weight</th>
-                                    <th>Set 3 Weight</th>
+                                    <th>Sets (reps x weight)</th>
+                                    <th></th>
                                 </tr>
                             </thead>
                             <tbody></tbody>
@@ -35,12 +44,19 @@ document.addEventListener('DOMContentLoaded', function() {
                         const tbody = table.querySelector('tbody');
                         exercises.forEach(exercise => {
                             const row = document.createElement('tr');
+                            row.dataset.exercise = exercise.exercise;
+                            const suggested = suggestions[exercise.exercise.toLowerCase()] || {};
                             row.innerHTML = `
-                                <td>${exercise.exercise}</td>
-                                <td><input type="number" name="${exercise.exercise}-set1" value="0"></td>
-                                <td><input type="number" name="${exercise.exercise}-set2" value="0"></td>
-                                <td><input type="number" name="${exercise.exercise}-set3" value="0"></td>
+                                <td>${exercise.exercise}${suggested.reason ? `<br><small title="${suggested.reason}">Suggested: ${suggested.weight ? `${suggested.sets} x ${suggested.reps} @ ${suggested.weight} ${suggested.unit}` : 'no history'}</small>` : ''}</td>
+                                <td class="sets"></td>
+                                <td><button type="button" class="add-set">Add Set</button></td>
                             `;
+                            for (let i = 0; i < (suggested.sets || 3); i++) {
+                                addSet(row.querySelector('.sets'), suggested);
+                            }
+                            row.querySelector('.add-set').addEventListener('click', function() {
+                                addSet(row.querySelector('.sets'), suggested);
+                            });
                             tbody.appendChild(row);
                         });
                         exercisesContainer.appendChild(table);
@@ -50,6 +66,23 @@ document.addEventListener('DOMContentLoaded', function() {
                     console.error('Error fetching exercises:', error);
                 });
         }
+
+        // addSet adds a set row, prefilled with the suggested reps and weight if any
+        function addSet(setsCell, suggested = {}) {
+            const set = document.createElement('div');
+            set.className = 'set';
+            set.innerHTML = `
+                <input type="number" class="set-reps" min="0" value="${suggested.reps || 0}" title="Reps">
+                x
+                <input type="number" class="set-weight" min="0" step="0.5" value="${suggested.weight || 0}" title="Weight">
+                <select class="set-type" title="Set type">
+                    <option value="warmup">Warm-up</option>
+                    <option value="working" selected>Working</option>
+                    <option value="drop">Drop</option>
+                </select>
+            `;
+            setsCell.appendChild(set);
+        }
     }
 
     if (document.getElementById('weights-log-form')) {
@@ -57,19 +90,24 @@ document.addEventListener('DOMContentLoaded', function() {
             event.preventDefault();
             const formData = new FormData(event.target);
             const workoutType = formData.get('workout-type');
+            const unit = formData.get('unit');
             const exercises = Array.from(document.querySelectorAll('#exercises tbody tr')).map(row => {
-                const exerciseName = row.querySelector('td').innerText;
+                const sets = Array.from(row.querySelectorAll('.set')).map((set, index) => ({
+                    set_number: index + 1,
+                    reps: parseInt(set.querySelector('.set-reps').value || 0, 10),
+                    weight: parseFloat(set.querySelector('.set-weight').value || 0),
+                    unit: unit,
+                    set_type: set.querySelector('.set-type').value
+                }));
                 return {
-                    name: exerciseName,
-                    set1: parseInt(formData.get(`${exerciseName}-set1`) || 0, 10),
-                    set2: parseInt(formData.get(`${exerciseName}-set2`) || 0, 10),
-                    set3: parseInt(formData.get(`${exerciseName}-set3`) || 0, 10)
+                    name: row.dataset.exercise,
+                    sets: sets
                 };
             });
             const weightsLog = {
                 workout_type: workoutType,
                 exercises: exercises,
-                date: new Date().toISOString() // Set the current date and time
+                performed_at: performedAt(formData.get('performed-at'))
             };
             console.log('Logging weights workout:', weightsLog);
             fetch('/workout/log/weights', {
@@ -81,12 +119,16 @@ document.addEventListener('DOMContentLoaded', function() {
             }).then(response => {
                 if (response.ok) {
                     console.log('Weights workout logged successfully!');
+                    response.json().then(announceRecords);
                     fetchLoggedWeightsWorkouts();
                     fetchLastLoggedWorkouts();
                     resetForm('weights-log-form');
                     document.getElementById('exercises').innerHTML = ''; // Clear exercises table
                 } else {
-                    console.error('Failed to log weights workout.');
+                    errorMessage(response).then(message => {
+                        console.error(`Failed to log weights workout: ${message}`);
+                        alert(`Failed to log weights workout: ${message}`);
+                    });
                 }
             }).catch(error => {
                 console.error('Error logging weights workout:', error);
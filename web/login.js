@@ -0,0 +1,57 @@
+document.addEventListener('DOMContentLoaded', function() {
+    const message = document.getElementById('login-message');
+
+    function submitCredentials(url, form) {
+        const formData = new FormData(form);
+        return fetch(url, {
+            method: 'POST',
+            headers: {
+                'Content-Type': 'application/json'
+            },
+            body: JSON.stringify({
+                username: formData.get('username'),
+                password: formData.get('password')
+            })
+        });
+    }
+
+    document.getElementById('login-form').addEventListener('submit', function(event) {
+        event.preventDefault();
+        submitCredentials('/auth/login', event.target)
+            .then(response => {
+                if (response.ok) {
+                    location.href = 'index.html';
+                } else {
+                    message.textContent = 'Invalid username or password.';
+                }
+            })
+            .catch(error => {
+                console.error('Error logging in:', error);
+                message.textContent = 'Error logging in. Please try again later.';
+            });
+    });
+
+    document.getElementById('register-form').addEventListener('submit', function(event) {
+        event.preventDefault();
+        const form = event.target;
+        submitCredentials('/auth/register', form)
+            .then(response => {
+                if (!response.ok) {
+                    return response.json().then(error => {
+                        const details = (error.details || []).map(detail => `${detail.field} ${detail.message}`);
+                        throw new Error(details.length ? details.join('; ') : error.message);
+                    });
+                }
+                return submitCredentials('/auth/login', form);
+            })
+            .then(response => {
+                if (response.ok) {
+                    location.href = 'index.html';
+                }
+            })
+            .catch(error => {
+                console.error('Error registering:', error);
+                message.textContent = `Registration failed: ${error.message}`;
+            });
+    });
+});
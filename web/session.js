@@ -0,0 +1,80 @@
+// Send the user to the login page whenever an API call reports that the
+// session is missing or has expired.
+const originalFetch = window.fetch;
+window.fetch = function(...args) {
+    return originalFetch(...args).then(response => {
+        if (response.status === 401 && !location.pathname.endsWith('login.html')) {
+            location.href = 'login.html';
+        }
+        return response;
+    });
+};
+
+// withUnits calls back with the logged-in user's unit system, metric or
+// imperial.
+function withUnits(callback) {
+    fetch('/auth/me')
+        .then(response => response.ok ? response.json() : null)
+        .then(user => callback((user && user.units) || 'metric'))
+        .catch(error => {
+            console.error('Error fetching unit preference:', error);
+        });
+}
+
+// setUnits saves the logged-in user's unit system.
+function setUnits(units) {
+    return updateUser({ units: units });
+}
+
+// withTimeZone calls back with the logged-in user's time zone, or an empty
+// string when their days follow the server's time zone.
+function withTimeZone(callback) {
+    fetch('/auth/me')
+        .then(response => response.ok ? response.json() : null)
+        .then(user => callback((user && user.time_zone) || ''))
+        .catch(error => {
+            console.error('Error fetching time zone:', error);
+        });
+}
+
+// setTimeZone saves the time zone whose midnight starts the logged-in user's
+// days.
+function setTimeZone(timeZone) {
+    return updateUser({ time_zone: timeZone });
+}
+
+// updateUser saves some of the logged-in user's preferences.
+function updateUser(fields) {
+    return fetch('/auth/me', {
+        method: 'PATCH',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify(fields)
+    });
+}
+
+// performedAt turns the value of an optional datetime-local input into the
+// RFC 3339 timestamp sent as performed_at, or undefined to log it as now.
+function performedAt(value) {
+    return value ? new Date(value).toISOString() : undefined;
+}
+
+// errorMessage resolves to a readable message for a failed API response,
+// naming the invalid fields of its JSON error body.
+function errorMessage(response) {
+    return response.json()
+        .then(error => {
+            const details = (error.details || []).map(detail => `${detail.field} ${detail.message}`);
+            return details.length ? `${error.message}: ${details.join('; ')}` : error.message;
+        })
+        .catch(() => response.statusText);
+}
+
+function logout() {
+    fetch('/auth/logout', { method: 'POST' })
+        .then(() => {
+            location.href = 'login.html';
+        })
+        .catch(error => {
+            console.error('Error logging out:', error);
+        });
+}